@@ -21,12 +21,29 @@ func NewBlock(data []byte) *Block {
 	return &Block{Data: data, Multihash: u.Hash(data)}
 }
 
+// NewBlockWithHashFunc creates a Block object from opaque data, hashing
+// it with the hash function of the given multihash code.
+func NewBlockWithHashFunc(data []byte, code int) (*Block, error) {
+	h, err := u.HashWith(data, code)
+	if err != nil {
+		return nil, err
+	}
+	return &Block{Data: data, Multihash: h}, nil
+}
+
 // NewBlockWithHash creates a new block when the hash of the data
 // is already known, this is used to save time in situations where
 // we are able to be confident that the data is correct
 func NewBlockWithHash(data []byte, h mh.Multihash) (*Block, error) {
 	if u.Debug {
-		chk := u.Hash(data)
+		code, err := u.Key(h).HashFunc()
+		if err != nil {
+			return nil, err
+		}
+		chk, err := u.HashWith(data, code)
+		if err != nil {
+			return nil, err
+		}
 		if string(chk) != string(h) {
 			return nil, errors.New("Data did not match given hash!")
 		}
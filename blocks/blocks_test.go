@@ -1,6 +1,10 @@
 package blocks
 
-import "testing"
+import (
+	"testing"
+
+	u "github.com/ipfs/go-ipfs/util"
+)
 
 func TestBlocksBasic(t *testing.T) {
 
@@ -14,3 +18,26 @@ func TestBlocksBasic(t *testing.T) {
 	// Test some data
 	NewBlock([]byte("Hello world!"))
 }
+
+func TestBlockHashFuncs(t *testing.T) {
+	data := []byte("Hello world!")
+	for name, code := range u.HashFuncs {
+		b, err := NewBlockWithHashFunc(data, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bcode, err := b.Key().HashFunc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bcode != code {
+			t.Fatalf("%s: block hashed with %d, expected %d", name, bcode, code)
+		}
+
+		_, err = NewBlockWithHash(data, b.Multihash)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	dsq "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/query"
 	ds_sync "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/sync"
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 
 	blocks "github.com/ipfs/go-ipfs/blocks"
@@ -266,3 +267,28 @@ func (c *queryTestDS) Query(q dsq.Query) (dsq.Results, error) {
 	}
 	return c.ds.Query(q)
 }
+
+func TestPutThenGetBlockHashFunc(t *testing.T) {
+	debug := u.Debug
+	u.Debug = true // verify blocks against their keys
+	defer func() { u.Debug = debug }()
+
+	bs := NewBlockstore(ds_sync.MutexWrap(ds.NewMapDatastore()))
+	block, err := blocks.NewBlockWithHashFunc([]byte("some data"), mh.SHA3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = bs.Put(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockFromBlockstore, err := bs.Get(block.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(block.Data, blockFromBlockstore.Data) {
+		t.Fail()
+	}
+}
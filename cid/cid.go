@@ -0,0 +1,280 @@
+// package cid implements self-describing content identifiers.
+//
+// A version 1 Cid is a multihash prefixed with a version and a codec, which
+// names the format of the content the multihash addresses. It is written as
+// a multibase string, whose first character names the base used:
+//
+//	<multibase prefix><varint version><varint codec><multihash>
+//
+// A version 0 Cid is a bare multihash, as used by ipfs before cids existed.
+// It always addresses a protobuf merkledag node, and is written in base58
+// with no prefix. Every existing key is a valid version 0 Cid.
+package cid
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	b58 "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-base58"
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+// Codecs of the content a Cid addresses, from the multicodec table.
+const (
+	Raw         = 0x55
+	DagProtobuf = 0x70
+	DagCBOR     = 0x71
+)
+
+// Codecs maps the names of supported codecs to their codes.
+var Codecs = map[string]uint64{
+	"raw":      Raw,
+	"dag-pb":   DagProtobuf,
+	"dag-cbor": DagCBOR,
+}
+
+// CodecToStr maps the codes of supported codecs to their names.
+var CodecToStr = map[uint64]string{
+	Raw:         "raw",
+	DagProtobuf: "dag-pb",
+	DagCBOR:     "dag-cbor",
+}
+
+// Multibase prefixes of the supported string encodings.
+const (
+	Base16    = 'f'
+	Base32    = 'b'
+	Base58BTC = 'z'
+)
+
+// Bases maps the names of supported string encodings to their prefixes.
+var Bases = map[string]byte{
+	"base16":    Base16,
+	"base32":    Base32,
+	"base58btc": Base58BTC,
+}
+
+var base32Encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567")
+
+var (
+	ErrVarintTooBig       = errors.New("cid: varint too big")
+	ErrVarintTruncated    = errors.New("cid: varint truncated")
+	ErrInvalidEncoding    = errors.New("cid: invalid encoding")
+	ErrUnknownBase        = errors.New("cid: unknown multibase")
+	ErrV0Incompatible     = errors.New("cid: only dag-pb objects hashed with sha2-256 have a version 0 cid")
+	ErrUnsupportedVersion = errors.New("cid: unsupported cid version")
+)
+
+// Cid is a content identifier.
+type Cid struct {
+	version uint64
+	codec   uint64
+	hash    mh.Multihash
+}
+
+// NewCidV0 returns the version 0 Cid of the given multihash.
+func NewCidV0(h mh.Multihash) *Cid {
+	return &Cid{version: 0, codec: DagProtobuf, hash: h}
+}
+
+// NewCidV1 returns the version 1 Cid of content with the given codec and
+// multihash.
+func NewCidV1(codec uint64, h mh.Multihash) *Cid {
+	return &Cid{version: 1, codec: codec, hash: h}
+}
+
+// Version returns the version of c, 0 or 1.
+func (c *Cid) Version() uint64 {
+	return c.version
+}
+
+// Codec returns the codec of the content c addresses.
+func (c *Cid) Codec() uint64 {
+	return c.codec
+}
+
+// Hash returns the multihash of the content c addresses.
+func (c *Cid) Hash() mh.Multihash {
+	return c.hash
+}
+
+// Key returns the key under which the content c addresses is stored.
+func (c *Cid) Key() u.Key {
+	return u.Key(c.hash)
+}
+
+// Equals reports whether c and o are the same Cid.
+func (c *Cid) Equals(o *Cid) bool {
+	return c.version == o.version && c.codec == o.codec && bytes.Equal(c.hash, o.hash)
+}
+
+// Bytes returns the binary form of c. For version 0 it is the multihash.
+func (c *Cid) Bytes() []byte {
+	if c.version == 0 {
+		return []byte(c.hash)
+	}
+
+	buf := make([]byte, 2*binary.MaxVarintLen64+len(c.hash))
+	n := binary.PutUvarint(buf, c.version)
+	n += binary.PutUvarint(buf[n:], c.codec)
+	n += copy(buf[n:], c.hash)
+	return buf[:n]
+}
+
+// String returns c in its default string form: base58 for version 0, and
+// base58btc multibase for version 1.
+func (c *Cid) String() string {
+	if c.version == 0 {
+		return c.hash.B58String()
+	}
+	s, _ := c.Encode(Base58BTC)
+	return s
+}
+
+// Encode returns c as a multibase string in the given base. Version 0 Cids
+// have no multibase prefix, so they may only be written in base58.
+func (c *Cid) Encode(base byte) (string, error) {
+	if c.version == 0 {
+		if base != Base58BTC {
+			return "", ErrV0Incompatible
+		}
+		return c.hash.B58String(), nil
+	}
+
+	b := c.Bytes()
+	switch base {
+	case Base16:
+		return string(Base16) + hex.EncodeToString(b), nil
+	case Base32:
+		return string(Base32) + strings.TrimRight(base32Encoding.EncodeToString(b), "="), nil
+	case Base58BTC:
+		return string(Base58BTC) + b58.Encode(b), nil
+	default:
+		return "", ErrUnknownBase
+	}
+}
+
+// ToVersion returns the Cid addressing the same content as c with the given
+// version. Only dag-pb content hashed with sha2-256 has a version 0 Cid.
+func (c *Cid) ToVersion(v uint64) (*Cid, error) {
+	switch v {
+	case 0:
+		if c.codec != DagProtobuf {
+			return nil, ErrV0Incompatible
+		}
+		dh, err := mh.Decode(c.hash)
+		if err != nil || dh.Code != mh.SHA2_256 || dh.Length != 32 {
+			return nil, ErrV0Incompatible
+		}
+		return NewCidV0(c.hash), nil
+	case 1:
+		return NewCidV1(c.codec, c.hash), nil
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+}
+
+// Decode parses a Cid from its string form. Besides the multibase forms of
+// version 1 Cids, any base58 multihash is accepted as a version 0 Cid.
+func Decode(s string) (*Cid, error) {
+	if len(s) < 2 {
+		return nil, ErrInvalidEncoding
+	}
+
+	// base58 sha2-256 multihashes always start with "Qm".
+	if len(s) != 46 || s[:2] != "Qm" {
+		if b, err := decodeBase(s[0], s[1:]); err == nil {
+			if c, err := Cast(b); err == nil && c.version == 1 {
+				return c, nil
+			}
+		}
+	}
+
+	h, err := mh.FromB58String(s)
+	if err != nil {
+		return nil, ErrInvalidEncoding
+	}
+	return NewCidV0(h), nil
+}
+
+func decodeBase(base byte, s string) ([]byte, error) {
+	switch base {
+	case Base16:
+		return hex.DecodeString(s)
+	case Base32:
+		if pad := len(s) % 8; pad != 0 {
+			s += strings.Repeat("=", 8-pad)
+		}
+		return base32Encoding.DecodeString(s)
+	case Base58BTC:
+		b := b58.Decode(s)
+		if len(b) == 0 {
+			return nil, ErrInvalidEncoding
+		}
+		return b, nil
+	default:
+		return nil, ErrUnknownBase
+	}
+}
+
+// Cast parses a Cid from its binary form. Binary version 0 Cids are bare
+// multihashes, which never start with the byte 0x01.
+func Cast(b []byte) (*Cid, error) {
+	if len(b) > 0 && b[0] == 1 {
+		return castV1(b)
+	}
+
+	h, err := mh.Cast(b)
+	if err != nil {
+		return nil, err
+	}
+	return NewCidV0(h), nil
+}
+
+func castV1(b []byte) (*Cid, error) {
+	vers, n, err := uvarint(b)
+	if err != nil {
+		return nil, err
+	}
+	if vers != 1 {
+		return nil, ErrUnsupportedVersion
+	}
+	b = b[n:]
+
+	codec, n, err := uvarint(b)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := mh.Cast(b[n:])
+	if err != nil {
+		return nil, err
+	}
+	return NewCidV1(codec, h), nil
+}
+
+func uvarint(b []byte) (uint64, int, error) {
+	v, n := binary.Uvarint(b)
+	switch {
+	case n == 0:
+		return 0, 0, ErrVarintTruncated
+	case n < 0:
+		return 0, 0, ErrVarintTooBig
+	}
+	return v, n, nil
+}
+
+// CodecName returns the name of codec, or its hex code if it is unknown.
+func CodecName(codec uint64) string {
+	if s, ok := CodecToStr[codec]; ok {
+		return s
+	}
+	return fmt.Sprintf("0x%x", codec)
+}
@@ -0,0 +1,134 @@
+package cid
+
+import (
+	"bytes"
+	"testing"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+func TestV0RoundTrip(t *testing.T) {
+	s := "QmVr26fY1tKyspEJBniVhqxQeEjhF78XerGiqWAwraVLQH"
+	c, err := Decode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 0 || c.Codec() != DagProtobuf {
+		t.Fatalf("got version %d codec %x", c.Version(), c.Codec())
+	}
+	if c.String() != s {
+		t.Fatalf("%s != %s", c.String(), s)
+	}
+	if c.Key() != u.B58KeyDecode(s) {
+		t.Fatal("key does not match the multihash")
+	}
+	if !bytes.Equal(c.Bytes(), c.Hash()) {
+		t.Fatal("v0 binary form is not the multihash")
+	}
+}
+
+func TestV1RoundTrip(t *testing.T) {
+	h, err := mh.Sum([]byte("hello"), mh.SHA3, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, codec := range []uint64{Raw, DagProtobuf, DagCBOR} {
+		c := NewCidV1(codec, h)
+		for name, base := range Bases {
+			s, err := c.Encode(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s[0] != base {
+				t.Fatalf("%s: %q has the wrong prefix", name, s)
+			}
+
+			out, err := Decode(s)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			if !out.Equals(c) {
+				t.Fatalf("%s: decoded %s, expected %s", name, out, c)
+			}
+		}
+
+		out, err := Cast(c.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !out.Equals(c) {
+			t.Fatalf("cast %s, expected %s", out, c)
+		}
+	}
+}
+
+func TestLegacyMultihash(t *testing.T) {
+	h, err := mh.Sum([]byte("hello"), mh.SHA3, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Decode(h.B58String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 0 || !bytes.Equal(c.Hash(), h) {
+		t.Fatal("bare multihash did not decode as version 0")
+	}
+	if c.String() != h.B58String() {
+		t.Fatal("bare multihash did not round trip")
+	}
+}
+
+func TestToVersion(t *testing.T) {
+	h := u.Hash([]byte("hello"))
+	v0 := NewCidV0(h)
+
+	v1, err := v0.ToVersion(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.Version() != 1 || v1.Codec() != DagProtobuf {
+		t.Fatal("bad version 1 cid")
+	}
+	if v1.String()[0] != Base58BTC {
+		t.Fatalf("%s has no multibase prefix", v1)
+	}
+
+	back, err := v1.ToVersion(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.Equals(v0) {
+		t.Fatal("did not convert back to version 0")
+	}
+
+	if _, err := NewCidV1(Raw, h).ToVersion(0); err != ErrV0Incompatible {
+		t.Fatal("converted raw cid to version 0")
+	}
+
+	sha3, _ := mh.Sum([]byte("hello"), mh.SHA3, -1)
+	if _, err := NewCidV1(DagProtobuf, sha3).ToVersion(0); err != ErrV0Incompatible {
+		t.Fatal("converted sha3 cid to version 0")
+	}
+
+	short, _ := mh.Sum([]byte("hello"), mh.SHA2_256, 20)
+	if _, err := NewCidV1(DagProtobuf, short).ToVersion(0); err != ErrV0Incompatible {
+		t.Fatal("converted truncated sha2-256 cid to version 0")
+	}
+
+	if _, err := v0.Encode(Base32); err != ErrV0Incompatible {
+		t.Fatal("encoded version 0 cid in base32")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, s := range []string{"", "z", "zzzz", "b", "f01", "f0155", "Qm", "not a cid"} {
+		if _, err := Decode(s); err == nil {
+			t.Fatalf("decoded invalid cid %q", s)
+		}
+	}
+}
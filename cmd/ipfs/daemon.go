@@ -230,7 +230,11 @@ func daemonFunc(req cmds.Request, res cmds.Response) {
 			var opts = []corehttp.ServeOption{
 				corehttp.VersionOption(),
 				corehttp.IPNSHostnameOption(),
-				corehttp.GatewayOption(writable),
+				corehttp.NewGateway(corehttp.GatewayConfig{
+					Writable:  writable,
+					BlockList: &corehttp.BlockList{},
+					ReadAhead: cfg.Gateway.ReadAhead,
+				}).ServeOption(),
 			}
 			if rootRedirect != nil {
 				opts = append(opts, rootRedirect)
@@ -105,6 +105,7 @@ var cmdDetailsMap = map[*cmds.Command]cmdDetails{
 	commandsClientCmd:          cmdDetails{doesNotUseRepo: true},
 	commands.CommandsDaemonCmd: cmdDetails{doesNotUseRepo: true},
 	commands.DiagCmd:           cmdDetails{cannotRunOnClient: true},
+	commands.CidCmd:            cmdDetails{doesNotUseRepo: true},
 	commands.VersionCmd:        cmdDetails{doesNotUseConfigAsInput: true, doesNotUseRepo: true}, // must be permitted to run before init
 	commands.UpdateCmd:         cmdDetails{preemptsAutoUpdate: true, cannotRunOnDaemon: true},
 	commands.UpdateCheckCmd:    cmdDetails{preemptsAutoUpdate: true},
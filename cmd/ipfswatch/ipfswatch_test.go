@@ -1,6 +1,9 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ipfs/go-ipfs/thirdparty/assert"
@@ -11,3 +14,65 @@ func TestIsHidden(t *testing.T) {
 	assert.False(IsHidden("."), t, ". for current dir should not be considered hidden")
 	assert.False(IsHidden("bar/baz"), t, "normal dirs should not be hidden")
 }
+
+func TestIgnored(t *testing.T) {
+	root, err := ioutil.TempDir("", "ipfswatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.MkdirAll(filepath.Join(root, "src", "build"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".ipfsignore"), []byte("*.o\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "src", ".ipfsignore"), []byte("build/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"src/main.c", false, false},
+		{"src/main.o", false, true},
+		{"src/build", true, true},
+		{"src/build/out", false, true},
+		{"build", true, false},
+		{".git", true, true},
+		{"src/.main.c.swp", false, true},
+	}
+	ig := newIgnores(root, false)
+	for _, c := range cases {
+		ignored, err := ig.ignored(filepath.Join(root, c.path), c.isDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(ignored == c.ignored, t, c.path+" ignored wrongly")
+	}
+
+	// a changed IgnoreFile is read again once forgotten
+	if err := ioutil.WriteFile(filepath.Join(root, "src", ".ipfsignore"), []byte("*.c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ignored, err := ig.ignored(filepath.Join(root, "src", "main.c"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(ignored, t, "the filters should be kept until forgotten")
+
+	ig.forget(filepath.Join(root, "src"))
+	ignored, err = ig.ignored(filepath.Join(root, "src", "main.c"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(ignored, t, "the changed IgnoreFile should be read again")
+	ignored, err = ig.ignored(filepath.Join(root, "src", "build"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(ignored, t, "the old rules should be gone")
+}
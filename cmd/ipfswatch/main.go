@@ -6,12 +6,14 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 
 	process "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/goprocess"
 	homedir "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/mitchellh/go-homedir"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	fsnotify "github.com/ipfs/go-ipfs/Godeps/_workspace/src/gopkg.in/fsnotify.v1"
 	commands "github.com/ipfs/go-ipfs/commands"
+	files "github.com/ipfs/go-ipfs/commands/files"
 	core "github.com/ipfs/go-ipfs/core"
 	corehttp "github.com/ipfs/go-ipfs/core/corehttp"
 	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
@@ -22,6 +24,7 @@ import (
 var http = flag.Bool("http", false, "expose IPFS HTTP API")
 var repoPath = flag.String("repo", os.Getenv("IPFS_PATH"), "IPFS_PATH to use")
 var watchPath = flag.String("path", ".", "the path to watch")
+var hidden = flag.Bool("hidden", false, "also watch and add hidden files")
 
 func main() {
 	flag.Parse()
@@ -61,7 +64,8 @@ func run(ipfsPath, watchPath string) error {
 	}
 	defer watcher.Close()
 
-	if err := addTree(watcher, watchPath); err != nil {
+	ig := newIgnores(watchPath, *hidden)
+	if err := addTree(watcher, ig, watchPath); err != nil {
 		return err
 	}
 
@@ -100,13 +104,21 @@ func run(ipfsPath, watchPath string) error {
 			return nil
 		case e := <-watcher.Events:
 			log.Printf("received event: %s", e)
+			if filepath.Base(e.Name) == files.IgnoreFile {
+				ig.forget(filepath.Dir(e.Name))
+			}
 			isDir, err := IsDirectory(e.Name)
 			if err != nil {
 				continue
 			}
+			skip, err := ig.ignored(e.Name, isDir)
+			if err != nil || skip {
+				continue
+			}
 			switch e.Op {
 			case fsnotify.Remove:
 				if isDir {
+					ig.forget(e.Name)
 					if err := watcher.Remove(e.Name); err != nil {
 						return err
 					}
@@ -117,7 +129,7 @@ func run(ipfsPath, watchPath string) error {
 				switch e.Op {
 				case fsnotify.Create:
 					if isDir {
-						addTree(watcher, e.Name)
+						addTree(watcher, ig, e.Name)
 					}
 				}
 				proc.Go(func(p process.Process) {
@@ -140,26 +152,28 @@ func run(ipfsPath, watchPath string) error {
 	return nil
 }
 
-func addTree(w *fsnotify.Watcher, root string) error {
+// addTree watches root, and the directories below it that are not
+// skipped.
+func addTree(w *fsnotify.Watcher, ig *ignores, root string) error {
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		isDir, err := IsDirectory(path)
 		if err != nil {
 			log.Println(err)
 			return nil
 		}
-		switch {
-		case isDir && IsHidden(path):
-			log.Println(path)
-			return filepath.SkipDir
-		case isDir:
-			log.Println(path)
-			if err := w.Add(path); err != nil {
-				return err
-			}
-		default:
+		if !info.IsDir() {
 			return nil
 		}
-		return nil
+
+		skip, err := ig.ignored(path, true)
+		if err != nil {
+			return err
+		}
+		if skip {
+			return filepath.SkipDir
+		}
+
+		log.Println(path)
+		return w.Add(path)
 	})
 	if err != nil {
 		return err
@@ -167,6 +181,77 @@ func addTree(w *fsnotify.Watcher, root string) error {
 	return nil
 }
 
+// ignores decides which files of the watched tree are hidden or ignored.
+// The filters of its directories are kept, so that their IgnoreFiles are
+// only read again once they change.
+type ignores struct {
+	root    string
+	hidden  bool
+	filters map[string]*files.Filter // of the files of each directory
+}
+
+func newIgnores(root string, hidden bool) *ignores {
+	return &ignores{
+		root:    filepath.Clean(root),
+		hidden:  hidden,
+		filters: make(map[string]*files.Filter),
+	}
+}
+
+// ignored returns whether the file at path, within the root, is hidden or
+// ignored, or is in a directory that is.
+func (ig *ignores) ignored(path string, isDir bool) (bool, error) {
+	filter, err := ig.enter(files.NewFilter(ig.hidden), ig.root)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(ig.root, path)
+	if err != nil || rel == "." {
+		return false, err
+	}
+
+	dir := ig.root
+	names := strings.Split(rel, string(filepath.Separator))
+	for i, name := range names {
+		dir = filepath.Join(dir, name)
+		if i == len(names)-1 && !isDir {
+			return filter.Skip(dir, false), nil
+		}
+		if filter.Skip(dir, true) {
+			return true, nil
+		}
+		if filter, err = ig.enter(filter, dir); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// enter returns the filter of the files of dir, given the one of its
+// parent.
+func (ig *ignores) enter(parent *files.Filter, dir string) (*files.Filter, error) {
+	if f, ok := ig.filters[dir]; ok {
+		return f, nil
+	}
+	f, err := parent.Enter(dir)
+	if err != nil {
+		return nil, err
+	}
+	ig.filters[dir] = f
+	return f, nil
+}
+
+// forget drops the filters of dir and of the directories below it, for
+// when the IgnoreFile of dir changed.
+func (ig *ignores) forget(dir string) {
+	dir = filepath.Clean(dir)
+	for d := range ig.filters {
+		if d == dir || strings.HasPrefix(d, dir+string(filepath.Separator)) {
+			delete(ig.filters, d)
+		}
+	}
+}
+
 func IsDirectory(path string) (bool, error) {
 	fileInfo, err := os.Stat(path)
 	return fileInfo.IsDir(), err
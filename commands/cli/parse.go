@@ -58,7 +58,19 @@ func Parse(input []string, stdin *os.File, root *cmds.Command) (cmds.Request, *c
 		}
 	}
 
-	stringArgs, fileArgs, err := parseArgs(stringVals, stdin, cmd.Arguments, recursive)
+	// if the command has the package builtin hidden path option, files
+	// of directories are filtered, leaving out hidden and ignored ones
+	var filter *files.Filter
+	hiddenOpt := req.Option(cmds.HidShort)
+	if hiddenOpt != nil && hiddenOpt.Definition() == cmds.OptionHiddenPath {
+		hidden, _, err := hiddenOpt.Bool()
+		if err != nil {
+			return req, nil, nil, u.ErrCast()
+		}
+		filter = files.NewFilter(hidden)
+	}
+
+	stringArgs, fileArgs, err := parseArgs(stringVals, stdin, cmd.Arguments, recursive, filter)
 	if err != nil {
 		return req, cmd, path, err
 	}
@@ -138,7 +150,7 @@ func parseOptions(input []string) (map[string]interface{}, []string, error) {
 	return opts, args, nil
 }
 
-func parseArgs(inputs []string, stdin *os.File, argDefs []cmds.Argument, recursive bool) ([]string, []files.File, error) {
+func parseArgs(inputs []string, stdin *os.File, argDefs []cmds.Argument, recursive bool, filter *files.Filter) ([]string, []files.File, error) {
 	// ignore stdin on Windows
 	if runtime.GOOS == "windows" {
 		stdin = nil
@@ -207,7 +219,7 @@ func parseArgs(inputs []string, stdin *os.File, argDefs []cmds.Argument, recursi
 		} else if argDef.Type == cmds.ArgFile {
 			if stdin == nil {
 				// treat stringArg values as file paths
-				fileArgs, inputs, err = appendFile(fileArgs, inputs, argDef, recursive)
+				fileArgs, inputs, err = appendFile(fileArgs, inputs, argDef, recursive, filter)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -263,7 +275,7 @@ func appendStdinAsString(args []string, stdin *os.File) ([]string, *os.File, err
 	return append(args, strings.Split(input, "\n")...), nil, nil
 }
 
-func appendFile(args []files.File, inputs []string, argDef *cmds.Argument, recursive bool) ([]files.File, []string, error) {
+func appendFile(args []files.File, inputs []string, argDef *cmds.Argument, recursive bool, filter *files.Filter) ([]files.File, []string, error) {
 	path := inputs[0]
 
 	file, err := os.Open(path)
@@ -289,7 +301,7 @@ func appendFile(args []files.File, inputs []string, argDef *cmds.Argument, recur
 		}
 	}
 
-	arg, err := files.NewSerialFile(path, file)
+	arg, err := files.NewFilteredSerialFile(path, file, filter)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -49,3 +49,26 @@ type SizeFile interface {
 
 	Size() (int64, error)
 }
+
+// TotalsFile is a File that knows the total size and number of the
+// files, not counting directories, below it before they are read.
+type TotalsFile interface {
+	File
+
+	Totals() (int64, int, error)
+}
+
+// Totals returns the total size and number of the files below f, and
+// whether f could tell them before they are read.
+func Totals(f File) (int64, int, bool) {
+	tf, ok := f.(TotalsFile)
+	if !ok {
+		return 0, 0, false
+	}
+
+	size, count, err := tf.Totals()
+	if err != nil {
+		return 0, 0, false
+	}
+	return size, count, true
+}
@@ -4,6 +4,8 @@ import (
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -192,3 +194,156 @@ bloop
 		t.Error("Expected NextFile to return (nil, EOF)")
 	}
 }
+
+func TestSerialFileSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serialfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a"), []byte("beep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a", filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("nowhere", filepath.Join(dir, "c")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := NewSerialFile(dir, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sf.NextFile(); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"a", "nowhere"} {
+		file, err := sf.NextFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		link, ok := file.(*Symlink)
+		if !ok {
+			t.Fatalf("expected a symlink, got %T", file)
+		}
+		if link.Target != target {
+			t.Fatalf("expected target %q, got %q", target, link.Target)
+		}
+		data, err := ioutil.ReadAll(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != target {
+			t.Fatal("symlink should read as its target")
+		}
+	}
+}
+
+// walkNames returns the paths of all the files of f, relative to root
+func walkNames(t *testing.T, root string, f File) []string {
+	var names []string
+	for {
+		child, err := f.NextFile()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, err := filepath.Rel(root, child.FileName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, rel)
+		if child.IsDirectory() {
+			names = append(names, walkNames(t, root, child)...)
+		}
+	}
+}
+
+func TestFilteredSerialFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serialfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore := "# build outputs\n*.o\nbuild/\n/top.txt\nlogs/**\n!keep.o\n"
+	write(IgnoreFile, ignore)
+	write(".git/config", "x")
+	write("a.c", "x")
+	write("a.o", "x")
+	write("keep.o", "x")
+	write("top.txt", "x")
+	write("build/out", "x")
+	write("logs/today/log", "x")
+	write("src/top.txt", "x")
+	write("src/b.o", "x")
+	write("src/.swp", "x")
+	write("src/build", "not a directory")
+	write("src/"+IgnoreFile, "b.c\n")
+	write("src/b.c", "x")
+	write("c.c", "x")
+
+	// the kept files are "x", but for src/build and the ignore files
+	size := int64(4 + len("not a directory"))
+	tests := []struct {
+		hidden   bool
+		expected string
+		size     int64
+		count    int
+	}{
+		{false, "a.c c.c keep.o logs src src/build src/top.txt", size, 5},
+		{true, ".git .git/config .ipfsignore a.c c.c keep.o logs src src/.ipfsignore src/.swp src/build src/top.txt", size + 2 + int64(len(ignore)+len("b.c\n")), 9},
+	}
+	for _, test := range tests {
+		f, err := os.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf, err := NewFilteredSerialFile(dir, f, NewFilter(test.hidden))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := strings.Join(walkNames(t, dir, sf), " ")
+		if names != test.expected {
+			t.Fatalf("hidden %v: expected %q, got %q", test.hidden, test.expected, names)
+		}
+
+		f, err = os.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf, err = NewFilteredSerialFile(dir, f, NewFilter(test.hidden))
+		if err != nil {
+			t.Fatal(err)
+		}
+		size, count, err := sf.(TotalsFile).Totals()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != test.size {
+			t.Fatalf("hidden %v: expected size %d, got %d", test.hidden, test.size, size)
+		}
+		if count != test.count {
+			t.Fatalf("hidden %v: expected %d files, got %d", test.hidden, test.count, count)
+		}
+	}
+}
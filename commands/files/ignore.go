@@ -0,0 +1,188 @@
+package files
+
+import (
+	"bufio"
+	"os"
+	fp "path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the files listing the paths to skip in a
+// directory, and in the directories below it. They use the format of
+// .gitignore files:
+//
+//   - blank lines, and lines starting with #, are skipped
+//   - a pattern ending with / only matches directories
+//   - a pattern without any other / matches names in any directory, one
+//     with a / matches paths from the directory of the IgnoreFile
+//   - *, ? and [...] match within a name, ** matches any directories
+//   - a pattern starting with ! includes again the paths it matches
+//
+// The last pattern matching a path decides whether it is skipped, and the
+// patterns of a directory come after the ones of its parents.
+const IgnoreFile = ".ipfsignore"
+
+type ignoreRule struct {
+	base    string // the directory of the IgnoreFile
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Filter decides which files of a directory tree are skipped: hidden
+// files, unless asked for, and the ones ignored by IgnoreFiles.
+type Filter struct {
+	hidden bool
+	rules  []ignoreRule
+}
+
+// NewFilter returns a filter skipping hidden files, unless hidden is
+// set, and no others until directories are entered.
+func NewFilter(hidden bool) *Filter {
+	return &Filter{hidden: hidden}
+}
+
+// Enter returns the filter for the files of the directory dir, with the
+// rules of its IgnoreFile, if it has one.
+func (f *Filter) Enter(dir string) (*Filter, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	file, err := os.Open(fp.Join(dir, IgnoreFile))
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules := append([]ignoreRule(nil), f.rules...)
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		if r, ok := parseIgnoreRule(dir, s.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return &Filter{hidden: f.hidden, rules: rules}, nil
+}
+
+// Skip returns whether the file at path is left out. A nil filter skips
+// nothing.
+func (f *Filter) Skip(path string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+
+	path = fp.Clean(path)
+	name := fp.Base(path)
+	if !f.hidden && strings.HasPrefix(name, ".") && name != "." && name != ".." {
+		return true
+	}
+
+	skip := false
+	for _, r := range f.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(r.relative(path)) {
+			skip = !r.negate
+		}
+	}
+	return skip
+}
+
+// relative returns path relative to the directory of the rule
+func (r ignoreRule) relative(path string) string {
+	if r.base == "." {
+		return path
+	}
+	prefix := r.base
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	r := ignoreRule{base: fp.Clean(base)}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// \! and \# start patterns with those characters
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp translates a gitignore pattern to a regular expression.
+func globToRegexp(glob string) string {
+	var expr []string
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr = append(expr, "(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			expr = append(expr, "/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr = append(expr, ".*")
+			i++
+		case c == '*':
+			expr = append(expr, "[^/]*")
+		case c == '?':
+			expr = append(expr, "[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr = append(expr, `\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr = append(expr, "["+class+"]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr = append(expr, regexp.QuoteMeta(glob[i:i+1]))
+		default:
+			expr = append(expr, regexp.QuoteMeta(string(c)))
+		}
+	}
+	return strings.Join(expr, "")
+}
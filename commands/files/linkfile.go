@@ -0,0 +1,58 @@
+package files
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Symlink is a File holding a symbolic link. Reading it yields the link's
+// target; it is never a directory.
+type Symlink struct {
+	filename string
+	Target   string
+	stat     os.FileInfo
+
+	reader io.Reader
+}
+
+func NewLinkFile(filename, target string, stat os.FileInfo) *Symlink {
+	return &Symlink{
+		filename: filename,
+		Target:   target,
+		stat:     stat,
+		reader:   strings.NewReader(target),
+	}
+}
+
+func (f *Symlink) IsDirectory() bool {
+	return false
+}
+
+func (f *Symlink) NextFile() (File, error) {
+	return nil, ErrNotDirectory
+}
+
+func (f *Symlink) FileName() string {
+	return f.filename
+}
+
+func (f *Symlink) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+func (f *Symlink) Close() error {
+	return nil
+}
+
+func (f *Symlink) Stat() os.FileInfo {
+	return f.stat
+}
+
+func (f *Symlink) Size() (int64, error) {
+	return int64(len(f.Target)), nil
+}
+
+func (f *Symlink) Totals() (int64, int, error) {
+	return int64(len(f.Target)), 1, nil
+}
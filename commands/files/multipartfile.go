@@ -1,17 +1,29 @@
 package files
 
 import (
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	multipartFormdataType = "multipart/form-data"
 	multipartMixedType    = "multipart/mixed"
 
+	// applicationSymlink is the media type of parts holding the target of a
+	// symlink
+	applicationSymlink = "application/symlink"
+
 	contentTypeHeader = "Content-Type"
+	modeHeader        = "Mode"
+	mtimeHeader       = "Mtime"
 )
 
 // MultipartFile implements File, and is created from a `multipart.Part`.
@@ -38,6 +50,14 @@ func NewFileFromPart(part *multipart.Part) (File, error) {
 		return nil, err
 	}
 
+	if f.Mediatype == applicationSymlink {
+		target, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		return NewLinkFile(f.FileName(), string(target), nil), nil
+	}
+
 	if f.IsDirectory() {
 		boundary, found := params["boundary"]
 		if !found {
@@ -76,6 +96,43 @@ func (f *MultipartFile) FileName() string {
 	return filename
 }
 
+// Stat returns the mode and modification time sent with the part, or nil
+// if they were not sent.
+func (f *MultipartFile) Stat() os.FileInfo {
+	modeStr := f.Part.Header.Get(modeHeader)
+	mtimeStr := f.Part.Header.Get(mtimeHeader)
+	if modeStr == "" || mtimeStr == "" {
+		return nil
+	}
+
+	mode, err := strconv.ParseUint(modeStr, 8, 32)
+	if err != nil {
+		return nil
+	}
+	secs, nsecs := mtimeStr, "0"
+	if i := strings.Index(mtimeStr, "."); i >= 0 {
+		secs, nsecs = mtimeStr[:i], mtimeStr[i+1:]
+	}
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return nil
+	}
+	ns, err := strconv.ParseInt(nsecs, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	info := &partInfo{
+		name:    path.Base(f.FileName()),
+		mode:    os.FileMode(mode) & os.ModePerm,
+		modTime: time.Unix(s, ns),
+	}
+	if f.IsDirectory() {
+		info.mode |= os.ModeDir
+	}
+	return info
+}
+
 func (f *MultipartFile) Read(p []byte) (int, error) {
 	if f.IsDirectory() {
 		return 0, ErrNotReader
@@ -89,3 +146,17 @@ func (f *MultipartFile) Close() error {
 	}
 	return f.Part.Close()
 }
+
+// partInfo describes a file sent as a multipart part.
+type partInfo struct {
+	name    string
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *partInfo) Name() string       { return i.name }
+func (i *partInfo) Size() int64        { return 0 }
+func (i *partInfo) Mode() os.FileMode  { return i.mode }
+func (i *partInfo) ModTime() time.Time { return i.modTime }
+func (i *partInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *partInfo) Sys() interface{}   { return nil }
@@ -48,3 +48,8 @@ func (f *ReaderFile) Size() (int64, error) {
 	}
 	return f.stat.Size(), nil
 }
+
+func (f *ReaderFile) Totals() (int64, int, error) {
+	size, err := f.Size()
+	return size, 1, err
+}
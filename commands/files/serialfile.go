@@ -22,18 +22,25 @@ type serialFile struct {
 	files   []os.FileInfo
 	stat    os.FileInfo
 	current *os.File
+	filter  *Filter
 }
 
 func NewSerialFile(path string, file *os.File) (File, error) {
+	return NewFilteredSerialFile(path, file, nil)
+}
+
+// NewFilteredSerialFile is like NewSerialFile, but leaves out the files
+// of directories that filter skips. A nil filter skips nothing.
+func NewFilteredSerialFile(path string, file *os.File, filter *Filter) (File, error) {
 	stat, err := file.Stat()
 	if err != nil {
 		return nil, err
 	}
 
-	return newSerialFile(path, file, stat)
+	return newSerialFile(path, file, stat, filter)
 }
 
-func newSerialFile(path string, file *os.File, stat os.FileInfo) (File, error) {
+func newSerialFile(path string, file *os.File, stat os.FileInfo, filter *Filter) (File, error) {
 	// for non-directories, return a ReaderFile
 	if !stat.IsDir() {
 		return &ReaderFile{path, file, stat}, nil
@@ -53,10 +60,36 @@ func newSerialFile(path string, file *os.File, stat os.FileInfo) (File, error) {
 		return nil, err
 	}
 
+	filter, contents, err = filterDir(path, contents, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	// make sure contents are sorted so -- repeatably -- we get the same inputs.
 	sort.Sort(sortFIByName(contents))
 
-	return &serialFile{path, contents, stat, nil}, nil
+	return &serialFile{path, contents, stat, nil, filter}, nil
+}
+
+// filterDir returns the filter of the directory at path, and the contents
+// of the directory it keeps.
+func filterDir(path string, contents []os.FileInfo, filter *Filter) (*Filter, []os.FileInfo, error) {
+	if filter == nil {
+		return nil, contents, nil
+	}
+
+	filter, err := filter.Enter(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kept := contents[:0]
+	for _, stat := range contents {
+		if !filter.Skip(fp.Join(path, stat.Name()), stat.IsDir()) {
+			kept = append(kept, stat)
+		}
+	}
+	return filter, kept, nil
 }
 
 func (f *serialFile) IsDirectory() bool {
@@ -80,18 +113,33 @@ func (f *serialFile) NextFile() (File, error) {
 	stat := f.files[0]
 	f.files = f.files[1:]
 
-	// open the next file
 	filePath := fp.Join(f.path, stat.Name())
+
+	// symlinks are stored as links, rather than as the files they point to
+	if stat.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(filePath)
+		if err != nil {
+			return nil, err
+		}
+		f.current = nil
+		return NewLinkFile(filePath, target, stat), nil
+	}
+
+	// open the next file
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
-	f.current = file
+	// directories are closed as soon as their contents are read
+	f.current = nil
+	if !stat.IsDir() {
+		f.current = file
+	}
 
 	// recursively call the constructor on the next file
 	// if it's a regular file, we will open it as a ReaderFile
 	// if it's a directory, files in it will be opened serially
-	return newSerialFile(filePath, file, stat)
+	return newSerialFile(filePath, file, stat, f.filter)
 }
 
 func (f *serialFile) FileName() string {
@@ -120,31 +168,45 @@ func (f *serialFile) Stat() os.FileInfo {
 }
 
 func (f *serialFile) Size() (int64, error) {
-	return size(f.stat, f.FileName())
+	size, _, err := scan(f.stat, f.FileName(), f.filter)
+	return size, err
+}
+
+func (f *serialFile) Totals() (int64, int, error) {
+	return scan(f.stat, f.FileName(), f.filter)
 }
 
-func size(stat os.FileInfo, filename string) (int64, error) {
+// scan returns the total size and the number of the files below
+// filename, leaving out the ones filter skips
+func scan(stat os.FileInfo, filename string, filter *Filter) (int64, int, error) {
 	if !stat.IsDir() {
-		return stat.Size(), nil
+		return stat.Size(), 1, nil
 	}
 
 	file, err := os.Open(filename)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 	files, err := file.Readdir(0)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 	file.Close()
 
-	var output int64
+	filter, files, err = filterDir(filename, files, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size int64
+	var count int
 	for _, child := range files {
-		s, err := size(child, fp.Join(filename, child.Name()))
+		s, c, err := scan(child, fp.Join(filename, child.Name()), filter)
 		if err != nil {
-			return 0, err
+			return 0, 0, err
 		}
-		output += s
+		size += s
+		count += c
 	}
-	return output, nil
+	return size, count, nil
 }
@@ -69,3 +69,24 @@ func (f *SliceFile) Size() (int64, error) {
 
 	return size, nil
 }
+
+func (f *SliceFile) Totals() (int64, int, error) {
+	var size int64
+	var count int
+
+	for _, file := range f.files {
+		totalsFile, ok := file.(TotalsFile)
+		if !ok {
+			return 0, 0, errors.New("Could not count child files")
+		}
+
+		s, c, err := totalsFile.Totals()
+		if err != nil {
+			return 0, 0, err
+		}
+		size += s
+		count += c
+	}
+
+	return size, count, nil
+}
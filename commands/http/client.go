@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
+	files "github.com/ipfs/go-ipfs/commands/files"
 	config "github.com/ipfs/go-ipfs/repo/config"
 )
 
@@ -76,6 +77,7 @@ func (c *client) Send(req cmds.Request) (cmds.Response, error) {
 	if fileReader != nil {
 		httpReq.Header.Set("Content-Type", "multipart/form-data; boundary="+fileReader.Boundary())
 		httpReq.Header.Set("Content-Disposition", "form-data: name=\"files\"")
+		setTotals(httpReq, req.Files())
 	} else {
 		httpReq.Header.Set("Content-Type", "application/octet-stream")
 	}
@@ -103,6 +105,18 @@ func (c *client) Send(req cmds.Request) (cmds.Response, error) {
 	return res, nil
 }
 
+// setTotals sets the headers giving the total size and number of the files
+// sent, when they can be known before the files are read, for commands to
+// report their progress.
+func setTotals(httpReq *http.Request, f files.File) {
+	size, count, ok := files.Totals(f)
+	if !ok {
+		return
+	}
+	httpReq.Header.Set(filesSizeHeader, strconv.FormatInt(size, 10))
+	httpReq.Header.Set(filesCountHeader, strconv.Itoa(count))
+}
+
 func getQuery(req cmds.Request) (string, error) {
 	query := url.Values{}
 	for k, v := range req.Options() {
@@ -29,6 +29,8 @@ const (
 	contentTypeHeader      = "Content-Type"
 	contentLengthHeader    = "Content-Length"
 	transferEncodingHeader = "Transfer-Encoding"
+	filesSizeHeader        = "X-Files-Size"
+	filesCountHeader       = "X-Files-Count"
 	applicationJson        = "application/json"
 )
 
@@ -72,7 +74,7 @@ func (i Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if len(i.origin) > 0 {
 		w.Header().Set("Access-Control-Allow-Origin", i.origin)
 	}
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join([]string{contentTypeHeader, filesSizeHeader, filesCountHeader}, ", "))
 
 	req, err := Parse(r, i.root)
 	if err != nil {
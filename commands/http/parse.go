@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
@@ -109,7 +110,14 @@ func Parse(r *http.Request, root *cmds.Command) (cmds.Request, error) {
 		return nil, fmt.Errorf("File argument '%s' is required", requiredFile)
 	}
 
-	req, err := cmds.NewRequest(path, opts, args, f, cmd, optDefs)
+	var file files.File = f
+	if f != nil {
+		if sf, ok := parseTotals(r, f); ok {
+			file = sf
+		}
+	}
+
+	req, err := cmds.NewRequest(path, opts, args, file, cmd, optDefs)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +130,37 @@ func Parse(r *http.Request, root *cmds.Command) (cmds.Request, error) {
 	return req, nil
 }
 
+// sizedFile is the root of the files of a request, whose total size and
+// number were sent by the client.
+type sizedFile struct {
+	*files.MultipartFile
+
+	size  int64
+	count int
+}
+
+func (f *sizedFile) Size() (int64, error) {
+	return f.size, nil
+}
+
+func (f *sizedFile) Totals() (int64, int, error) {
+	return f.size, f.count, nil
+}
+
+// parseTotals returns f, with the total size and number of its files,
+// if the request has headers giving them.
+func parseTotals(r *http.Request, f *files.MultipartFile) (files.File, bool) {
+	size, err := strconv.ParseInt(r.Header.Get(filesSizeHeader), 10, 64)
+	if err != nil || size < 0 {
+		return nil, false
+	}
+	count, err := strconv.Atoi(r.Header.Get(filesCountHeader))
+	if err != nil || count < 0 {
+		return nil, false
+	}
+	return &sizedFile{f, size, count}, true
+}
+
 func parseOptions(r *http.Request) (map[string]interface{}, []string) {
 	opts := make(map[string]interface{})
 	var args []string
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	files "github.com/ipfs/go-ipfs/commands/files"
+)
+
+func TestParseTotals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parse-totals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a", "sub/b", "sub/c"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := &cmds.Command{
+		Subcommands: map[string]*cmds.Command{
+			"add": &cmds.Command{
+				Arguments: []cmds.Argument{
+					cmds.FileArg("path", true, true, "").EnableRecursive(),
+				},
+			},
+		},
+	}
+
+	newRequest := func(withTotals bool) *http.Request {
+		f, err := os.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf, err := files.NewSerialFile(dir, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf = files.NewSliceFile("", []files.File{sf})
+
+		mfr := NewMultiFileReader(sf, true)
+		url := fmt.Sprintf("http://127.0.0.1%s/add", ApiPath)
+		httpReq, err := http.NewRequest("POST", url, mfr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpReq.Header.Set(contentTypeHeader, "multipart/form-data; boundary="+mfr.Boundary())
+		if withTotals {
+			setTotals(httpReq, sf)
+		}
+		return httpReq
+	}
+
+	req, err := Parse(newRequest(true), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, count, ok := files.Totals(req.Files())
+	if !ok {
+		t.Fatal("expected the totals of the files to be known")
+	}
+	if size != 11 || count != 3 {
+		t.Fatalf("expected 11 bytes in 3 files, got %d bytes in %d files", size, count)
+	}
+
+	req, err = Parse(newRequest(false), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := files.Totals(req.Files()); ok {
+		t.Fatal("expected the totals of the files to be unknown without headers")
+	}
+}
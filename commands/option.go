@@ -159,11 +159,14 @@ const (
 	RecShort = "r"
 	RecLong  = "recursive"
 	ChanOpt  = "stream-channels"
+	HidShort = "H"
+	HidLong  = "hidden"
 )
 
 // options that are used by this package
 var OptionEncodingType = StringOption(EncShort, EncLong, "The encoding type the output should be encoded with (json, xml, or text)")
 var OptionRecursivePath = BoolOption(RecShort, RecLong, "Add directory paths recursively")
+var OptionHiddenPath = BoolOption(HidLong, HidShort, "Include files that are hidden")
 var OptionStreamChannels = BoolOption(ChanOpt, "Stream channel output")
 
 // global options, added to every command
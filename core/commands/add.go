@@ -1,24 +1,34 @@
 package commands
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"path"
 	"strings"
+	"time"
 
+	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
 	cmds "github.com/ipfs/go-ipfs/commands"
 	files "github.com/ipfs/go-ipfs/commands/files"
 	core "github.com/ipfs/go-ipfs/core"
-	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
+	offline "github.com/ipfs/go-ipfs/exchange/offline"
 	importer "github.com/ipfs/go-ipfs/importer"
 	"github.com/ipfs/go-ipfs/importer/chunk"
+	h "github.com/ipfs/go-ipfs/importer/helpers"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	pinning "github.com/ipfs/go-ipfs/pin"
 	ft "github.com/ipfs/go-ipfs/unixfs"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	u "github.com/ipfs/go-ipfs/util"
 
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/cheggaaa/pb"
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	dssync "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/sync"
 )
 
 // Error indicating the max depth has been exceded.
@@ -28,14 +38,46 @@ var ErrDepthLimitExceeded = fmt.Errorf("depth limit exceeded")
 const progressReaderIncrement = 1024 * 256
 
 const (
-	progressOptionName = "progress"
-	wrapOptionName     = "wrap-with-directory"
+	progressOptionName  = "progress"
+	wrapOptionName      = "wrap-with-directory"
+	rawLeavesOptionName = "raw-leaves"
+	hashOptionName      = "hash"
+	preserveModeName    = "preserve-mode"
+	preserveMtimeName   = "preserve-mtime"
+	mimeOptionName      = "mime"
+	chunkerOptionName   = "chunker"
+	onlyHashOptionName  = "only-hash"
 )
 
+// how many bytes of a file are used to detect its content type
+const sniffLen = 512
+
+// addOptions holds the options that apply to every file of an add
+type addOptions struct {
+	progress      bool
+	rawLeaves     bool
+	hashFunc      int
+	preserveMode  bool
+	preserveMtime bool
+	mime          bool
+	splitter      chunk.BlockSplitter
+
+	// onlyHash computes the hashes of the objects without storing or
+	// pinning them; dserv is where the objects are written
+	onlyHash bool
+	dserv    dag.DAGService
+}
+
+// AddedObject is an event of an add. The first one gives the Total size
+// and number of Files added, when they are known before the files are
+// read; the others report the Bytes of file Name read so far, until its
+// Hash is known.
 type AddedObject struct {
 	Name  string
 	Hash  string `json:",omitempty"`
 	Bytes int64  `json:",omitempty"`
+	Total int64  `json:",omitempty"`
+	Files int    `json:",omitempty"`
 }
 
 var AddCmd = &cmds.Command{
@@ -46,6 +88,39 @@ Adds contents of <path> to ipfs. Use -r to add directories.
 Note that directories are added recursively, to form the ipfs
 MerkleDAG. A smarter partial add with a staging area (like git)
 remains to be implemented.
+
+Files and directories whose name starts with a dot are left out of
+directories, unless --hidden is given. Paths matching the patterns of
+the .ipfsignore files of the directories added, written like .gitignore
+files, are left out too.
+
+With --raw-leaves, file data is stored in raw blocks, rather than in
+unixfs objects. Files of a single block are stored as usual.
+
+--hash selects the hash function objects are hashed with. It may be one
+of sha1, sha2-256 (default), sha2-512, sha3-512 or blake2b.
+
+--preserve-mode and --preserve-mtime record the permissions and the
+modification times of files and directories, to be restored by 'ipfs get'.
+They change the hashes of the objects recorded.
+
+With --mime, the content type of each file is detected from its first
+bytes, and the file is wrapped in a metadata object recording it. The
+gateway serves files with the content type recorded.
+
+--chunker selects how files are split into blocks:
+
+    size-<bytes>             - blocks of a fixed size (default size-262144)
+    rabin                    - content defined blocks, 256KiB on average
+    rabin-<avg>              - rabin, of the given average size
+    rabin-<min>-<avg>-<max>  - rabin, of the given sizes
+    buzhash                  - content defined blocks, using buzhash
+
+Content defined blocks are found again when data is inserted or removed
+in a file, so that versions of a file share most of their blocks.
+
+With --only-hash, the objects are built, and their hashes output, as
+they would be by an add, but they are neither stored nor pinned.
 `,
 	},
 
@@ -54,10 +129,18 @@ remains to be implemented.
 	},
 	Options: []cmds.Option{
 		cmds.OptionRecursivePath, // a builtin option that allows recursive paths (-r, --recursive)
+		cmds.OptionHiddenPath,    // a builtin option that includes hidden files (-H, --hidden)
 		cmds.BoolOption("quiet", "q", "Write minimal output"),
 		cmds.BoolOption(progressOptionName, "p", "Stream progress data"),
 		cmds.BoolOption(wrapOptionName, "w", "Wrap files with a directory object"),
 		cmds.BoolOption("t", "trickle", "Use trickle-dag format for dag generation"),
+		cmds.BoolOption(rawLeavesOptionName, "Store file data in raw blocks"),
+		hashOption,
+		cmds.BoolOption(preserveModeName, "Record the permissions of files and directories"),
+		cmds.BoolOption(preserveMtimeName, "Record the modification times of files and directories"),
+		cmds.BoolOption(mimeOptionName, "Detect and record the content type of files"),
+		cmds.StringOption(chunkerOptionName, "s", "Chunking algorithm to use: size-<bytes>, rabin[-<min>-<avg>-<max>] or buzhash"),
+		cmds.BoolOption(onlyHashOptionName, "n", "Only compute the hashes, without writing to the repo"),
 	},
 	PreRun: func(req cmds.Request) error {
 		if quiet, _, _ := req.Option("quiet").Bool(); quiet {
@@ -65,21 +148,6 @@ remains to be implemented.
 		}
 
 		req.SetOption(progressOptionName, true)
-
-		sizeFile, ok := req.Files().(files.SizeFile)
-		if !ok {
-			// we don't need to error, the progress bar just won't know how big the files are
-			return nil
-		}
-
-		size, err := sizeFile.Size()
-		if err != nil {
-			// see comment above
-			return nil
-		}
-		log.Debugf("Total size of file being added: %v\n", size)
-		req.Values()["size"] = size
-
 		return nil
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
@@ -91,12 +159,64 @@ remains to be implemented.
 
 		progress, _, _ := req.Option(progressOptionName).Bool()
 		wrap, _, _ := req.Option(wrapOptionName).Bool()
+		rawLeaves, _, _ := req.Option(rawLeavesOptionName).Bool()
+		preserveMode, _, _ := req.Option(preserveModeName).Bool()
+		preserveMtime, _, _ := req.Option(preserveMtimeName).Bool()
+		mime, _, _ := req.Option(mimeOptionName).Bool()
+		onlyHash, _, _ := req.Option(onlyHashOptionName).Bool()
+
+		hashFunc, err := getHashFunc(req)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		chunker, _, _ := req.Option(chunkerOptionName).String()
+		splitter, err := chunk.FromString(chunker)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		opts := &addOptions{
+			progress:      progress,
+			rawLeaves:     rawLeaves,
+			hashFunc:      hashFunc,
+			preserveMode:  preserveMode,
+			preserveMtime: preserveMtime,
+			mime:          mime,
+			splitter:      splitter,
+			onlyHash:      onlyHash,
+			dserv:         n.DAG,
+		}
+		// when only hashing, blocks go to a blockservice of their own,
+		// closed once the add is done to stop its workers
+		var discardBS *bserv.BlockService
+		if onlyHash {
+			discardBS, err = discardBlockService()
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+			opts.dserv = dag.NewDAGService(discardBS)
+		}
 
 		outChan := make(chan interface{})
 		res.SetOutput((<-chan interface{})(outChan))
 
 		go func() {
 			defer close(outChan)
+			if discardBS != nil {
+				defer discardBS.Close()
+			}
+
+			if progress {
+				// we don't need to error, the progress bar just won't know how big the files are
+				if size, count, ok := files.Totals(req.Files()); ok {
+					log.Debugf("Total size of files being added: %v (%v files)\n", size, count)
+					outChan <- &AddedObject{Total: size, Files: count}
+				}
+			}
 
 			for {
 				file, err := req.Files().NextFile()
@@ -104,7 +224,7 @@ remains to be implemented.
 					return
 				}
 
-				_, err = addFile(n, file, outChan, progress, wrap)
+				_, err = addFile(n, file, outChan, opts, wrap)
 				if err != nil {
 					return
 				}
@@ -128,39 +248,38 @@ remains to be implemented.
 			return
 		}
 
-		size := int64(0)
-		s, found := req.Values()["size"]
-		if found {
-			size = s.(int64)
-		}
-		showProgressBar := !quiet && size >= progressBarMinSize
-
 		var bar *pb.ProgressBar
-		var terminalWidth int
-		if showProgressBar {
-			bar = pb.New64(size).SetUnits(pb.U_BYTES)
-			bar.ManualUpdate = true
-			bar.Start()
-
-			// the progress bar lib doesn't give us a way to get the width of the output,
-			// so as a hack we just use a callback to measure the output, then git rid of it
-			terminalWidth = 0
-			bar.Callback = func(line string) {
-				terminalWidth = len(line)
-				bar.Callback = nil
-				bar.Output = res.Stderr()
-				log.Infof("terminal width: %v\n", terminalWidth)
-			}
-			bar.Update()
-		}
-
+		var terminalWidth, totalFiles, filesStarted int
 		lastFile := ""
 		var totalProgress, prevFiles, lastBytes int64
 
 		for out := range outChan {
 			output := out.(*AddedObject)
+			if output.Total > 0 || output.Files > 0 {
+				if quiet || output.Total < progressBarMinSize {
+					continue
+				}
+				totalFiles = output.Files
+
+				bar = pb.New64(output.Total).SetUnits(pb.U_BYTES)
+				bar.ManualUpdate = true
+				bar.Start()
+
+				// the progress bar lib doesn't give us a way to get the width of the output,
+				// so as a hack we just use a callback to measure the output, then git rid of it
+				terminalWidth = 0
+				bar.Callback = func(line string) {
+					terminalWidth = len(line)
+					bar.Callback = nil
+					bar.Output = res.Stderr()
+					log.Infof("terminal width: %v\n", terminalWidth)
+				}
+				bar.Update()
+				continue
+			}
+
 			if len(output.Hash) > 0 {
-				if showProgressBar {
+				if bar != nil {
 					// clear progress bar line before we print "added x" output
 					fmt.Fprintf(res.Stderr(), "\r%s\r", strings.Repeat(" ", terminalWidth))
 				}
@@ -173,23 +292,26 @@ remains to be implemented.
 			} else {
 				log.Debugf("add progress: %v %v\n", output.Name, output.Bytes)
 
-				if !showProgressBar {
+				if bar == nil {
 					continue
 				}
 
-				if len(lastFile) == 0 {
-					lastFile = output.Name
-				}
 				if output.Name != lastFile || output.Bytes < lastBytes {
-					prevFiles += lastBytes
+					if len(lastFile) > 0 {
+						prevFiles += lastBytes
+					}
 					lastFile = output.Name
+					filesStarted++
+					if totalFiles > 1 {
+						bar.Prefix(fmt.Sprintf("%d/%d files ", filesStarted, totalFiles))
+					}
 				}
 				lastBytes = output.Bytes
 				delta := prevFiles + lastBytes - totalProgress
 				totalProgress = bar.Add64(delta)
 			}
 
-			if showProgressBar {
+			if bar != nil {
 				bar.Update()
 			}
 		}
@@ -197,22 +319,39 @@ remains to be implemented.
 	Type: AddedObject{},
 }
 
-func add(n *core.IpfsNode, readers []io.Reader) ([]*dag.Node, error) {
+func add(n *core.IpfsNode, readers []io.Reader, opts *addOptions, mode os.FileMode, mtime time.Time) ([]*dag.Node, error) {
 	mp, ok := n.Pinning.(pinning.ManualPinner)
 	if !ok {
 		return nil, errors.New("invalid pinner type! expected manual pinner")
 	}
 
+	if opts.onlyHash {
+		mp = nil
+	}
+
 	dagnodes := make([]*dag.Node, 0)
 
 	for _, reader := range readers {
-		node, err := importer.BuildDagFromReader(reader, n.DAG, mp, chunk.DefaultSplitter)
+		dbp := h.DagBuilderParams{
+			Dagserv:   opts.dserv,
+			Pinner:    mp,
+			RawLeaves: opts.rawLeaves,
+			HashFunc:  opts.hashFunc,
+			Mode:      mode,
+			ModTime:   mtime,
+		}
+
+		node, err := importer.BuildDagFromReaderParams(reader, opts.splitter, dbp)
 		if err != nil {
 			return nil, err
 		}
 		dagnodes = append(dagnodes, node)
 	}
 
+	if opts.onlyHash {
+		return dagnodes, nil
+	}
+
 	err := n.Pinning.Flush()
 	if err != nil {
 		return nil, err
@@ -221,6 +360,34 @@ func add(n *core.IpfsNode, readers []io.Reader) ([]*dag.Node, error) {
 	return dagnodes, nil
 }
 
+// discardBlockService returns a BlockService that stores nothing, for
+// adds that only compute hashes. It must be closed once used.
+func discardBlockService() (*bserv.BlockService, error) {
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewNullDatastore()))
+	return bserv.New(bstore, offline.Exchange(bstore))
+}
+
+// addNode stores node and pins it, unless only hashing
+func (opts *addOptions) addNode(n *core.IpfsNode, node *dag.Node) error {
+	if opts.onlyHash {
+		return nil
+	}
+	return addNode(n, node)
+}
+
+// hashOption selects the hash function of new objects
+var hashOption = cmds.StringOption(hashOptionName, "Hash function to use: sha1, sha2-256, sha2-512, sha3-512 or blake2b")
+
+// getHashFunc returns the multihash code of the hash function selected by
+// the hash option, or 0 for the default
+func getHashFunc(req cmds.Request) (int, error) {
+	name, found, err := req.Option(hashOptionName).String()
+	if err != nil || !found {
+		return 0, err
+	}
+	return u.HashFuncByName(name)
+}
+
 func addNode(n *core.IpfsNode, node *dag.Node) error {
 	err := n.DAG.AddRecursive(node) // add the file to the graph + local storage
 	if err != nil {
@@ -235,46 +402,133 @@ func addNode(n *core.IpfsNode, node *dag.Node) error {
 	return nil
 }
 
-func addFile(n *core.IpfsNode, file files.File, out chan interface{}, progress bool, wrap bool) (*dag.Node, error) {
+func addFile(n *core.IpfsNode, file files.File, out chan interface{}, opts *addOptions, wrap bool) (*dag.Node, error) {
 	if file.IsDirectory() {
-		return addDir(n, file, out, progress)
+		return addDir(n, file, out, opts)
+	}
+
+	if s, ok := file.(*files.Symlink); ok {
+		return addSymlink(n, s, out, opts)
 	}
 
 	// if the progress flag was specified, wrap the file so that we can send
 	// progress updates to the client (over the output channel)
 	var reader io.Reader = file
-	if progress {
+	if opts.progress {
 		reader = &progressReader{file: file, out: out}
 	}
 
-	if wrap {
-		p, dagnode, err := coreunix.AddWrapped(n, reader, path.Base(file.FileName()))
+	// peek at the start of the file to detect its content type
+	var mimeType string
+	if opts.mime {
+		br := bufio.NewReaderSize(reader, sniffLen)
+		head, err := br.Peek(sniffLen)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		mimeType = http.DetectContentType(head)
+		reader = br
+	}
+
+	mode, mtime := fileStat(file, opts)
+	dns, err := add(n, []io.Reader{reader}, opts, mode, mtime)
+	if err != nil {
+		return nil, err
+	}
+	node := dns[len(dns)-1] // last dag node is the file.
+
+	if opts.mime {
+		node, err = addMetadata(n, node, mimeType, opts, mode, mtime)
 		if err != nil {
 			return nil, err
 		}
-		out <- &AddedObject{
-			Hash: p,
-			Name: file.FileName(),
+	}
+
+	if wrap {
+		return addWrapped(n, node, file.FileName(), out, opts)
+	}
+
+	log.Infof("adding file: %s", file.FileName())
+	if err := outputDagnode(out, file.FileName(), node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// addMetadata wraps the file node in a metadata node recording its
+// content type. The mode and modification time of the file are recorded
+// on the metadata node too, as it stands for the file in directories.
+func addMetadata(n *core.IpfsNode, node *dag.Node, mimeType string, opts *addOptions, mode os.FileMode, mtime time.Time) (*dag.Node, error) {
+	size, err := ft.DataSize(node.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ft.BytesForMetadata(&ft.Metadata{MimeType: mimeType, Size: size})
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 || !mtime.IsZero() {
+		data, err = ft.SetModeAndModTime(data, mode, mtime)
+		if err != nil {
+			return nil, err
 		}
-		return dagnode, nil
 	}
 
-	dns, err := add(n, []io.Reader{reader})
+	mdnode := &dag.Node{Data: data, HashFunc: opts.hashFunc}
+	if err := mdnode.AddNodeLinkClean("file", node); err != nil {
+		return nil, err
+	}
+	if err := opts.addNode(n, mdnode); err != nil {
+		return nil, err
+	}
+	return mdnode, nil
+}
+
+// addSymlink adds a symlink node holding the link's target.
+func addSymlink(n *core.IpfsNode, s *files.Symlink, out chan interface{}, opts *addOptions) (*dag.Node, error) {
+	node := &dag.Node{Data: ft.SymlinkData(s.Target), HashFunc: opts.hashFunc}
+	if err := opts.addNode(n, node); err != nil {
+		return nil, err
+	}
+
+	if err := outputDagnode(out, s.FileName(), node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// addWrapped wraps the given file node with a directory object, to
+// preserve its filename, and outputs the path of the file within it.
+func addWrapped(n *core.IpfsNode, node *dag.Node, filename string, out chan interface{}, opts *addOptions) (*dag.Node, error) {
+	tree := &dag.Node{Data: ft.FolderPBData(), HashFunc: opts.hashFunc}
+	err := tree.AddNodeLink(path.Base(filename), node)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Infof("adding file: %s", file.FileName())
-	if err := outputDagnode(out, file.FileName(), dns[len(dns)-1]); err != nil {
+	err = opts.addNode(n, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := tree.Cid()
+	if err != nil {
 		return nil, err
 	}
-	return dns[len(dns)-1], nil // last dag node is the file.
+
+	out <- &AddedObject{
+		Hash: path.Join(c.String(), path.Base(filename)),
+		Name: filename,
+	}
+	return tree, nil
 }
 
-func addDir(n *core.IpfsNode, dir files.File, out chan interface{}, progress bool) (*dag.Node, error) {
+func addDir(n *core.IpfsNode, dir files.File, out chan interface{}, opts *addOptions) (*dag.Node, error) {
 	log.Infof("adding directory: %s", dir.FileName())
 
-	tree := &dag.Node{Data: ft.FolderPBData()}
+	dirb := uio.NewDirectory(opts.dserv)
+	dirb.SetHashFunc(opts.hashFunc)
 
 	for {
 		file, err := dir.NextFile()
@@ -285,25 +539,37 @@ func addDir(n *core.IpfsNode, dir files.File, out chan interface{}, progress boo
 			break
 		}
 
-		node, err := addFile(n, file, out, progress, false)
+		node, err := addFile(n, file, out, opts, false)
 		if err != nil {
 			return nil, err
 		}
 
 		_, name := path.Split(file.FileName())
 
-		err = tree.AddNodeLink(name, node)
+		err = dirb.AddChildNode(name, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tree, err := dirb.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode, mtime := fileStat(dir, opts); mode != 0 || !mtime.IsZero() {
+		tree.Data, err = ft.SetModeAndModTime(tree.Data, mode, mtime)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	err := outputDagnode(out, dir.FileName(), tree)
+	err = outputDagnode(out, dir.FileName(), tree)
 	if err != nil {
 		return nil, err
 	}
 
-	err = addNode(n, tree)
+	err = opts.addNode(n, tree)
 	if err != nil {
 		return nil, err
 	}
@@ -311,6 +577,25 @@ func addDir(n *core.IpfsNode, dir files.File, out chan interface{}, progress boo
 	return tree, nil
 }
 
+// fileStat returns the mode and modification time of file to record, as
+// selected by the preserve options. Zero values are not recorded.
+func fileStat(file files.File, opts *addOptions) (os.FileMode, time.Time) {
+	var mode os.FileMode
+	var mtime time.Time
+
+	sf, ok := file.(files.StatFile)
+	if !ok || sf.Stat() == nil {
+		return mode, mtime
+	}
+	if opts.preserveMode {
+		mode = sf.Stat().Mode()
+	}
+	if opts.preserveMtime {
+		mtime = sf.Stat().ModTime()
+	}
+	return mode, mtime
+}
+
 // outputDagnode sends dagnode info over the output channel
 func outputDagnode(out chan interface{}, name string, dn *dag.Node) error {
 	o, err := getOutput(dn)
@@ -8,11 +8,10 @@ import (
 	"io/ioutil"
 	"strings"
 
-	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	"github.com/ipfs/go-ipfs/blocks"
+	cid "github.com/ipfs/go-ipfs/cid"
 	cmds "github.com/ipfs/go-ipfs/commands"
-	u "github.com/ipfs/go-ipfs/util"
 )
 
 type BlockStat struct {
@@ -30,7 +29,7 @@ var BlockCmd = &cmds.Command{
 		ShortDescription: `
 'ipfs block' is a plumbing command used to manipulate raw ipfs blocks.
 Reads from stdin or writes to stdout, and <key> is a base58 encoded
-multihash.
+multihash, or a cid.
 `,
 	},
 
@@ -55,7 +54,7 @@ on raw ipfs blocks. It outputs the following to stdout:
 	},
 
 	Arguments: []cmds.Argument{
-		cmds.StringArg("key", true, false, "The base58 multihash of an existing block to get").EnableStdin(),
+		cmds.StringArg("key", true, false, "The base58 multihash or cid of an existing block to get").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		b, err := getBlockForKey(req, req.Arguments()[0])
@@ -83,12 +82,12 @@ var blockGetCmd = &cmds.Command{
 		Tagline: "Get a raw IPFS block",
 		ShortDescription: `
 'ipfs block get' is a plumbing command for retreiving raw ipfs blocks.
-It outputs to stdout, and <key> is a base58 encoded multihash.
+It outputs to stdout, and <key> is a base58 encoded multihash, or a cid.
 `,
 	},
 
 	Arguments: []cmds.Argument{
-		cmds.StringArg("key", true, false, "The base58 multihash of an existing block to get").EnableStdin(),
+		cmds.StringArg("key", true, false, "The base58 multihash or cid of an existing block to get").EnableStdin(),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		b, err := getBlockForKey(req, req.Arguments()[0])
@@ -107,12 +106,18 @@ var blockPutCmd = &cmds.Command{
 		ShortDescription: `
 ipfs block put is a plumbing command for storing raw ipfs blocks.
 It reads from stdin, and <key> is a base58 encoded multihash.
+
+The block is hashed with the function selected by --hash, one of sha1,
+sha2-256 (default), sha2-512, sha3-512 or blake2b.
 `,
 	},
 
 	Arguments: []cmds.Argument{
 		cmds.FileArg("data", true, false, "The data to be stored as an IPFS block").EnableStdin(),
 	},
+	Options: []cmds.Option{
+		hashOption,
+	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		n, err := req.Context().GetNode()
 		if err != nil {
@@ -138,7 +143,17 @@ It reads from stdin, and <key> is a base58 encoded multihash.
 			return
 		}
 
-		b := blocks.NewBlock(data)
+		hashFunc, err := getHashFunc(req)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		b, err := blocks.NewBlockWithHashFunc(data, hashFunc)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
 		log.Debugf("BlockPut key: '%q'", b.Key())
 
 		k, err := n.Blocks.AddBlock(b)
@@ -167,17 +182,12 @@ func getBlockForKey(req cmds.Request, key string) (*blocks.Block, error) {
 		return nil, err
 	}
 
-	if !u.IsValidHash(key) {
-		return nil, errors.New("Not a valid hash")
-	}
-
-	h, err := mh.FromB58String(key)
+	c, err := cid.Decode(key)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("Not a valid hash")
 	}
 
-	k := u.Key(h)
-	b, err := n.Blocks.GetBlock(context.TODO(), k)
+	b, err := n.Blocks.GetBlock(context.TODO(), c.Key())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	cid "github.com/ipfs/go-ipfs/cid"
+	cmds "github.com/ipfs/go-ipfs/commands"
+)
+
+// ErrUnknownBase is returned when --base names an unsupported multibase
+var ErrUnknownBase = errors.New("unknown base, expected one of base58btc, base32 or base16")
+
+type CidList struct {
+	Cids []string
+}
+
+var CidCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Convert and inspect content identifiers",
+		ShortDescription: `
+'ipfs cid' is a plumbing command for working with cids. A cid names an
+object by its multihash, together with the codec of the object. Version 0
+cids are plain base58 multihashes; version 1 cids are multibase strings.`,
+		Synopsis: `
+ipfs cid format <cid>...  - Convert cids to a given version and base
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"format": cidFormatCmd,
+	},
+}
+
+var cidFormatCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Convert cids to a given version and base",
+		ShortDescription: `
+'ipfs cid format' converts each <cid> to the cid version given by
+--cid-version, encoded in the multibase given by --base.
+
+Only protobuf objects hashed with sha2-256 have a version 0 cid. Version 0
+cids are always base58; asking for another base converts them to version 1.
+
+--base may be one of the following:
+	* "base58btc" (default)
+	* "base32"
+	* "base16"
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, true, "Cids to convert").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption("cid-version", "Cid version to convert to, 0 or 1"),
+		cmds.StringOption("base", "b", "Multibase to encode the cids in"),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		version, versionFound, err := req.Option("cid-version").Int()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		baseName, found, err := req.Option("base").String()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if !found {
+			baseName = "base58btc"
+		}
+		base, ok := cid.Bases[baseName]
+		if !ok {
+			res.SetError(ErrUnknownBase, cmds.ErrClient)
+			return
+		}
+
+		out := &CidList{}
+		for _, arg := range req.Arguments() {
+			c, err := cid.Decode(strings.TrimSpace(arg))
+			if err != nil {
+				res.SetError(err, cmds.ErrClient)
+				return
+			}
+
+			switch {
+			case versionFound:
+				c, err = c.ToVersion(uint64(version))
+			case c.Version() == 0 && base != cid.Base58BTC:
+				c, err = c.ToVersion(1)
+			}
+			if err != nil {
+				res.SetError(err, cmds.ErrClient)
+				return
+			}
+
+			s, err := c.Encode(base)
+			if err != nil {
+				res.SetError(err, cmds.ErrClient)
+				return
+			}
+			out.Cids = append(out.Cids, s)
+		}
+
+		res.SetOutput(out)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			list := res.Output().(*CidList)
+			return strings.NewReader(strings.Join(list.Cids, "\n") + "\n"), nil
+		},
+	},
+	Type: CidList{},
+}
@@ -0,0 +1,299 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	cid "github.com/ipfs/go-ipfs/cid"
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	path "github.com/ipfs/go-ipfs/path"
+)
+
+// ErrInvalidLink is returned when a JSON link object is malformed
+var ErrInvalidLink = errors.New(`link objects must have exactly one key, "/", with a cid value`)
+
+// ErrTrailingData is returned when JSON input holds more than one document
+var ErrTrailingData = errors.New("unexpected data after the json document")
+
+var DagCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Interact with structured ipfs objects",
+		ShortDescription: `
+'ipfs dag' is a plumbing command used to manipulate structured DAG
+objects. Structured objects are stored as canonical CBOR documents, and
+may contain links anywhere, written in JSON as {"/": "<cid>"}.`,
+		Synopsis: `
+ipfs dag put <data>       - Stores a JSON document, outputs its key
+ipfs dag get <ipfs-path>  - Outputs the object or value at <ipfs-path>
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"get": dagGetCmd,
+		"put": dagPutCmd,
+	},
+}
+
+var dagPutCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Stores input as a structured DAG object, outputs its key",
+		ShortDescription: `
+'ipfs dag put' is a plumbing command for storing structured DAG objects.
+It reads a document from stdin, stores it as canonical CBOR, and outputs
+its cid.
+
+The document must be a map. Links to other objects are written as
+{"/": "<cid>"} anywhere within it. A bare base58 multihash links to a
+protobuf object.
+
+--inputenc may be one of the following:
+	* "json" (default)
+	* "cbor"
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.FileArg("data", true, false, "Document to be stored as a DAG object").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("inputenc", "Encoding type of input data, either \"json\" or \"cbor\""),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		input, err := req.Files().NextFile()
+		if err != nil && err != io.EOF {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		inputenc, found, err := req.Option("inputenc").String()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if !found {
+			inputenc = "json"
+		}
+
+		output, err := dagPut(n, input, inputenc)
+		if err != nil {
+			errType := cmds.ErrNormal
+			if err == ErrUnknownObjectEnc || err == ErrInvalidLink {
+				errType = cmds.ErrClient
+			}
+			res.SetError(err, errType)
+			return
+		}
+
+		res.SetOutput(output)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			object := res.Output().(*Object)
+			return strings.NewReader(object.Hash + "\n"), nil
+		},
+	},
+	Type: Object{},
+}
+
+var dagGetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Get the DAG object or value named by <ipfs-path>",
+		ShortDescription: `
+'ipfs dag get' is a plumbing command for retreiving DAG objects as JSON.
+<ipfs-path> may continue past a structured object into its fields, as in
+/ipfs/<key>/field/subfield, following any links it crosses. Protobuf
+objects are output with their "Data" and "Links".
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("ipfs-path", true, false, "The path of the object or value to get").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		val, err := n.Resolver.ResolveValue(path.Path(req.Arguments()[0]))
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		out, err := json.MarshalIndent(dagValueToJSON(val), "", "  ")
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		res.SetOutput(bytes.NewReader(append(out, '\n')))
+	},
+}
+
+// dagPut decodes a document from input, and stores and pins it as a
+// structured node.
+func dagPut(n *core.IpfsNode, input io.Reader, encoding string) (*Object, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(input, inputLimit+10))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) >= inputLimit {
+		return nil, ErrObjectTooLarge
+	}
+
+	var dagnode *dag.Node
+	switch encoding {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		// the document must be all of the input, but for white space
+		if err := dec.Decode(new(interface{})); err != io.EOF {
+			return nil, ErrTrailingData
+		}
+
+		v, err = jsonToDagValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, dag.ErrNotCBORMap
+		}
+
+		dagnode, err = dag.NewCBORNode(obj)
+
+	case "cbor":
+		dagnode, err = dag.Decoded(data)
+		if err == nil && dagnode.Format != dag.FormatCBOR {
+			err = dag.ErrNotCBORMap
+		}
+
+	default:
+		return nil, ErrUnknownObjectEnc
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addNode(n, dagnode); err != nil {
+		return nil, err
+	}
+	if err := n.Pinning.Flush(); err != nil {
+		return nil, err
+	}
+
+	return getOutput(dagnode)
+}
+
+// jsonToDagValue converts a decoded JSON value to a document value,
+// turning link objects into multihashes, or cids for version 1 cids.
+func jsonToDagValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case map[string]interface{}:
+		if l, ok := v["/"]; ok {
+			s, ok := l.(string)
+			if !ok || len(v) != 1 {
+				return nil, ErrInvalidLink
+			}
+			c, err := cid.Decode(s)
+			if err != nil {
+				return nil, ErrInvalidLink
+			}
+			if c.Version() == 0 {
+				return c.Hash(), nil
+			}
+			return c, nil
+		}
+
+		for k, e := range v {
+			de, err := jsonToDagValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = de
+		}
+		return v, nil
+	case []interface{}:
+		for i, e := range v {
+			de, err := jsonToDagValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = de
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// dagValueToJSON converts a resolved node or document value to a value
+// suitable for JSON encoding, turning multihashes and cids into link objects.
+func dagValueToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *dag.Node:
+		if v.Format == dag.FormatCBOR {
+			return dagValueToJSON(v.Obj)
+		}
+
+		links := make([]interface{}, len(v.Links))
+		for i, l := range v.Links {
+			links[i] = map[string]interface{}{
+				"Name": l.Name,
+				"Size": l.Size,
+				"Hash": dagValueToJSON(l.Cid()),
+			}
+		}
+		return map[string]interface{}{
+			"Data":  string(v.Data),
+			"Links": links,
+		}
+	case mh.Multihash:
+		return map[string]interface{}{"/": v.B58String()}
+	case *cid.Cid:
+		return map[string]interface{}{"/": v.String()}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = dagValueToJSON(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = dagValueToJSON(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
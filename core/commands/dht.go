@@ -7,6 +7,7 @@ import (
 	"io"
 	"time"
 
+	cid "github.com/ipfs/go-ipfs/cid"
 	cmds "github.com/ipfs/go-ipfs/commands"
 	notif "github.com/ipfs/go-ipfs/notifications"
 	peer "github.com/ipfs/go-ipfs/p2p/peer"
@@ -16,6 +17,9 @@ import (
 
 var ErrNotDHT = errors.New("routing service is not a DHT")
 
+// ErrInvalidDHTKey is returned when a key is not b58 encoded
+var ErrInvalidDHTKey = errors.New("dht keys must be b58 encoded")
+
 var DhtCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline:          "Issue commands directly through the DHT",
@@ -159,6 +163,12 @@ FindProviders will return a list of peers who are able to provide the value requ
 			return
 		}
 
+		c, err := cid.Decode(req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
 		numProviders := 20
 
 		outChan := make(chan interface{})
@@ -167,7 +177,7 @@ FindProviders will return a list of peers who are able to provide the value requ
 		events := make(chan *notif.QueryEvent)
 		ctx := notif.RegisterForQueryEvents(req.Context().Context, events)
 
-		pchan := dht.FindProvidersAsync(ctx, u.B58KeyDecode(req.Arguments()[0]), numProviders)
+		pchan := dht.FindProvidersAsync(ctx, c.Key(), numProviders)
 		go func() {
 			defer close(outChan)
 			for e := range events {
@@ -382,6 +392,13 @@ GetValue will return the value stored in the dht at the given key.
 			return
 		}
 
+		// record keys, like /ipns/<hash>, are not cids
+		key := u.B58KeyDecode(req.Arguments()[0])
+		if key == "" {
+			res.SetError(ErrInvalidDHTKey, cmds.ErrClient)
+			return
+		}
+
 		outChan := make(chan interface{})
 		res.SetOutput((<-chan interface{})(outChan))
 
@@ -397,7 +414,7 @@ GetValue will return the value stored in the dht at the given key.
 
 		go func() {
 			defer close(events)
-			val, err := dht.GetValue(ctx, u.B58KeyDecode(req.Arguments()[0]))
+			val, err := dht.GetValue(ctx, key)
 			if err != nil {
 				notif.PublishQueryEvent(ctx, &notif.QueryEvent{
 					Type:  notif.QueryError,
@@ -490,13 +507,19 @@ PutValue will store the given key value pair in the dht.
 			return
 		}
 
+		// record keys, like /ipns/<hash>, are not cids
+		key := u.B58KeyDecode(req.Arguments()[0])
+		if key == "" {
+			res.SetError(ErrInvalidDHTKey, cmds.ErrClient)
+			return
+		}
+
 		outChan := make(chan interface{})
 		res.SetOutput((<-chan interface{})(outChan))
 
 		events := make(chan *notif.QueryEvent)
 		ctx := notif.RegisterForQueryEvents(req.Context().Context, events)
 
-		key := u.B58KeyDecode(req.Arguments()[0])
 		data := req.Arguments()[1]
 
 		go func() {
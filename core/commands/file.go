@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	path "github.com/ipfs/go-ipfs/path"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+	ftpb "github.com/ipfs/go-ipfs/unixfs/pb"
+)
+
+// ErrNotUnixfs is returned for objects that are not unixfs files or
+// directories
+var ErrNotUnixfs = errors.New("object is not a unixfs file or directory")
+
+type FileStatOutput struct {
+	Hash           string
+	Type           string
+	Size           uint64
+	Blocks         int
+	CumulativeSize uint64
+	MimeType       string `json:",omitempty"`
+}
+
+type FileLsLink struct {
+	Name, Hash string
+	Size       uint64
+	Type       string
+}
+
+type FileLsObject struct {
+	Hash  string
+	Size  uint64
+	Type  string
+	Links []FileLsLink
+}
+
+type FileLsOutput struct {
+	Objects []FileLsObject
+}
+
+var FileCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Interact with unixfs files and directories",
+		ShortDescription: `
+'ipfs file' provides a familiar interface to the files and directories
+stored in ipfs, as opposed to the raw objects 'ipfs object' works with.`,
+		Synopsis: `
+ipfs file ls <ipfs-path>...  - List directory contents
+ipfs file stat <ipfs-path>   - Show information about a file or directory
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"ls":   fileLsCmd,
+		"stat": fileStatCmd,
+	},
+}
+
+var fileLsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List directory contents",
+		ShortDescription: `
+'ipfs file ls' lists the entries of each directory named by <ipfs-path>,
+with the following format:
+
+  <entry hash> <entry size in bytes> <entry name>
+
+Unlike 'ipfs ls', which lists the raw links of an object, it lists the
+entries of sharded directories as one directory, and the size of a file
+is the size of its contents. The names of directories end with a '/'.
+Files are listed by their path alone.
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("ipfs-path", true, true, "The path to the IPFS object(s) to list").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		paths := req.Arguments()
+		output := make([]FileLsObject, len(paths))
+		for i, fpath := range paths {
+			nd, err := n.Resolver.ResolvePath(path.Path(fpath))
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+
+			st, err := unixfsStat(n, nd)
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+			output[i] = FileLsObject{
+				Hash: fpath,
+				Size: st.Size,
+				Type: st.Type,
+			}
+			if !isDirectory(st.Type) {
+				continue
+			}
+
+			links, err := uio.DirectoryLinks(n.DAG, nd)
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+			output[i].Links = make([]FileLsLink, len(links))
+			for j, link := range links {
+				child, err := link.GetNode(n.DAG)
+				if err != nil {
+					res.SetError(err, cmds.ErrNormal)
+					return
+				}
+				st, err := unixfsStat(n, child)
+				if err != nil {
+					res.SetError(err, cmds.ErrNormal)
+					return
+				}
+				output[i].Links[j] = FileLsLink{
+					Name: link.Name,
+					Hash: link.Cid().String(),
+					Size: st.Size,
+					Type: st.Type,
+				}
+			}
+		}
+
+		res.SetOutput(&FileLsOutput{output})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			output := res.Output().(*FileLsOutput)
+			var buf bytes.Buffer
+			w := tabwriter.NewWriter(&buf, 1, 2, 1, ' ', 0)
+			for _, object := range output.Objects {
+				if !isDirectory(object.Type) {
+					fmt.Fprintln(w, object.Hash)
+					continue
+				}
+				if len(output.Objects) > 1 {
+					fmt.Fprintf(w, "%s:\n", object.Hash)
+				}
+				for _, link := range object.Links {
+					if isDirectory(link.Type) {
+						link.Name += "/"
+					}
+					fmt.Fprintf(w, "%s\t%v\t%s\n", link.Hash, link.Size, link.Name)
+				}
+				if len(output.Objects) > 1 {
+					fmt.Fprintln(w)
+				}
+			}
+			w.Flush()
+
+			return &buf, nil
+		},
+	},
+	Type: FileLsOutput{},
+}
+
+var fileStatCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show information about a file or directory",
+		ShortDescription: `
+'ipfs file stat' displays the type and size of the file or directory
+named by <ipfs-path>, along with its content type if one was recorded
+with 'ipfs add --mime'. The type is one of file, directory,
+sharded-directory and symlink. The size of a file is the size of its contents;
+the number of blocks is the number of blocks directly linked from its
+root, and the cumulative size is the size of all of its blocks.
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("ipfs-path", true, false, "The path to the file or directory to show").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		fpath := path.Path(req.Arguments()[0])
+		nd, err := n.Resolver.ResolvePath(fpath)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		output, err := unixfsStat(n, nd)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		output.Hash = fpath.String()
+		res.SetOutput(output)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			out := res.Output().(*FileStatOutput)
+			var buf bytes.Buffer
+			fmt.Fprintf(&buf, "Type: %s\n", out.Type)
+			fmt.Fprintf(&buf, "Size: %d\n", out.Size)
+			fmt.Fprintf(&buf, "Blocks: %d\n", out.Blocks)
+			fmt.Fprintf(&buf, "CumulativeSize: %d\n", out.CumulativeSize)
+			if out.MimeType != "" {
+				fmt.Fprintf(&buf, "MimeType: %s\n", out.MimeType)
+			}
+			return &buf, nil
+		},
+	},
+	Type: FileStatOutput{},
+}
+
+// unixfsStat describes the unixfs object nd. A metadata node is described
+// as the object it wraps, with the recorded size and content type.
+func unixfsStat(n *core.IpfsNode, nd *dag.Node) (*FileStatOutput, error) {
+	ns, err := nd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	output := &FileStatOutput{
+		Blocks:         len(nd.Links),
+		CumulativeSize: uint64(ns.CumulativeSize),
+	}
+
+	if nd.Format == dag.FormatRaw {
+		output.Type = "file"
+		output.Size = uint64(len(nd.Data))
+		return output, nil
+	}
+
+	d, err := ft.FromNode(nd)
+	if err != nil {
+		return nil, ErrNotUnixfs
+	}
+
+	if d.GetType() != ftpb.Data_Metadata {
+		output.Type, err = fileType(d)
+		if err != nil {
+			return nil, err
+		}
+		if !isDirectory(output.Type) {
+			output.Size, err = ft.DataSize(nd.Data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return output, nil
+	}
+
+	md, err := ft.MetadataFromBytes(nd.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(nd.Links) != 1 {
+		return nil, ErrNotUnixfs
+	}
+	child, err := nd.Links[0].GetNode(n.DAG)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := unixfsStat(n, child)
+	if err != nil {
+		return nil, err
+	}
+	output.Type = inner.Type
+	output.Size = md.Size
+	output.Blocks = inner.Blocks
+	output.MimeType = md.MimeType
+	return output, nil
+}
+
+// fileType names the type of a unixfs object, as ipfs file stat prints it.
+func fileType(d *ftpb.Data) (string, error) {
+	switch d.GetType() {
+	case ftpb.Data_Raw, ftpb.Data_File:
+		return "file", nil
+	case ftpb.Data_Directory:
+		return "directory", nil
+	case ftpb.Data_HAMTShard:
+		return "sharded-directory", nil
+	case ftpb.Data_Symlink:
+		return "symlink", nil
+	default:
+		return "", ErrNotUnixfs
+	}
+}
+
+// isDirectory reports whether t, as returned by fileType, is a type of
+// directory.
+func isDirectory(t string) bool {
+	return t == "directory" || t == "sharded-directory"
+}
@@ -0,0 +1,414 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	keystore "github.com/ipfs/go-ipfs/keystore"
+	crypto "github.com/ipfs/go-ipfs/p2p/crypto"
+	peer "github.com/ipfs/go-ipfs/p2p/peer"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+// minRSAKeySize is the smallest size, in bits, of the keys key gen creates.
+const minRSAKeySize = 1024
+
+var KeyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Create and manage the keys ipns names are published with",
+		Synopsis: `
+ipfs key gen --type=<type> --size=<size> <name> - Create a new key
+ipfs key list [-l]                             - List the keys
+ipfs key rename <name> <newName>               - Rename a key
+ipfs key rm <name>...                          - Remove keys
+`,
+		ShortDescription: `
+Keys are kept in the keystore of the repo, and are named by the user. The
+key of the node's own identity is called 'self', and can't be changed.
+Publish to the name of a key with 'ipfs name publish --key=<name>'.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"gen":    keyGenCmd,
+		"list":   keyListCmd,
+		"rename": keyRenameCmd,
+		"rm":     keyRmCmd,
+	},
+}
+
+type KeyOutput struct {
+	Name string
+	Id   string
+}
+
+type KeyOutputList struct {
+	Keys []KeyOutput
+}
+
+type KeyRenameOutput struct {
+	Was       string
+	Now       string
+	Id        string
+	Overwrite bool
+}
+
+var keyGenCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Create a new keypair",
+		ShortDescription: `
+Generates a keypair, and stores it in the keystore under <name>. Only rsa
+keys are supported for now.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the key to create"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("type", "t", "Type of the key to create (default: rsa)"),
+		cmds.IntOption("size", "s", "Size of the key to create, in bits (default: 2048)"),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		name := req.Arguments()[0]
+		if err := keystore.ValidateName(name); err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		typ, found, err := req.Option("type").String()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if found && typ != "rsa" {
+			res.SetError(fmt.Errorf("unrecognized key type: %s", typ), cmds.ErrClient)
+			return
+		}
+
+		size, found, err := req.Option("size").Int()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if !found {
+			size = 2048
+		}
+		if size < minRSAKeySize {
+			res.SetError(fmt.Errorf("key size must be at least %d bits", minRSAKeySize), cmds.ErrClient)
+			return
+		}
+
+		ks := n.Repo.Keystore()
+		if has, err := ks.Has(name); err != nil || has {
+			if err == nil {
+				err = keystore.ErrKeyExists
+			}
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		sk, pk, err := crypto.GenerateKeyPair(crypto.RSA, size)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		if err := ks.Put(name, sk); err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		id, err := peer.IDFromPublicKey(pk)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		// the key can be written to through ipnsfs, and has its name
+		// republished, right away; otherwise when the node starts again
+		if n.IpnsFs != nil {
+			if _, err := n.IpnsFs.AddRoot(n.Context(), sk); err != nil {
+				log.Errorf("creating ipnsfs root of key %s: %s", name, err)
+			}
+		}
+		if n.IpnsRepub != nil {
+			if err := n.IpnsRepub.AddKey(sk); err != nil {
+				log.Errorf("republishing the name of key %s: %s", name, err)
+			}
+		}
+
+		res.SetOutput(&KeyOutput{
+			Name: name,
+			Id:   id.Pretty(),
+		})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			k, ok := res.Output().(*KeyOutput)
+			if !ok {
+				return nil, u.ErrCast()
+			}
+			return strings.NewReader(k.Id + "\n"), nil
+		},
+	},
+	Type: KeyOutput{},
+}
+
+var keyListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List all local keypairs",
+		ShortDescription: `
+Lists the names of the keys in the keystore, and 'self' for the node's
+identity. With -l, the ids of the keys are listed along with them.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("l", "Show the ids of the keys"),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		if n.Identity == "" {
+			res.SetError(errors.New("Identity not loaded!"), cmds.ErrNormal)
+			return
+		}
+
+		ks := n.Repo.Keystore()
+		names, err := ks.List()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		sort.Strings(names)
+
+		list := make([]KeyOutput, 0, len(names)+1)
+		list = append(list, KeyOutput{Name: keystore.SelfKeyName, Id: n.Identity.Pretty()})
+		for _, name := range names {
+			id, err := keyID(ks, name)
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+			list = append(list, KeyOutput{Name: name, Id: id.Pretty()})
+		}
+
+		res.SetOutput(&KeyOutputList{list})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: keyOutputListMarshaler,
+	},
+	Type: KeyOutputList{},
+}
+
+var keyRenameCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Rename a keypair",
+		ShortDescription: `
+Renames the key <name> to <newName>. A key already called <newName> is
+only replaced with --force. The id of the key doesn't change.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, false, "Name of the key to rename"),
+		cmds.StringArg("newName", true, false, "New name of the key"),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("force", "f", "Replace the key called <newName>, if any"),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		name, newName := req.Arguments()[0], req.Arguments()[1]
+		for _, k := range []string{name, newName} {
+			if err := keystore.ValidateName(k); err != nil {
+				res.SetError(err, cmds.ErrClient)
+				return
+			}
+		}
+
+		force, _, err := req.Option("force").Bool()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		out, err := renameKey(n, name, newName, force)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		res.SetOutput(out)
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			k, ok := res.Output().(*KeyRenameOutput)
+			if !ok {
+				return nil, u.ErrCast()
+			}
+			buf := new(bytes.Buffer)
+			if k.Overwrite {
+				fmt.Fprintf(buf, "Key %s renamed to %s, replacing the key of that name\n", k.Was, k.Now)
+			} else {
+				fmt.Fprintf(buf, "Key %s renamed to %s\n", k.Was, k.Now)
+			}
+			return buf, nil
+		},
+	},
+	Type: KeyRenameOutput{},
+}
+
+var keyRmCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove keypairs",
+		ShortDescription: `
+Removes the keys of the given names from the keystore. The names they were
+published to are no longer republished by this node, and expire.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("name", true, true, "Names of the keys to remove").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("l", "Show the ids of the keys"),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		names := req.Arguments()
+		for _, name := range names {
+			if err := keystore.ValidateName(name); err != nil {
+				res.SetError(fmt.Errorf("cannot remove key %q: %s", name, err), cmds.ErrClient)
+				return
+			}
+		}
+
+		ks := n.Repo.Keystore()
+		list := make([]KeyOutput, 0, len(names))
+		for _, name := range names {
+			id, err := keyID(ks, name)
+			if err != nil {
+				res.SetError(fmt.Errorf("cannot remove key %q: %s", name, err), cmds.ErrNormal)
+				return
+			}
+
+			if err := ks.Delete(name); err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+
+			if n.IpnsFs != nil {
+				// os.ErrNotExist only means there was no root to remove
+				n.IpnsFs.RemoveRoot(u.Key(id).Pretty())
+			}
+			if n.IpnsRepub != nil {
+				n.IpnsRepub.RemoveKey(id)
+			}
+			list = append(list, KeyOutput{Name: name, Id: id.Pretty()})
+		}
+
+		res.SetOutput(&KeyOutputList{list})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: keyOutputListMarshaler,
+	},
+	Type: KeyOutputList{},
+}
+
+// keyID returns the peer id of the key of the given name.
+func keyID(ks keystore.Keystore, name string) (peer.ID, error) {
+	sk, err := ks.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return peer.IDFromPrivateKey(sk)
+}
+
+func renameKey(n *core.IpfsNode, name, newName string, force bool) (*KeyRenameOutput, error) {
+	ks := n.Repo.Keystore()
+	sk, err := ks.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &KeyRenameOutput{Was: name, Now: newName, Id: id.Pretty()}
+	if name == newName {
+		return out, nil
+	}
+
+	exists, err := ks.Has(newName)
+	if err != nil {
+		return nil, err
+	}
+	var replaced peer.ID
+	if exists {
+		if !force {
+			return nil, fmt.Errorf("key %s already exists, use --force to replace it", newName)
+		}
+		replaced, err = keyID(ks, newName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the key replaced is only dropped once the renamed one is in its place
+	if err := ks.Rename(name, newName, force); err != nil {
+		return nil, err
+	}
+	if exists {
+		if n.IpnsFs != nil {
+			n.IpnsFs.RemoveRoot(u.Key(replaced).Pretty())
+		}
+		if n.IpnsRepub != nil {
+			n.IpnsRepub.RemoveKey(replaced)
+		}
+		out.Overwrite = true
+	}
+	return out, nil
+}
+
+func keyOutputListMarshaler(res cmds.Response) (io.Reader, error) {
+	withID, _, _ := res.Request().Option("l").Bool()
+
+	list, ok := res.Output().(*KeyOutputList)
+	if !ok {
+		return nil, u.ErrCast()
+	}
+
+	buf := new(bytes.Buffer)
+	for _, k := range list.Keys {
+		if withID {
+			fmt.Fprintf(buf, "%s %s\n", k.Id, k.Name)
+		} else {
+			fmt.Fprintln(buf, k.Name)
+		}
+	}
+	return buf, nil
+}
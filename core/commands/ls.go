@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"text/tabwriter"
+	"time"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
 	merkledag "github.com/ipfs/go-ipfs/merkledag"
 	path "github.com/ipfs/go-ipfs/path"
 	"github.com/ipfs/go-ipfs/unixfs"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	unixfspb "github.com/ipfs/go-ipfs/unixfs/pb"
 )
 
@@ -17,6 +20,11 @@ type LsLink struct {
 	Name, Hash string
 	Size       uint64
 	Type       unixfspb.Data_DataType
+
+	// POSIX mode and modification time (in seconds since the epoch), if
+	// recorded
+	Mode  uint32 `json:",omitempty"`
+	Mtime int64  `json:",omitempty"`
 }
 
 type LsObject struct {
@@ -36,6 +44,9 @@ Retrieves the object named by <ipfs-path> and displays the links
 it contains, with the following format:
 
   <link base58 hash> <link size in bytes> <link name>
+
+With -l, each line starts with the mode and the modification time of the
+link target, if they were recorded when it was added.
 `,
 	},
 
@@ -44,6 +55,7 @@ it contains, with the following format:
 	},
 	Options: []cmds.Option{
 		cmds.BoolOption("headers", "", "Print table headers (Hash, Name, Size)"),
+		cmds.BoolOption("long", "l", "Also print the mode and modification time of each link"),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		node, err := req.Context().GetNode()
@@ -72,26 +84,35 @@ it contains, with the following format:
 
 		output := make([]LsObject, len(req.Arguments()))
 		for i, dagnode := range dagnodes {
+			links, err := uio.DirectoryLinks(node.DAG, dagnode)
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
 			output[i] = LsObject{
 				Hash:  paths[i],
-				Links: make([]LsLink, len(dagnode.Links)),
+				Links: make([]LsLink, len(links)),
 			}
-			for j, link := range dagnode.Links {
+			for j, link := range links {
 				link.Node, err = link.GetNode(node.DAG)
 				if err != nil {
 					res.SetError(err, cmds.ErrNormal)
 					return
 				}
-				d, err := unixfs.FromBytes(link.Node.Data)
+				d, err := unixfs.FromNode(link.Node)
 				if err != nil {
 					res.SetError(err, cmds.ErrNormal)
 					return
 				}
 				output[i].Links[j] = LsLink{
 					Name: link.Name,
-					Hash: link.Hash.B58String(),
+					Hash: link.Cid().String(),
 					Size: link.Size,
 					Type: d.GetType(),
+					Mode: d.GetMode(),
+				}
+				if mtime, ok := unixfs.ModTime(d); ok {
+					output[i].Links[j].Mtime = mtime.Unix()
 				}
 			}
 		}
@@ -102,6 +123,7 @@ it contains, with the following format:
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
 
 			headers, _, _ := res.Request().Option("headers").Bool()
+			long, _, _ := res.Request().Option("long").Bool()
 			output := res.Output().(*LsOutput)
 			var buf bytes.Buffer
 			w := tabwriter.NewWriter(&buf, 1, 2, 1, ' ', 0)
@@ -110,12 +132,18 @@ it contains, with the following format:
 					fmt.Fprintf(w, "%s:\n", object.Hash)
 				}
 				if headers {
+					if long {
+						fmt.Fprint(w, "Mode\tModified\t")
+					}
 					fmt.Fprintln(w, "Hash\tSize\tName\t")
 				}
 				for _, link := range object.Links {
-					if link.Type == unixfspb.Data_Directory {
+					if link.Type == unixfspb.Data_Directory || link.Type == unixfspb.Data_HAMTShard {
 						link.Name += "/"
 					}
+					if long {
+						fmt.Fprintf(w, "%s\t%s\t", lsMode(link), lsMtime(link))
+					}
 					fmt.Fprintf(w, "%s\t%v\t%s\t\n", link.Hash, link.Size, link.Name)
 				}
 				if len(output.Objects) > 1 {
@@ -129,3 +157,27 @@ it contains, with the following format:
 	},
 	Type: LsOutput{},
 }
+
+// lsMode formats the mode of a link's target, as ls -l does.
+func lsMode(link LsLink) string {
+	if link.Mode == 0 {
+		return "-"
+	}
+
+	mode, _ := unixfs.Mode(&unixfspb.Data{Mode: &link.Mode})
+	switch link.Type {
+	case unixfspb.Data_Directory, unixfspb.Data_HAMTShard:
+		mode |= os.ModeDir
+	case unixfspb.Data_Symlink:
+		mode |= os.ModeSymlink
+	}
+	return mode.String()
+}
+
+// lsMtime formats the modification time of a link's target.
+func lsMtime(link LsLink) string {
+	if link.Mtime == 0 {
+		return "-"
+	}
+	return time.Unix(link.Mtime, 0).Format("2006-01-02 15:04")
+}
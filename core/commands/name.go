@@ -11,8 +11,10 @@ var NameCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "IPFS namespace (IPNS) tool",
 		Synopsis: `
-ipfs name publish [<name>] <ipfs-path> - Publish an object to IPNS
+ipfs name publish <ipfs-path>          - Publish an object to IPNS
 ipfs name resolve [<name>]             - Gets the value currently published at an IPNS name
+ipfs name cache                        - Show the counts of the cache of resolved names
+ipfs name republisher                  - Show when the names of this node were last republished
 `,
 		ShortDescription: `
 IPNS is a PKI namespace, where names are the hashes of public keys, and
@@ -30,28 +32,33 @@ Examples:
 Publish a <ref> to your identity name:
 
   > ipfs name publish QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
-  published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  Published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
-Publish a <ref> to another public key:
+Publish a <ref> to the name of a key made with 'ipfs key gen':
 
-  > ipfs name publish QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
-  published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  > ipfs name publish --key=mykey QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  Published name QmSomeKeyHash to /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
 Resolve the value of your identity:
 
   > ipfs name resolve
-  QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
 Resolve the value of another name:
 
   > ipfs name resolve QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n
-  QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
+The daemon publishes the names of its keys again before their records
+expire, every 4 hours unless the config says otherwise in
+Ipns.RepublishPeriod.
 `,
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"publish": publishCmd,
-		"resolve": resolveCmd,
+		"publish":     publishCmd,
+		"resolve":     resolveCmd,
+		"cache":       nameCacheCmd,
+		"republisher": nameRepublisherCmd,
 	},
 }
@@ -10,8 +10,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
-	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
-
+	cid "github.com/ipfs/go-ipfs/cid"
 	cmds "github.com/ipfs/go-ipfs/commands"
 	core "github.com/ipfs/go-ipfs/core"
 	dag "github.com/ipfs/go-ipfs/merkledag"
@@ -189,7 +188,7 @@ This command outputs data in the following encodings:
 
 		for i, link := range object.Links {
 			node.Links[i] = Link{
-				Hash: link.Hash.B58String(),
+				Hash: link.Cid().String(),
 				Name: link.Name,
 				Size: link.Size,
 			}
@@ -281,16 +280,19 @@ var objectPutCmd = &cmds.Command{
 		Tagline: "Stores input as a DAG object, outputs its key",
 		ShortDescription: `
 'ipfs object put' is a plumbing command for storing DAG nodes.
-It reads from stdin, and the output is a base58 encoded multihash.
+It reads from stdin, and the output is the cid of the stored object.
 `,
 		LongDescription: `
 'ipfs object put' is a plumbing command for storing DAG nodes.
-It reads from stdin, and the output is a base58 encoded multihash.
+It reads from stdin, and the output is the cid of the stored object.
 
 Data should be in the format specified by the --inputenc flag.
 --inputenc may be one of the following:
 	* "protobuf"
 	* "json" (default)
+
+The object is hashed with the function selected by --hash, one of sha1,
+sha2-256 (default), sha2-512, sha3-512 or blake2b.
 `,
 	},
 
@@ -299,6 +301,7 @@ Data should be in the format specified by the --inputenc flag.
 	},
 	Options: []cmds.Option{
 		cmds.StringOption("inputenc", "Encoding type of input data, either \"protobuf\" or \"json\""),
+		hashOption,
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		n, err := req.Context().GetNode()
@@ -322,7 +325,13 @@ Data should be in the format specified by the --inputenc flag.
 			inputenc = "json"
 		}
 
-		output, err := objectPut(n, input, inputenc)
+		hashFunc, err := getHashFunc(req)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		output, err := objectPut(n, input, inputenc, hashFunc)
 		if err != nil {
 			errType := cmds.ErrNormal
 			if err == ErrUnknownObjectEnc {
@@ -383,7 +392,7 @@ func objectGet(n *core.IpfsNode, fpath path.Path) (*dag.Node, error) {
 var ErrEmptyNode = errors.New("no data or links in this node")
 
 // objectPut takes a format option, serializes bytes from stdin and updates the dag with that data
-func objectPut(n *core.IpfsNode, input io.Reader, encoding string) (*Object, error) {
+func objectPut(n *core.IpfsNode, input io.Reader, encoding string, hashFunc int) (*Object, error) {
 	var (
 		dagnode *dag.Node
 		data    []byte
@@ -429,6 +438,7 @@ func objectPut(n *core.IpfsNode, input io.Reader, encoding string) (*Object, err
 		return nil, err
 	}
 
+	dagnode.HashFunc = hashFunc
 	err = addNode(n, dagnode)
 	if err != nil {
 		return nil, err
@@ -459,20 +469,20 @@ func getObjectEnc(o interface{}) objectEncoding {
 }
 
 func getOutput(dagnode *dag.Node) (*Object, error) {
-	key, err := dagnode.Key()
+	c, err := dagnode.Cid()
 	if err != nil {
 		return nil, err
 	}
 
 	output := &Object{
-		Hash:  key.Pretty(),
+		Hash:  c.String(),
 		Links: make([]Link, len(dagnode.Links)),
 	}
 
 	for i, link := range dagnode.Links {
 		output.Links[i] = Link{
 			Name: link.Name,
-			Hash: link.Hash.B58String(),
+			Hash: link.Cid().String(),
 			Size: link.Size,
 		}
 	}
@@ -486,14 +496,19 @@ func deserializeNode(node *Node) (*dag.Node, error) {
 	dagnode.Data = []byte(node.Data)
 	dagnode.Links = make([]*dag.Link, len(node.Links))
 	for i, link := range node.Links {
-		hash, err := mh.FromB58String(link.Hash)
+		c, err := cid.Decode(link.Hash)
+		if err != nil {
+			return nil, err
+		}
+		format, err := dag.FormatForCodec(c.Codec())
 		if err != nil {
 			return nil, err
 		}
 		dagnode.Links[i] = &dag.Link{
-			Name: link.Name,
-			Size: link.Size,
-			Hash: hash,
+			Name:   link.Name,
+			Size:   link.Size,
+			Hash:   c.Hash(),
+			Format: format,
 		}
 	}
 
@@ -5,13 +5,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
-
-	b58 "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-base58"
+	"time"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
 	core "github.com/ipfs/go-ipfs/core"
+	keystore "github.com/ipfs/go-ipfs/keystore"
 	nsys "github.com/ipfs/go-ipfs/namesys"
 	crypto "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
@@ -22,31 +23,52 @@ var publishCmd = &cmds.Command{
 		Tagline: "Publish an object to IPNS",
 		ShortDescription: `
 IPNS is a PKI namespace, where names are the hashes of public keys, and
-the private key enables publishing new (signed) values. In publish, the
-default value of <name> is your own identity public key.
+the private key enables publishing new (signed) values. Names are
+published with your own identity key, unless another key from the
+keystore is given with --key.
 `,
 		LongDescription: `
 IPNS is a PKI namespace, where names are the hashes of public keys, and
-the private key enables publishing new (signed) values. In publish, the
-default value of <name> is your own identity public key.
+the private key enables publishing new (signed) values. Names are
+published with your own identity key, unless another key from the
+keystore is given with --key.
 
 Examples:
 
 Publish a <ref> to your identity name:
 
   > ipfs name publish QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
-  published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  Published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+
+Publish a path below a <ref>, or another name:
+
+  > ipfs name publish /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy/docs
+  Published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy/docs
+  > ipfs name publish /ipns/ipfs.io
+  Published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to /ipns/ipfs.io
+
+Publish a <ref> to the name of a key made with 'ipfs key gen':
 
-Publish a <ref> to another public key:
+  > ipfs key gen mykey
+  QmSomeKeyHash
+  > ipfs name publish --key=mykey QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  Published name QmSomeKeyHash to /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
-  > ipfs name publish QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
-  published name QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n to QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+Records are valid for 24 hours, unless another --lifetime is given, and
+replace the records published before them. With --ttl, resolvers are
+told how long they may cache the record for. Durations are written like
+"300ms", "1.5h" or "2h45m".
 `,
 	},
 
 	Arguments: []cmds.Argument{
 		cmds.StringArg("name", false, false, "The IPNS name to publish to. Defaults to your node's peerID"),
-		cmds.StringArg("ipfs-path", true, false, "IPFS path of the obejct to be published at <name>").EnableStdin(),
+		cmds.StringArg("ipfs-path", true, false, "IPFS or IPNS path of the object to be published at <name>").EnableStdin(),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("lifetime", "t", "How long the record is valid for (default: 24h)"),
+		cmds.StringOption("ttl", "How long resolvers may cache the record for"),
+		cmds.StringOption("key", "k", "Name of the key to publish with, see 'ipfs key list' (default: self)"),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		log.Debug("Begin Publish")
@@ -71,25 +93,66 @@ Publish a <ref> to another public key:
 			return
 		}
 
-		// name := ""
 		ref := ""
 
 		switch len(args) {
 		case 2:
-			// name = args[0]
-			ref = args[1]
-			res.SetError(errors.New("keychains not yet implemented"), cmds.ErrNormal)
+			res.SetError(errors.New("publishing to a name given as argument is not supported, use --key"), cmds.ErrClient)
+			return
 		case 1:
-			// name = n.Identity.ID.String()
 			ref = args[0]
 		}
 
-		// TODO n.Keychain.Get(name).PrivKey
-		output, err := publish(n, n.PrivateKey, ref)
+		p, err := path.ParsePath(ref)
+		if err != nil {
+			res.SetError(fmt.Errorf("cannot publish %q: %s", ref, err), cmds.ErrClient)
+			return
+		}
+
+		k := n.PrivateKey
+		if name, found, _ := req.Option("key").String(); found && name != keystore.SelfKeyName {
+			k, err = n.Repo.Keystore().Get(name)
+			if err != nil {
+				res.SetError(fmt.Errorf("cannot publish with key %q: %s", name, err), cmds.ErrNormal)
+				return
+			}
+		}
+
+		lifetime := nsys.DefaultRecordLifetime
+		if v, found, _ := req.Option("lifetime").String(); found {
+			lifetime, err = time.ParseDuration(v)
+			if err != nil {
+				res.SetError(fmt.Errorf("error parsing lifetime option: %s", err), cmds.ErrClient)
+				return
+			}
+			if lifetime <= 0 {
+				res.SetError(fmt.Errorf("lifetime must be positive, not %s", lifetime), cmds.ErrClient)
+				return
+			}
+		}
+
+		var ttl time.Duration
+		if v, found, _ := req.Option("ttl").String(); found {
+			ttl, err = time.ParseDuration(v)
+			if err != nil {
+				res.SetError(fmt.Errorf("error parsing ttl option: %s", err), cmds.ErrClient)
+				return
+			}
+		}
+
+		output, err := publish(n, k, p, time.Now().Add(lifetime), ttl)
 		if err != nil {
 			res.SetError(err, cmds.ErrNormal)
 			return
 		}
+
+		// make sure the republisher knows of the name, which is a no-op
+		// for the keys it was started with
+		if n.IpnsRepub != nil {
+			if err := n.IpnsRepub.AddKey(k); err != nil {
+				log.Errorf("republishing %s: %s", output.Name, err)
+			}
+		}
 		res.SetOutput(output)
 	},
 	Marshalers: cmds.MarshalerMap{
@@ -102,10 +165,8 @@ Publish a <ref> to another public key:
 	Type: IpnsEntry{},
 }
 
-func publish(n *core.IpfsNode, k crypto.PrivKey, ref string) (*IpnsEntry, error) {
-	pub := nsys.NewRoutingPublisher(n.Routing)
-	val := b58.Decode(ref)
-	err := pub.Publish(n.Context(), k, u.Key(val))
+func publish(n *core.IpfsNode, k crypto.PrivKey, p path.Path, eol time.Time, ttl time.Duration) (*IpnsEntry, error) {
+	err := n.Namesys.PublishWithEOL(n.Context(), k, p, eol, ttl)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +178,6 @@ func publish(n *core.IpfsNode, k crypto.PrivKey, ref string) (*IpnsEntry, error)
 
 	return &IpnsEntry{
 		Name:  u.Key(hash).String(),
-		Value: ref,
+		Value: p.String(),
 	}, nil
 }
@@ -7,10 +7,14 @@ import (
 	"sync"
 
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
+	cid "github.com/ipfs/go-ipfs/cid"
 	cmds "github.com/ipfs/go-ipfs/commands"
-	"github.com/ipfs/go-ipfs/core"
+	offline "github.com/ipfs/go-ipfs/exchange/offline"
 	dag "github.com/ipfs/go-ipfs/merkledag"
+	traverse "github.com/ipfs/go-ipfs/merkledag/traverse"
 	path "github.com/ipfs/go-ipfs/path"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
@@ -38,7 +42,10 @@ hashes it contains, with the following format:
 
   <link base58 hash>
 
-Note: list all refs recursively with -r.
+Note: list all refs recursively with -r. Recursive listings may be limited
+to the top of the dag with --max-depth, and listed breadth-first with --bfs.
+With --offline, objects missing locally are not fetched, and the listing
+stops at them.
 `,
 	},
 	Subcommands: map[string]*cmds.Command{
@@ -52,6 +59,9 @@ Note: list all refs recursively with -r.
 		cmds.BoolOption("edges", "e", "Emit edge format: `<from> -> <to>`"),
 		cmds.BoolOption("unique", "u", "Omit duplicate refs from output"),
 		cmds.BoolOption("recursive", "r", "Recursively list links of child nodes"),
+		cmds.IntOption("max-depth", "Only for recursive refs, list refs down to this depth (default: no limit)"),
+		cmds.BoolOption("bfs", "Only for recursive refs, list refs in breadth-first order"),
+		cmds.BoolOption("offline", "Do not fetch objects missing locally, list only the refs below local objects"),
 	},
 	Run: func(req cmds.Request, res cmds.Response) {
 		ctx := req.Context().Context
@@ -85,26 +95,73 @@ Note: list all refs recursively with -r.
 			return
 		}
 
-		objs, err := objectsForPaths(n, req.Arguments())
+		maxDepth, found, err := req.Option("max-depth").Int()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if !found {
+			maxDepth = -1
+		}
+
+		bfs, _, err := req.Option("bfs").Bool()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		order := traverse.DFSPre
+		if bfs {
+			order = traverse.BFS
+		}
+
+		offlineMode, _, err := req.Option("offline").Bool()
 		if err != nil {
 			res.SetError(err, cmds.ErrNormal)
 			return
 		}
 
+		// the offline blockservice is closed once the refs are written,
+		// to stop its workers
+		dagserv := n.DAG
+		var offlineBS *bserv.BlockService
+		if offlineMode {
+			offlineBS, err = bserv.New(n.Blockstore, offline.Exchange(n.Blockstore))
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+			dagserv = dag.NewDAGService(offlineBS)
+		}
+
+		objs, err := objectsForPaths(&path.Resolver{DAG: dagserv, ResolveOnce: uio.ResolveUnixfsOnce}, req.Arguments())
+		if err != nil {
+			if offlineBS != nil {
+				offlineBS.Close()
+			}
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
 		piper, pipew := io.Pipe()
 		eptr := &ErrPassThroughReader{R: piper}
 
 		go func() {
 			defer pipew.Close()
+			if offlineBS != nil {
+				defer offlineBS.Close()
+			}
 
 			rw := RefWriter{
-				W:         pipew,
-				DAG:       n.DAG,
-				Ctx:       ctx,
-				Unique:    unique,
-				PrintEdge: edges,
-				PrintFmt:  format,
-				Recursive: recursive,
+				W:           pipew,
+				DAG:         dagserv,
+				Ctx:         ctx,
+				Unique:      unique,
+				PrintEdge:   edges,
+				PrintFmt:    format,
+				Recursive:   recursive,
+				MaxDepth:    maxDepth,
+				Order:       order,
+				SkipMissing: offlineMode,
 			}
 
 			for _, o := range objs {
@@ -161,10 +218,10 @@ Displays the hashes of all local objects.
 	},
 }
 
-func objectsForPaths(n *core.IpfsNode, paths []string) ([]*dag.Node, error) {
+func objectsForPaths(r *path.Resolver, paths []string) ([]*dag.Node, error) {
 	objects := make([]*dag.Node, len(paths))
 	for i, p := range paths {
-		o, err := n.Resolver.ResolvePath(path.Path(p))
+		o, err := r.ResolvePath(path.Path(p))
 		if err != nil {
 			return nil, err
 		}
@@ -221,45 +278,125 @@ type RefWriter struct {
 	PrintEdge bool
 	PrintFmt  string
 
+	MaxDepth    int            // depth of recursive refs to list. negative for no limit
+	Order       traverse.Order // order of recursive refs: DFSPre (each ref, then the refs below it) or BFS
+	SkipMissing bool           // skip objects DAG cannot find, instead of failing
+
 	seen map[u.Key]struct{}
 }
 
 // WriteRefs writes refs of the given object to the underlying writer.
+// Objects at the last level listed are never fetched.
 func (rw *RefWriter) WriteRefs(n *dag.Node) (int, error) {
-	if rw.Recursive {
-		return rw.writeRefsRecursive(n)
-	} else {
-		return rw.writeRefsSingle(n)
+	maxDepth := rw.MaxDepth
+	if !rw.Recursive {
+		maxDepth = 1
+	}
+	if maxDepth == 0 {
+		return 0, nil
 	}
-}
 
-func (rw *RefWriter) writeRefsRecursive(n *dag.Node) (int, error) {
-	nkey, err := n.Key()
+	nc, err := n.Cid()
 	if err != nil {
 		return 0, err
 	}
 
-	if rw.skip(nkey) {
+	if rw.skip(nc.Key()) {
 		return 0, nil
 	}
 
-	var count int
-	for i, ng := range rw.DAG.GetDAG(rw.Ctx, n) {
-		lk := u.Key(n.Links[i].Hash)
-		if rw.skip(lk) {
+	if maxDepth == 1 {
+		return rw.writeLinks(n)
+	}
+	if rw.Order != traverse.BFS {
+		return rw.writeRefsRecursive(n, nc, maxDepth)
+	}
+
+	ctx := rw.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := traverse.Options{
+		DAG:            rw.DAG,
+		Order:          rw.Order,
+		SkipDuplicates: rw.Unique,
+	}
+	if maxDepth > 0 {
+		// the links of the deepest objects visited are the last level.
+		opts.MaxDepth = maxDepth - 1
+	}
+	if rw.SkipMissing {
+		opts.ErrFunc = traverse.SkipMissing
+	}
+
+	states, errs := traverse.Stream(ctx, n, opts)
+
+	count := 0
+	for s := range states {
+		c, err := rw.writeLinks(s.Node)
+		count += c
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, <-errs
+}
+
+// writeRefsRecursive writes each ref of n, followed by the refs below it,
+// down to depth levels, or all of them if depth is negative. The children
+// of n are fetched all at once, but when objects missing are skipped: they
+// are all local then, and GetDAG would wait on the missing ones.
+func (rw *RefWriter) writeRefsRecursive(n *dag.Node, nc *cid.Cid, depth int) (int, error) {
+	ctx := rw.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var getters []dag.NodeGetter
+	if depth != 1 && !rw.SkipMissing {
+		getters = rw.DAG.GetDAG(ctx, n)
+	}
+
+	count := 0
+	for i, l := range n.Links {
+		lc := l.Cid()
+		if rw.skip(lc.Key()) {
 			continue
 		}
 
-		if err := rw.WriteEdge(nkey, lk, n.Links[i].Name); err != nil {
+		if err := rw.WriteEdge(nc, lc, l.Name); err != nil {
 			return count, err
 		}
+		count++
+
+		if depth == 1 {
+			continue
+		}
 
-		nd, err := ng.Get(rw.Ctx)
+		var nd *dag.Node
+		var err error
+		if getters != nil {
+			nd, err = getters[i].Get(ctx)
+		} else {
+			nd, err = l.GetNode(rw.DAG)
+		}
+		if err != nil {
+			if rw.SkipMissing && traverse.SkipMissing(err) == nil {
+				continue
+			}
+			return count, err
+		}
+		ndc, err := nd.Cid()
 		if err != nil {
 			return count, err
 		}
 
-		c, err := rw.writeRefsRecursive(nd)
+		c, err := rw.writeRefsRecursive(nd, ndc, depth-1)
 		count += c
 		if err != nil {
 			return count, err
@@ -268,25 +405,21 @@ func (rw *RefWriter) writeRefsRecursive(n *dag.Node) (int, error) {
 	return count, nil
 }
 
-func (rw *RefWriter) writeRefsSingle(n *dag.Node) (int, error) {
-	nkey, err := n.Key()
+// writeLinks writes the refs held directly by n.
+func (rw *RefWriter) writeLinks(n *dag.Node) (int, error) {
+	nc, err := n.Cid()
 	if err != nil {
 		return 0, err
 	}
 
-	if rw.skip(nkey) {
-		return 0, nil
-	}
-
 	count := 0
 	for _, l := range n.Links {
-		lk := u.Key(l.Hash)
-
-		if rw.skip(lk) {
+		lc := l.Cid()
+		if rw.skip(lc.Key()) {
 			continue
 		}
 
-		if err := rw.WriteEdge(nkey, lk, l.Name); err != nil {
+		if err := rw.WriteEdge(nc, lc, l.Name); err != nil {
 			return count, err
 		}
 		count++
@@ -312,7 +445,7 @@ func (rw *RefWriter) skip(k u.Key) bool {
 }
 
 // Write one edge
-func (rw *RefWriter) WriteEdge(from, to u.Key, linkname string) error {
+func (rw *RefWriter) WriteEdge(from, to *cid.Cid, linkname string) error {
 	if rw.Ctx != nil {
 		select {
 		case <-rw.Ctx.Done(): // just in case.
@@ -325,13 +458,13 @@ func (rw *RefWriter) WriteEdge(from, to u.Key, linkname string) error {
 	switch {
 	case rw.PrintFmt != "":
 		s = rw.PrintFmt
-		s = strings.Replace(s, "<src>", from.Pretty(), -1)
-		s = strings.Replace(s, "<dst>", to.Pretty(), -1)
+		s = strings.Replace(s, "<src>", from.String(), -1)
+		s = strings.Replace(s, "<dst>", to.String(), -1)
 		s = strings.Replace(s, "<linkname>", linkname, -1)
 	case rw.PrintEdge:
-		s = from.Pretty() + " -> " + to.Pretty()
+		s = from.String() + " -> " + to.String()
 	default:
-		s += to.Pretty()
+		s += to.String()
 	}
 	s += "\n"
 
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	core "github.com/ipfs/go-ipfs/core"
+	keystore "github.com/ipfs/go-ipfs/keystore"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+type RepublishedName struct {
+	Name          string
+	Key           string
+	Value         string
+	LastRepublish time.Time
+	Error         string
+}
+
+type RepublisherOutput struct {
+	Names []RepublishedName
+}
+
+var nameRepublisherCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show when the names of this node were last republished",
+		ShortDescription: `
+The daemon publishes the names of its identity and of the keys in its
+keystore again, before their records expire. 'ipfs name republisher'
+lists each name, the key it belongs to, and when it was last republished.
+
+Names are republished every 4 hours, for 24 hours, unless told otherwise
+by the Ipns.RepublishPeriod and Ipns.RecordLifetime settings of the
+config.
+`,
+	},
+
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		if n.IpnsRepub == nil {
+			res.SetError(errNotOnline, cmds.ErrClient)
+			return
+		}
+
+		keyNames, err := keyNamesByID(n)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		var names []RepublishedName
+		for _, s := range n.IpnsRepub.Status() {
+			names = append(names, RepublishedName{
+				Name:          s.Name,
+				Key:           keyNames[s.Name],
+				Value:         s.Value.String(),
+				LastRepublish: s.LastRepublish,
+				Error:         s.Error,
+			})
+		}
+		sort.Sort(republishedNames(names))
+
+		res.SetOutput(&RepublisherOutput{names})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			out, ok := res.Output().(*RepublisherOutput)
+			if !ok {
+				return nil, u.ErrCast()
+			}
+			buf := new(bytes.Buffer)
+			for _, name := range out.Names {
+				fmt.Fprintf(buf, "%s (%s): ", name.Name, name.Key)
+				switch {
+				case name.Error != "":
+					fmt.Fprintf(buf, "%s\n", name.Error)
+				case name.LastRepublish.IsZero():
+					fmt.Fprintln(buf, "not republished yet")
+				default:
+					fmt.Fprintf(buf, "republished to %s at %s\n", name.Value, u.FormatRFC3339(name.LastRepublish))
+				}
+			}
+			return buf, nil
+		},
+	},
+	Type: RepublisherOutput{},
+}
+
+// keyNamesByID returns the names of the keys of the node, by the ids of
+// their names.
+func keyNamesByID(n *core.IpfsNode) (map[string]string, error) {
+	names := map[string]string{n.Identity.Pretty(): keystore.SelfKeyName}
+
+	ks := n.Repo.Keystore()
+	list, err := ks.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range list {
+		id, err := keyID(ks, name)
+		if err != nil {
+			return nil, err
+		}
+		names[id.Pretty()] = name
+	}
+	return names, nil
+}
+
+// republishedNames sorts the name of the node's identity first, and the
+// others by the names of their keys.
+type republishedNames []RepublishedName
+
+func (r republishedNames) Len() int      { return len(r) }
+func (r republishedNames) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r republishedNames) Less(i, j int) bool {
+	if r[i].Key == keystore.SelfKeyName || r[j].Key == keystore.SelfKeyName {
+		return r[i].Key == keystore.SelfKeyName
+	}
+	return r[i].Key < r[j].Key
+}
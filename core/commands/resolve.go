@@ -1,16 +1,20 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 
 	cmds "github.com/ipfs/go-ipfs/commands"
+	namesys "github.com/ipfs/go-ipfs/namesys"
+	path "github.com/ipfs/go-ipfs/path"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
-type ResolvedKey struct {
-	Key u.Key
+type ResolvedPath struct {
+	Path path.Path
 }
 
 var resolveCmd = &cmds.Command{
@@ -32,19 +36,35 @@ Examples:
 Resolve the value of your identity:
 
   > ipfs name resolve
-  QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  /ipfs/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
-Resolve te value of another name:
+Resolve the value of another name:
 
   > ipfs name resolve QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n
-  QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+  /ipns/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
 
+Resolve the value of a name, and of the names it points to, in turn:
+
+  > ipfs name resolve --recursive QmbCMUZw6JFeZ7Wp9jkzbye3Fzp2GGcPgC3nmeUjfVF87n
+  /ipfs/Qmcqtw8FfrVSBaRmbWwHxt3AuySBhJLcvmFYi3Lbc4xnwj
+
+Recursive resolution stops after 32 names, unless another --max-depth is
+given, and fails on names that resolve to themselves.
+
+Resolved names are cached by the daemon for as long as their records
+allow, a minute unless they tell otherwise. Use --nocache to resolve a
+name again.
 `,
 	},
 
 	Arguments: []cmds.Argument{
 		cmds.StringArg("name", false, false, "The IPNS name to resolve. Defaults to your node's peerID.").EnableStdin(),
 	},
+	Options: []cmds.Option{
+		cmds.BoolOption("nocache", "n", "Do not use cached entries"),
+		cmds.BoolOption("recursive", "r", "Resolve until the result is not an IPNS name"),
+		cmds.IntOption("max-depth", "Most names resolved in a recursive resolution (default: 32)"),
+	},
 	Run: func(req cmds.Request, res cmds.Response) {
 
 		n, err := req.Context().GetNode()
@@ -74,7 +94,39 @@ Resolve te value of another name:
 			name = req.Arguments()[0]
 		}
 
-		output, err := n.Namesys.Resolve(n.Context(), name)
+		ctx := n.Context()
+		if nocache, _, _ := req.Option("nocache").Bool(); nocache {
+			ctx = namesys.NoCache(ctx)
+		}
+
+		recursive, _, err := req.Option("recursive").Bool()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		maxDepth, found, err := req.Option("max-depth").Int()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if !found {
+			maxDepth = namesys.DefaultDepthLimit
+		}
+		if maxDepth < 1 {
+			res.SetError(errors.New("max-depth must be at least 1"), cmds.ErrClient)
+			return
+		}
+
+		depth := 1
+		if recursive {
+			depth = maxDepth
+		}
+
+		output, err := n.Namesys.ResolveN(ctx, name, depth)
+		// without --recursive, names resolving to other names are fine
+		if err == namesys.ErrResolveRecursion && !recursive {
+			err = nil
+		}
 		if err != nil {
 			res.SetError(err, cmds.ErrNormal)
 			return
@@ -82,16 +134,65 @@ Resolve te value of another name:
 
 		// TODO: better errors (in the case of not finding the name, we get "failed to find any peer in table")
 
-		res.SetOutput(&ResolvedKey{output})
+		res.SetOutput(&ResolvedPath{output})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			output, ok := res.Output().(*ResolvedPath)
+			if !ok {
+				return nil, u.ErrCast()
+			}
+			return strings.NewReader(output.Path.String()), nil
+		},
+	},
+	Type: ResolvedPath{},
+}
+
+var nameCacheCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show the counts of the cache of resolved names",
+		ShortDescription: `
+'ipfs name cache' shows how many names were resolved from the cache of
+the node, how many were not, and how many names are cached.
+`,
+	},
+
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		if !n.OnlineMode() {
+			err := n.SetupOfflineRouting()
+			if err != nil {
+				res.SetError(err, cmds.ErrNormal)
+				return
+			}
+		}
+
+		cns, ok := n.Namesys.(namesys.CachingNameSystem)
+		if !ok {
+			res.SetError(errors.New("the name system of this node has no cache"), cmds.ErrNormal)
+			return
+		}
+		stats := cns.CacheStats()
+		res.SetOutput(&stats)
 	},
 	Marshalers: cmds.MarshalerMap{
 		cmds.Text: func(res cmds.Response) (io.Reader, error) {
-			output, ok := res.Output().(*ResolvedKey)
+			stats, ok := res.Output().(*namesys.CacheStats)
 			if !ok {
 				return nil, u.ErrCast()
 			}
-			return strings.NewReader(output.Key.B58String()), nil
+			buf := new(bytes.Buffer)
+			fmt.Fprintln(buf, "name cache")
+			fmt.Fprintf(buf, "\thits: %d\n", stats.Hits)
+			fmt.Fprintf(buf, "\tmisses: %d\n", stats.Misses)
+			fmt.Fprintf(buf, "\tentries: %d\n", stats.Entries)
+			return buf, nil
 		},
 	},
-	Type: ResolvedKey{},
+	Type: namesys.CacheStats{},
 }
@@ -29,18 +29,24 @@ BASIC COMMANDS
     cat <ref>     Show ipfs object data
     get <ref>     Download ipfs objects
     ls <ref>      List links from an object
+    file          Interact with unixfs files and directories
     refs <ref>    List hashes of links from an object
+    urlstore      Add content from http and https urls
 
 DATA STRUCTURE COMMANDS
 
     block         Interact with raw blocks in the datastore
     object        Interact with raw dag nodes
+    dag           Interact with structured dag objects
+    cid           Convert and inspect content identifiers
+    tar           Utility functions for tar files in ipfs
 
 ADVANCED COMMANDS
 
     daemon        Start a long-running daemon process
     mount         Mount an ipfs read-only mountpoint
     name          Publish or resolve IPNS names
+    key           Create and manage the keys names are published with
     pin           Pin objects to local storage
     repo gc       Garbage collect unpinned objects
 
@@ -80,12 +86,16 @@ var rootSubcommands = map[string]*cmds.Command{
 	"block":     BlockCmd,
 	"bootstrap": BootstrapCmd,
 	"cat":       CatCmd,
+	"cid":       CidCmd,
 	"commands":  CommandsDaemonCmd,
 	"config":    ConfigCmd,
+	"dag":       DagCmd,
 	"dht":       DhtCmd,
 	"diag":      DiagCmd,
+	"file":      FileCmd,
 	"get":       GetCmd,
 	"id":        IDCmd,
+	"key":       KeyCmd,
 	"log":       LogCmd,
 	"ls":        LsCmd,
 	"mount":     MountCmd,
@@ -97,7 +107,9 @@ var rootSubcommands = map[string]*cmds.Command{
 	"repo":      RepoCmd,
 	"stats":     StatsCmd,
 	"swarm":     SwarmCmd,
+	"tar":       TarCmd,
 	"update":    UpdateCmd,
+	"urlstore":  UrlstoreCmd,
 	"version":   VersionCmd,
 	"bitswap":   BitswapCmd,
 }
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	path "github.com/ipfs/go-ipfs/path"
+	pinning "github.com/ipfs/go-ipfs/pin"
+	tar "github.com/ipfs/go-ipfs/unixfs/tar"
+)
+
+var TarCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Utility functions for tar files in ipfs",
+		ShortDescription: `
+'ipfs tar' stores tar archives in ipfs, keeping their structure: the
+contents of every file in an archive is stored as a separate unixfs file,
+so that files shared between archives, or with files added with 'ipfs
+add', are only stored once.`,
+		Synopsis: `
+ipfs tar add <file>       - Import a tar archive into ipfs
+ipfs tar cat <ipfs-path>  - Export a tar archive from ipfs
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"add": tarAddCmd,
+		"cat": tarCatCmd,
+	},
+}
+
+var tarAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import a tar archive into ipfs",
+		ShortDescription: `
+'ipfs tar add' parses the tar archive <file> and stores it in ipfs,
+keeping every header of the archive exactly as it is, with the contents
+of each file as a unixfs file. It outputs the hash of the archive, which
+'ipfs tar cat' turns back into the original archive.
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.FileArg("file", true, false, "Tar file to add").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		fi, err := req.Files().NextFile()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		mp, ok := n.Pinning.(pinning.ManualPinner)
+		if !ok {
+			res.SetError(errors.New("invalid pinner type! expected manual pinner"), cmds.ErrNormal)
+			return
+		}
+
+		root, err := tar.ImportTar(fi, n.DAG, mp)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if err := addNode(n, root); err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		if err := n.Pinning.Flush(); err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		c, err := root.Cid()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		res.SetOutput(&AddedObject{
+			Name: fi.FileName(),
+			Hash: c.String(),
+		})
+	},
+	Marshalers: cmds.MarshalerMap{
+		cmds.Text: func(res cmds.Response) (io.Reader, error) {
+			o := res.Output().(*AddedObject)
+			return strings.NewReader(o.Hash + "\n"), nil
+		},
+	},
+	Type: AddedObject{},
+}
+
+var tarCatCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Export a tar archive from ipfs",
+		ShortDescription: `
+'ipfs tar cat' outputs the tar archive stored in ipfs by 'ipfs tar add'
+as <ipfs-path>, byte for byte as it was added.
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("ipfs-path", true, false, "The path to the archive to output").EnableStdin(),
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		root, err := n.Resolver.ResolvePath(path.Path(req.Arguments()[0]))
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		r, err := tar.ExportTar(req.Context().Context, root, n.DAG)
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+		res.SetOutput(r)
+	},
+}
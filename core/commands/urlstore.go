@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	cmds "github.com/ipfs/go-ipfs/commands"
+	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
+	importer "github.com/ipfs/go-ipfs/importer"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	h "github.com/ipfs/go-ipfs/importer/helpers"
+	u "github.com/ipfs/go-ipfs/util"
+
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/cheggaaa/pb"
+)
+
+// URLAddedObject is the output of 'ipfs urlstore add'. Objects without a
+// hash report the progress of the download, or the error it failed with.
+type URLAddedObject struct {
+	Hash  string `json:",omitempty"`
+	URL   string
+	ETag  string `json:",omitempty"`
+	Size  int64  `json:",omitempty"`
+	Bytes int64  `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+var UrlstoreCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Interact with content fetched from urls",
+		Synopsis: `
+ipfs urlstore add <url>    - Add the content at a url to ipfs
+`,
+	},
+
+	Subcommands: map[string]*cmds.Command{
+		"add": urlAddCmd,
+	},
+}
+
+var urlAddCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add the content at a url to ipfs",
+		ShortDescription: `
+'ipfs urlstore add' downloads the content at <url> over http or https,
+and adds it as a file, streaming the response into ipfs as it is
+received: nothing is written to disk but the blocks of the file.
+
+The output records the url the content was read from, after redirects,
+and the ETag of the response, if any.
+`,
+	},
+
+	Arguments: []cmds.Argument{
+		cmds.StringArg("url", true, false, "The url of the content to add"),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("quiet", "q", "Write minimal output"),
+		cmds.BoolOption(progressOptionName, "p", "Stream progress data"),
+		cmds.BoolOption(rawLeavesOptionName, "Store file data in raw blocks"),
+		cmds.StringOption(chunkerOptionName, "s", "Chunking algorithm to use: size-<bytes>, rabin[-<min>-<avg>-<max>] or buzhash"),
+		hashOption,
+	},
+	PreRun: func(req cmds.Request) error {
+		if quiet, _, _ := req.Option("quiet").Bool(); !quiet {
+			req.SetOption(progressOptionName, true)
+		}
+		return nil
+	},
+	Run: func(req cmds.Request, res cmds.Response) {
+		n, err := req.Context().GetNode()
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		progress, _, _ := req.Option(progressOptionName).Bool()
+		rawLeaves, _, _ := req.Option(rawLeavesOptionName).Bool()
+		chunker, _, _ := req.Option(chunkerOptionName).String()
+		splitter, err := chunk.FromString(chunker)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+		hashFunc, err := getHashFunc(req)
+		if err != nil {
+			res.SetError(err, cmds.ErrClient)
+			return
+		}
+
+		src, err := coreunix.OpenURL(req.Context().Context, req.Arguments()[0])
+		if err != nil {
+			res.SetError(err, cmds.ErrNormal)
+			return
+		}
+
+		outChan := make(chan interface{})
+		res.SetOutput((<-chan interface{})(outChan))
+
+		go func() {
+			defer close(outChan)
+			defer src.Close()
+
+			var r io.Reader = src
+			if progress {
+				r = &urlProgressReader{src: src, out: outChan}
+			}
+			// the splitter takes any read error for the end of the data
+			rec := &readErrRecorder{r: r}
+
+			dbp := h.DagBuilderParams{
+				Dagserv:   n.DAG,
+				Pinner:    n.Pinning.GetManual(),
+				RawLeaves: rawLeaves,
+				HashFunc:  hashFunc,
+			}
+			nd, err := importer.BuildDagFromReaderParams(rec, splitter, dbp)
+			if err == nil && rec.err != nil {
+				err = fmt.Errorf("GET %s: %s", src.URL, rec.err)
+			}
+			if err != nil {
+				outChan <- &URLAddedObject{URL: src.URL, Error: err.Error()}
+				return
+			}
+			c, err := nd.Cid()
+			if err != nil {
+				outChan <- &URLAddedObject{URL: src.URL, Error: err.Error()}
+				return
+			}
+
+			outChan <- &URLAddedObject{
+				Hash: c.String(),
+				URL:  src.URL,
+				ETag: src.ETag,
+				Size: src.Size,
+			}
+		}()
+	},
+	PostRun: func(req cmds.Request, res cmds.Response) {
+		if res.Error() != nil {
+			return
+		}
+		outChan, ok := res.Output().(<-chan interface{})
+		if !ok {
+			res.SetError(u.ErrCast(), cmds.ErrNormal)
+			return
+		}
+		res.SetOutput(nil)
+
+		quiet, _, _ := req.Option("quiet").Bool()
+
+		var bar *pb.ProgressBar
+		var lastBytes int64
+		for out := range outChan {
+			output := out.(*URLAddedObject)
+			if output.Error != "" {
+				if bar != nil {
+					bar.Finish()
+				}
+				res.SetError(errors.New(output.Error), cmds.ErrNormal)
+				return
+			}
+			if len(output.Hash) == 0 {
+				if quiet || output.Size < progressBarMinSize {
+					continue
+				}
+				if bar == nil {
+					bar = pb.New64(output.Size).SetUnits(pb.U_BYTES)
+					bar.ManualUpdate = true
+					bar.Output = res.Stderr()
+					bar.Start()
+				}
+				bar.Add64(output.Bytes - lastBytes)
+				lastBytes = output.Bytes
+				bar.Update()
+				continue
+			}
+
+			if bar != nil {
+				bar.Finish()
+			}
+			if quiet {
+				fmt.Fprintf(res.Stdout(), "%s\n", output.Hash)
+				continue
+			}
+			fmt.Fprintf(res.Stdout(), "added %s %s\n", output.Hash, output.URL)
+			if output.ETag != "" {
+				fmt.Fprintf(res.Stdout(), "etag %s\n", output.ETag)
+			}
+		}
+	},
+	Type: URLAddedObject{},
+}
+
+// urlProgressReader sends progress updates over out as src is read
+type urlProgressReader struct {
+	src          *coreunix.URLSource
+	out          chan interface{}
+	bytes        int64
+	lastProgress int64
+}
+
+func (i *urlProgressReader) Read(p []byte) (int, error) {
+	n, err := i.src.Read(p)
+
+	i.bytes += int64(n)
+	if i.bytes-i.lastProgress >= progressReaderIncrement || err == io.EOF {
+		i.lastProgress = i.bytes
+		i.out <- &URLAddedObject{
+			URL:   i.src.URL,
+			Size:  i.src.Size,
+			Bytes: i.bytes,
+		}
+	}
+
+	return n, err
+}
+
+// readErrRecorder keeps the first error other than io.EOF that reading
+// from r returns.
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (e *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
@@ -39,13 +39,16 @@ import (
 	rp "github.com/ipfs/go-ipfs/exchange/reprovide"
 
 	mount "github.com/ipfs/go-ipfs/fuse/mount"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
 	ipnsfs "github.com/ipfs/go-ipfs/ipnsfs"
 	merkledag "github.com/ipfs/go-ipfs/merkledag"
 	namesys "github.com/ipfs/go-ipfs/namesys"
+	ipnsrp "github.com/ipfs/go-ipfs/namesys/republisher"
 	path "github.com/ipfs/go-ipfs/path"
 	pin "github.com/ipfs/go-ipfs/pin"
 	repo "github.com/ipfs/go-ipfs/repo"
 	config "github.com/ipfs/go-ipfs/repo/config"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 )
 
 const IpnsValidatorTag = "ipns"
@@ -92,6 +95,7 @@ type IpfsNode struct {
 	Namesys      namesys.NameSystem  // the name system, resolves paths to hashes
 	Diagnostics  *diag.Diagnostics   // the diagnostics service
 	Reprovider   *rp.Reprovider      // the value reprovider system
+	IpnsRepub    *ipnsrp.Republisher // the ipns name republisher
 
 	IpnsFs *ipnsfs.Filesystem
 
@@ -143,7 +147,7 @@ func NewIPFSNode(parent context.Context, option ConfigOption) (*IpfsNode, error)
 	if err != nil {
 		node.Pinning = pin.NewPinner(node.Repo.Datastore(), node.DAG)
 	}
-	node.Resolver = &path.Resolver{DAG: node.DAG}
+	node.Resolver = &path.Resolver{DAG: node.DAG, ResolveOnce: uio.ResolveUnixfsOnce}
 
 	// Setup the mutable ipns filesystem structure
 	if node.OnlineMode() {
@@ -151,6 +155,14 @@ func NewIPFSNode(parent context.Context, option ConfigOption) (*IpfsNode, error)
 		if err != nil && err != kb.ErrLookupFailure {
 			return nil, debugerror.Wrap(err)
 		}
+		if fs != nil {
+			spl, err := chunk.FromString(node.Repo.Config().Mounts.Chunker)
+			if err != nil {
+				return nil, debugerror.Wrap(err)
+			}
+			fs.SetSplitter(spl)
+			node.addKeystoreRoots(ctx, fs)
+		}
 		node.IpnsFs = fs
 	}
 
@@ -261,6 +273,10 @@ func (n *IpfsNode) startOnlineServices(ctx context.Context, routingOption Routin
 	n.Reprovider = rp.NewReprovider(n.Routing, n.Blockstore)
 	go n.Reprovider.ProvideEvery(ctx, kReprovideFrequency)
 
+	if err := n.setupIpnsRepublisher(ctx); err != nil {
+		return err
+	}
+
 	return n.Bootstrap(DefaultBootstrapConfig)
 }
 
@@ -286,9 +302,7 @@ func (n *IpfsNode) startOnlineServicesWithHost(ctx context.Context, host p2phost
 	n.Exchange = bitswap.New(ctx, n.Identity, bitswapNetwork, n.Blockstore, alwaysSendToPeer)
 
 	// setup name system
-	n.Namesys = namesys.NewNameSystem(n.Routing)
-
-	return nil
+	return n.setupNamesys()
 }
 
 // teardown closes owned children. If any errors occur, this function returns
@@ -395,6 +409,90 @@ func (n *IpfsNode) loadID() error {
 	return nil
 }
 
+// setupNamesys sets up the name system on the routing of the node, looking
+// up dns links with the resolver the config tells.
+func (n *IpfsNode) setupNamesys() error {
+	txt, err := namesys.ParseTXTResolver(n.Repo.Config().DNS.Resolver)
+	if err != nil {
+		return debugerror.Errorf("failure to parse config setting DNS.Resolver: %s", err)
+	}
+	n.Namesys = namesys.NewNameSystem(n.Routing, n.Repo.Datastore(), txt)
+	return nil
+}
+
+// setupIpnsRepublisher starts republishing the names of the node's
+// identity, and of the keys in its keystore, as the config tells.
+func (n *IpfsNode) setupIpnsRepublisher(ctx context.Context) error {
+	repub := ipnsrp.NewRepublisher(n.Routing, n.Repo.Datastore())
+
+	cfg := n.Repo.Config().Ipns
+	if cfg.RepublishPeriod != "" {
+		d, err := time.ParseDuration(cfg.RepublishPeriod)
+		if err != nil {
+			return debugerror.Errorf("failure to parse config setting Ipns.RepublishPeriod: %s", err)
+		}
+		if d <= 0 {
+			return debugerror.Errorf("config setting Ipns.RepublishPeriod must be positive, not %s", d)
+		}
+		repub.Period = d
+	}
+	if cfg.RecordLifetime != "" {
+		d, err := time.ParseDuration(cfg.RecordLifetime)
+		if err != nil {
+			return debugerror.Errorf("failure to parse config setting Ipns.RecordLifetime: %s", err)
+		}
+		if d <= 0 {
+			return debugerror.Errorf("config setting Ipns.RecordLifetime must be positive, not %s", d)
+		}
+		repub.RecordLifetime = d
+	}
+
+	if err := repub.AddKey(n.PrivateKey); err != nil {
+		return err
+	}
+
+	ks := n.Repo.Keystore()
+	names, err := ks.List()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		k, err := ks.Get(name)
+		if err != nil {
+			log.Errorf("loading key %s: %s", name, err)
+			continue
+		}
+		if err := repub.AddKey(k); err != nil {
+			log.Errorf("republishing the name of key %s: %s", name, err)
+		}
+	}
+
+	n.IpnsRepub = repub
+	go repub.Run(ctx)
+	return nil
+}
+
+// addKeystoreRoots creates the ipnsfs roots of the keys in the keystore.
+// Keys whose roots can't be created are left out, and logged.
+func (n *IpfsNode) addKeystoreRoots(ctx context.Context, fs *ipnsfs.Filesystem) {
+	ks := n.Repo.Keystore()
+	names, err := ks.List()
+	if err != nil {
+		log.Errorf("listing keystore: %s", err)
+		return
+	}
+	for _, name := range names {
+		k, err := ks.Get(name)
+		if err != nil {
+			log.Errorf("loading key %s: %s", name, err)
+			continue
+		}
+		if _, err := fs.AddRoot(ctx, k); err != nil {
+			log.Errorf("creating ipnsfs root of key %s: %s", name, err)
+		}
+	}
+}
+
 func (n *IpfsNode) LoadPrivateKey() error {
 	if n.Identity == "" || n.Peerstore == nil {
 		return debugerror.New("loaded private key out of order.")
@@ -434,9 +532,7 @@ func (n *IpfsNode) SetupOfflineRouting() error {
 
 	n.Routing = offroute.NewOfflineRouter(n.Repo.Datastore(), n.PrivateKey)
 
-	n.Namesys = namesys.NewNameSystem(n.Routing)
-
-	return nil
+	return n.setupNamesys()
 }
 
 func loadPrivateKey(cfg *config.Identity, id peer.ID) (ic.PrivKey, error) {
@@ -520,6 +616,7 @@ func startListening(ctx context.Context, host p2phost.Host, cfg *config.Config)
 func constructDHTRouting(ctx context.Context, host p2phost.Host, dstore ds.ThreadSafeDatastore) (routing.IpfsRouting, error) {
 	dhtRouting := dht.NewDHT(ctx, host, dstore)
 	dhtRouting.Validator[IpnsValidatorTag] = namesys.IpnsRecordValidator
+	dhtRouting.Selector[IpnsValidatorTag] = namesys.NewIpnsSelectorFunc(dhtRouting.LocalPublicKey)
 	return dhtRouting, nil
 }
 
@@ -17,6 +17,10 @@ type Gateway struct {
 type GatewayConfig struct {
 	BlockList *BlockList
 	Writable  bool
+
+	// ReadAhead is the number of bytes of a file fetched ahead of what is
+	// being served, or zero for uio.DefaultReadAhead
+	ReadAhead int64
 }
 
 func NewGateway(conf GatewayConfig) *Gateway {
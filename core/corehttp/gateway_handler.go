@@ -30,7 +30,7 @@ const (
 
 type gateway interface {
 	ResolvePath(string) (*dag.Node, error)
-	NewDagFromReader(io.Reader) (*dag.Node, error)
+	NewDagFromReader(io.Reader, chunk.BlockSplitter) (*dag.Node, error)
 	AddNodeToDAG(nd *dag.Node) (u.Key, error)
 	NewDagReader(nd *dag.Node) (uio.ReadSeekCloser, error)
 }
@@ -81,12 +81,15 @@ func (i *gatewayHandler) resolveNamePath(ctx context.Context, p string) (string,
 	if strings.HasPrefix(p, IpnsPathPrefix) {
 		elements := strings.Split(p[len(IpnsPathPrefix):], "/")
 		hash := elements[0]
-		k, err := i.node.Namesys.Resolve(ctx, hash)
+		resolved, err := i.node.Namesys.Resolve(ctx, hash)
 		if err != nil {
 			return "", err
 		}
+		if resolved.IsIpns() {
+			return "", fmt.Errorf("%s resolved to %s, which can't be resolved further", hash, resolved)
+		}
 
-		elements[0] = k.Pretty()
+		elements[0] = resolved.String()
 		p = gopath.Join(elements...)
 	}
 	if !strings.HasPrefix(p, IpfsPathPrefix) {
@@ -108,9 +111,15 @@ func (i *gatewayHandler) ResolvePath(ctx context.Context, p string) (*dag.Node,
 	return node, p, err
 }
 
-func (i *gatewayHandler) NewDagFromReader(r io.Reader) (*dag.Node, error) {
+func (i *gatewayHandler) NewDagFromReader(r io.Reader, spl chunk.BlockSplitter) (*dag.Node, error) {
 	return importer.BuildDagFromReader(
-		r, i.node.DAG, i.node.Pinning.GetManual(), chunk.DefaultSplitter)
+		r, i.node.DAG, i.node.Pinning.GetManual(), spl)
+}
+
+// requestSplitter returns the splitter selected by the chunker parameter
+// of the request, as with 'ipfs add --chunker'.
+func requestSplitter(r *http.Request) (chunk.BlockSplitter, error) {
+	return chunk.FromString(r.URL.Query().Get("chunker"))
 }
 
 func NewDagEmptyDir() *dag.Node {
@@ -122,7 +131,14 @@ func (i *gatewayHandler) AddNodeToDAG(nd *dag.Node) (u.Key, error) {
 }
 
 func (i *gatewayHandler) NewDagReader(nd *dag.Node) (uio.ReadSeekCloser, error) {
-	return uio.NewDagReader(i.node.Context(), nd, i.node.DAG)
+	dr, err := uio.NewDagReader(i.node.Context(), nd, i.node.DAG)
+	if err != nil {
+		return nil, err
+	}
+	if i.config.ReadAhead != 0 {
+		dr.SetReadAhead(i.config.ReadAhead)
+	}
+	return dr, nil
 }
 
 // TODO(btc): break this apart into separate handlers using a more expressive
@@ -214,6 +230,7 @@ func (i *gatewayHandler) getHandler(w http.ResponseWriter, r *http.Request) {
 
 	if err == nil {
 		defer dr.Close()
+		setMimeType(w, nd)
 		_, name := gopath.Split(urlPath)
 		http.ServeContent(w, r, name, modtime, dr)
 		return
@@ -223,7 +240,12 @@ func (i *gatewayHandler) getHandler(w http.ResponseWriter, r *http.Request) {
 	var dirListing []directoryItem
 	// loop through files
 	foundIndex := false
-	for _, link := range nd.Links {
+	links, err := uio.DirectoryLinks(i.node.DAG, nd)
+	if err != nil {
+		internalWebError(w, err)
+		return
+	}
+	for _, link := range links {
 		if link.Name == "index.html" {
 			if urlPath[len(urlPath)-1] != '/' {
 				http.Redirect(w, r, urlPath+"/", 302)
@@ -244,6 +266,7 @@ func (i *gatewayHandler) getHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			defer dr.Close()
+			setMimeType(w, nd)
 			// write to request
 			io.Copy(w, dr)
 			break
@@ -266,8 +289,27 @@ func (i *gatewayHandler) getHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// setMimeType sets the Content-Type of the response to the type recorded
+// in nd, if it is a metadata node. Otherwise, it is left to be detected.
+func setMimeType(w http.ResponseWriter, nd *dag.Node) {
+	if nd.Format != dag.FormatProtobuf {
+		return
+	}
+	md, err := ufs.MetadataFromBytes(nd.Data)
+	if err != nil || md.MimeType == "" {
+		return
+	}
+	w.Header().Set("Content-Type", md.MimeType)
+}
+
 func (i *gatewayHandler) postHandler(w http.ResponseWriter, r *http.Request) {
-	nd, err := i.NewDagFromReader(r.Body)
+	spl, err := requestSplitter(r)
+	if err != nil {
+		webError(w, "Invalid chunker", err, http.StatusBadRequest)
+		return
+	}
+
+	nd, err := i.NewDagFromReader(r.Body, spl)
 	if err != nil {
 		internalWebError(w, err)
 		return
@@ -310,7 +352,12 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 	if pathext[len(pathext)-1] == '/' {
 		newnode = NewDagEmptyDir()
 	} else {
-		newnode, err = i.NewDagFromReader(r.Body)
+		spl, err := requestSplitter(r)
+		if err != nil {
+			webError(w, "Invalid chunker", err, http.StatusBadRequest)
+			return
+		}
+		newnode, err = i.NewDagFromReader(r.Body, spl)
 		if err != nil {
 			webError(w, "Could not create DAG from request", err, http.StatusInternalServerError)
 			return
@@ -327,7 +374,7 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h, components, err := path.SplitAbsPath(path.Path(ipfspath))
+	c, components, err := path.SplitAbsPath(path.Path(ipfspath))
 	if err != nil {
 		webError(w, "Could not split path", err, http.StatusInternalServerError)
 		return
@@ -341,7 +388,7 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rootnd, err := i.node.Resolver.DAG.Get(u.Key(h))
+	rootnd, err := dag.GetCid(i.node.Resolver.DAG, c)
 	if err != nil {
 		webError(w, "Could not resolve root object", err, http.StatusBadRequest)
 		return
@@ -397,13 +444,13 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h, components, err := path.SplitAbsPath(path.Path(ipfspath))
+	c, components, err := path.SplitAbsPath(path.Path(ipfspath))
 	if err != nil {
 		webError(w, "Could not split path", err, http.StatusInternalServerError)
 		return
 	}
 
-	rootnd, err := i.node.Resolver.DAG.Get(u.Key(h))
+	rootnd, err := dag.GetCid(i.node.Resolver.DAG, c)
 	if err != nil {
 		webError(w, "Could not resolve root object", err, http.StatusBadRequest)
 		return
@@ -8,31 +8,35 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
-	b58 "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-base58"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	core "github.com/ipfs/go-ipfs/core"
 	coreunix "github.com/ipfs/go-ipfs/core/coreunix"
 	namesys "github.com/ipfs/go-ipfs/namesys"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	repo "github.com/ipfs/go-ipfs/repo"
 	config "github.com/ipfs/go-ipfs/repo/config"
-	u "github.com/ipfs/go-ipfs/util"
 	testutil "github.com/ipfs/go-ipfs/util/testutil"
 )
 
 type mockNamesys map[string]string
 
-func (m mockNamesys) Resolve(ctx context.Context, name string) (value u.Key, err error) {
+func (m mockNamesys) Resolve(ctx context.Context, name string) (value path.Path, err error) {
+	return m.ResolveN(ctx, name, namesys.DefaultDepthLimit)
+}
+
+func (m mockNamesys) ResolveN(ctx context.Context, name string, depth int) (value path.Path, err error) {
 	enc, ok := m[name]
 	if !ok {
 		return "", namesys.ErrResolveFailed
 	}
-	dec := b58.Decode(enc)
-	if len(dec) == 0 {
-		return "", fmt.Errorf("invalid b58 string for name %q: %q", name, enc)
+	p, err := path.ParsePath(enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid path for name %q: %q", name, enc)
 	}
-	return u.Key(dec), nil
+	return p, nil
 }
 
 func (m mockNamesys) CanResolve(name string) bool {
@@ -40,7 +44,11 @@ func (m mockNamesys) CanResolve(name string) bool {
 	return ok
 }
 
-func (m mockNamesys) Publish(ctx context.Context, name ci.PrivKey, value u.Key) error {
+func (m mockNamesys) Publish(ctx context.Context, name ci.PrivKey, value path.Path) error {
+	return errors.New("not implemented for mockNamesys")
+}
+
+func (m mockNamesys) PublishWithEOL(ctx context.Context, name ci.PrivKey, value path.Path, eol time.Time, ttl time.Duration) error {
 	return errors.New("not implemented for mockNamesys")
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/ipfs/go-ipfs/pin"
 	"github.com/ipfs/go-ipfs/thirdparty/eventlog"
 	unixfs "github.com/ipfs/go-ipfs/unixfs"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 )
 
 var log = eventlog.Logger("coreunix")
@@ -120,6 +121,14 @@ func addFile(n *core.IpfsNode, file files.File) (*merkledag.Node, error) {
 		return addDir(n, file)
 	}
 
+	if s, ok := file.(*files.Symlink); ok {
+		node := &merkledag.Node{Data: unixfs.SymlinkData(s.Target)}
+		if err := addNode(n, node); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
 	dns, err := add(n, []io.Reader{file})
 	if err != nil {
 		return nil, err
@@ -130,7 +139,7 @@ func addFile(n *core.IpfsNode, file files.File) (*merkledag.Node, error) {
 
 func addDir(n *core.IpfsNode, dir files.File) (*merkledag.Node, error) {
 
-	tree := &merkledag.Node{Data: unixfs.FolderPBData()}
+	dirb := uio.NewDirectory(n.DAG)
 
 Loop:
 	for {
@@ -149,13 +158,18 @@ Loop:
 
 		_, name := gopath.Split(file.FileName())
 
-		err = tree.AddNodeLink(name, node)
+		err = dirb.AddChildNode(name, node)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	err := addNode(n, tree)
+	tree, err := dirb.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	err = addNode(n, tree)
 	if err != nil {
 		return nil, err
 	}
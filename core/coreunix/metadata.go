@@ -1,15 +1,18 @@
 package coreunix
 
 import (
+	cid "github.com/ipfs/go-ipfs/cid"
 	core "github.com/ipfs/go-ipfs/core"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	ft "github.com/ipfs/go-ipfs/unixfs"
-	u "github.com/ipfs/go-ipfs/util"
 )
 
 func AddMetadataTo(n *core.IpfsNode, key string, m *ft.Metadata) (string, error) {
-	ukey := u.B58KeyDecode(key)
-	nd, err := n.DAG.Get(ukey)
+	c, err := cid.Decode(key)
+	if err != nil {
+		return "", err
+	}
+	nd, err := n.DAG.Get(c.Key())
 	if err != nil {
 		return "", err
 	}
@@ -35,8 +38,11 @@ func AddMetadataTo(n *core.IpfsNode, key string, m *ft.Metadata) (string, error)
 }
 
 func Metadata(n *core.IpfsNode, key string) (*ft.Metadata, error) {
-	ukey := u.B58KeyDecode(key)
-	nd, err := n.DAG.Get(ukey)
+	c, err := cid.Decode(key)
+	if err != nil {
+		return nil, err
+	}
+	nd, err := n.DAG.Get(c.Key())
 	if err != nil {
 		return nil, err
 	}
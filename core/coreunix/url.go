@@ -0,0 +1,83 @@
+package coreunix
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+// URLSource is the body of the response to a GET of a URL.
+type URLSource struct {
+	io.ReadCloser
+
+	// URL is the URL the body was read from, after redirects
+	URL string
+
+	// ETag is the entity tag of the response, if it had one
+	ETag string
+
+	// Size is the Content-Length of the response, or -1 if unknown
+	Size int64
+}
+
+// ErrURLScheme signals a URL that is neither http nor https.
+var ErrURLScheme = fmt.Errorf("only http and https URLs can be added")
+
+// OpenURL starts a GET of u, returning the body of the response. Only
+// successful responses are returned. The body must be closed.
+func OpenURL(ctx context.Context, u string) (*URLSource, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, ErrURLScheme
+	}
+
+	req, err := http.NewRequest("GET", parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+
+	// stop reading the body once ctx is done
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Body.Close()
+		case <-done:
+		}
+	}()
+
+	return &URLSource{
+		ReadCloser: &closeNotifier{resp.Body, done},
+		URL:        resp.Request.URL.String(),
+		ETag:       resp.Header.Get("ETag"),
+		Size:       resp.ContentLength,
+	}, nil
+}
+
+type closeNotifier struct {
+	io.ReadCloser
+	done chan struct{}
+}
+
+func (c *closeNotifier) Close() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	return c.ReadCloser.Close()
+}
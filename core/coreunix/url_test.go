@@ -0,0 +1,103 @@
+package coreunix
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/ipfs/go-ipfs/core"
+	"github.com/ipfs/go-ipfs/repo"
+	"github.com/ipfs/go-ipfs/repo/config"
+	"github.com/ipfs/go-ipfs/util/testutil"
+)
+
+func testNode(t *testing.T) *core.IpfsNode {
+	r := &repo.Mock{
+		C: config.Config{
+			Identity: config.Identity{
+				PeerID: "Qmfoo", // required by offline node
+			},
+		},
+		D: testutil.ThreadSafeCloserMapDatastore(),
+	}
+	node, err := core.NewIPFSNode(context.Background(), core.Offline(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestOpenURL(t *testing.T) {
+	data := make([]byte, 1<<20+5)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/file", http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	node := testNode(t)
+	expected, err := Add(node, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := OpenURL(context.Background(), ts.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	k, err := Add(node, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k != expected {
+		t.Fatalf("added %s, expected %s", k, expected)
+	}
+	if src.URL != ts.URL+"/file" {
+		t.Fatalf("expected the redirected url, got %s", src.URL)
+	}
+	if src.ETag != `"v1"` {
+		t.Fatalf("expected the etag of the response, got %s", src.ETag)
+	}
+	if src.Size != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), src.Size)
+	}
+
+	r, err := Cat(node, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatal("read back the wrong data")
+	}
+}
+
+func TestOpenURLErrors(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	if _, err := OpenURL(context.Background(), ts.URL+"/nothing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if _, err := OpenURL(context.Background(), "ftp://example.com/file"); err != ErrURLScheme {
+		t.Fatalf("expected %v, got %v", ErrURLScheme, err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	pin "github.com/ipfs/go-ipfs/pin"
 	"github.com/ipfs/go-ipfs/repo"
 	offrt "github.com/ipfs/go-ipfs/routing/offline"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	ds2 "github.com/ipfs/go-ipfs/util/datastore2"
 	testutil "github.com/ipfs/go-ipfs/util/testutil"
 )
@@ -69,10 +70,10 @@ func NewMockNode() (*IpfsNode, error) {
 	nd.Pinning = pin.NewPinner(nd.Repo.Datastore(), nd.DAG)
 
 	// Namespace resolver
-	nd.Namesys = nsys.NewNameSystem(nd.Routing)
+	nd.Namesys = nsys.NewNameSystem(nd.Routing, nd.Repo.Datastore(), nil)
 
 	// Path resolver
-	nd.Resolver = &path.Resolver{DAG: nd.DAG}
+	nd.Resolver = &path.Resolver{DAG: nd.DAG, ResolveOnce: uio.ResolveUnixfsOnce}
 
 	return nd, nil
 }
@@ -5,6 +5,7 @@ import (
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	nsys "github.com/ipfs/go-ipfs/namesys"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	ft "github.com/ipfs/go-ipfs/unixfs"
 )
 
@@ -27,8 +28,8 @@ func InitializeKeyspace(n *core.IpfsNode, key ci.PrivKey) error {
 		return err
 	}
 
-	pub := nsys.NewRoutingPublisher(n.Routing)
-	err = pub.Publish(n.Context(), key, nodek)
+	pub := nsys.NewRoutingPublisher(n.Routing, n.Repo.Datastore())
+	err = pub.Publish(n.Context(), key, path.Path("/ipfs/"+nodek.B58String()))
 	if err != nil {
 		return err
 	}
@@ -7,6 +7,7 @@ package ipns
 import (
 	"errors"
 	"os"
+	"strings"
 
 	fuse "github.com/ipfs/go-ipfs/Godeps/_workspace/src/bazil.org/fuse"
 	fs "github.com/ipfs/go-ipfs/Godeps/_workspace/src/bazil.org/fuse/fs"
@@ -142,8 +143,12 @@ func (s *Root) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		log.Warningf("ipns: namesys resolve error: %s", err)
 		return nil, fuse.ENOENT
 	}
+	if !strings.HasPrefix(resolved.String(), "/ipfs/") {
+		log.Warningf("ipns: %s did not resolve to an ipfs path: %s", name, resolved)
+		return nil, fuse.ENOENT
+	}
 
-	return &Link{s.IpfsRoot + "/" + resolved.B58String()}, nil
+	return &Link{s.IpfsRoot + strings.TrimPrefix(resolved.String(), "/ipfs")}, nil
 }
 
 func (r *Root) Close() error {
@@ -207,11 +212,11 @@ type File struct {
 // Attr returns the attributes of a given node.
 func (d *Directory) Attr() fuse.Attr {
 	log.Debug("Directory Attr")
-	return fuse.Attr{
+	return recordedAttr(d.dir, fuse.Attr{
 		Mode: os.ModeDir | 0555,
 		Uid:  uint32(os.Getuid()),
 		Gid:  uint32(os.Getgid()),
-	}
+	})
 }
 
 // Attr returns the attributes of a given node.
@@ -222,12 +227,33 @@ func (fi *File) Attr() fuse.Attr {
 		// In this case, the dag node in question may not be unixfs
 		log.Critical("Failed to get file size: %s", err)
 	}
-	return fuse.Attr{
+	return recordedAttr(fi.fi, fuse.Attr{
 		Mode: os.FileMode(0666),
 		Size: uint64(size),
 		Uid:  uint32(os.Getuid()),
 		Gid:  uint32(os.Getgid()),
+	})
+}
+
+// recordedAttr sets the mode and modification time in a to those recorded
+// in the node of n, if any.
+func recordedAttr(n nsfs.FSNode, a fuse.Attr) fuse.Attr {
+	nd, err := n.GetNode()
+	if err != nil {
+		return a
+	}
+	pbd, err := ft.FromNode(nd)
+	if err != nil {
+		return a
+	}
+
+	if mode, ok := ft.Mode(pbd); ok {
+		a.Mode = a.Mode&os.ModeType | mode
+	}
+	if mtime, ok := ft.ModTime(pbd); ok {
+		a.Mtime = mtime
 	}
+	return a
 }
 
 // Lookup performs a lookup under this node.
@@ -243,6 +269,8 @@ func (s *Directory) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		return &Directory{dir: child}, nil
 	case *nsfs.File:
 		return &File{fi: child}, nil
+	case *nsfs.Symlink:
+		return &Link{child.Target}, nil
 	default:
 		// NB: if this happens, we do not want to continue, unpredictable behaviour
 		// may occur.
@@ -267,6 +295,8 @@ func (dir *Directory) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 			dirent.Type = fuse.DT_Dir
 		case nsfs.TFile:
 			dirent.Type = fuse.DT_File
+		case nsfs.TSymlink:
+			dirent.Type = fuse.DT_Link
 		}
 
 		entries = append(entries, dirent)
@@ -6,15 +6,17 @@ package readonly
 import (
 	"io"
 	"os"
+	"sync"
+	"syscall"
 
 	fuse "github.com/ipfs/go-ipfs/Godeps/_workspace/src/bazil.org/fuse"
 	fs "github.com/ipfs/go-ipfs/Godeps/_workspace/src/bazil.org/fuse/fs"
-	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	core "github.com/ipfs/go-ipfs/core"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	path "github.com/ipfs/go-ipfs/path"
 	eventlog "github.com/ipfs/go-ipfs/thirdparty/eventlog"
+	ft "github.com/ipfs/go-ipfs/unixfs"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	ftpb "github.com/ipfs/go-ipfs/unixfs/pb"
 	lgbl "github.com/ipfs/go-ipfs/util/eventlog/loggables"
@@ -25,21 +27,27 @@ var log = eventlog.Logger("fuse/ipfs")
 // FileSystem is the readonly Ipfs Fuse Filesystem.
 type FileSystem struct {
 	Ipfs *core.IpfsNode
+
+	// ReadAhead is the number of bytes of an open file fetched ahead of
+	// what is being read, or zero for uio.DefaultReadAhead
+	ReadAhead int64
 }
 
-// NewFileSystem constructs new fs using given core.IpfsNode instance.
+// NewFileSystem constructs new fs using given core.IpfsNode instance,
+// with the read-ahead of its config.
 func NewFileSystem(ipfs *core.IpfsNode) *FileSystem {
-	return &FileSystem{Ipfs: ipfs}
+	return &FileSystem{Ipfs: ipfs, ReadAhead: ipfs.Repo.Config().Mounts.ReadAhead}
 }
 
 // Root constructs the Root of the filesystem, a Root object.
 func (f FileSystem) Root() (fs.Node, error) {
-	return &Root{Ipfs: f.Ipfs}, nil
+	return &Root{Ipfs: f.Ipfs, readAhead: f.ReadAhead}, nil
 }
 
 // Root is the root object of the filesystem tree.
 type Root struct {
-	Ipfs *core.IpfsNode
+	Ipfs      *core.IpfsNode
+	readAhead int64
 }
 
 // Attr returns file attributes.
@@ -47,6 +55,19 @@ func (*Root) Attr() fuse.Attr {
 	return fuse.Attr{Mode: os.ModeDir | 0111} // -rw+x
 }
 
+// recordedAttr sets the mode and modification time in a to those recorded
+// in the node, if any. Write permission is never granted, as the mount is
+// read only.
+func (s *Node) recordedAttr(a fuse.Attr) fuse.Attr {
+	if mode, ok := ft.Mode(s.cached); ok {
+		a.Mode = a.Mode&os.ModeType | mode&^0222
+	}
+	if mtime, ok := ft.ModTime(s.cached); ok {
+		a.Mtime = mtime
+	}
+	return a
+}
+
 // Lookup performs a lookup under this node.
 func (s *Root) Lookup(ctx context.Context, name string) (fs.Node, error) {
 	log.Debugf("Root Lookup: '%s'", name)
@@ -62,7 +83,7 @@ func (s *Root) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		return nil, fuse.ENOENT
 	}
 
-	return &Node{Ipfs: s.Ipfs, Nd: nd}, nil
+	return &Node{Ipfs: s.Ipfs, Nd: nd, readAhead: s.readAhead}, nil
 }
 
 // ReadDirAll reads a particular directory. Disallowed for root.
@@ -73,15 +94,16 @@ func (*Root) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 
 // Node is the core object representing a filesystem tree node.
 type Node struct {
-	Ipfs   *core.IpfsNode
-	Nd     *mdag.Node
-	fd     *uio.DagReader
-	cached *ftpb.Data
+	Ipfs      *core.IpfsNode
+	Nd        *mdag.Node
+	cached    *ftpb.Data
+	readAhead int64
 }
 
 func (s *Node) loadData() error {
-	s.cached = new(ftpb.Data)
-	return proto.Unmarshal(s.Nd.Data, s.cached)
+	var err error
+	s.cached, err = ft.FromNode(s.Nd)
+	return err
 }
 
 // Attr returns the attributes of a given node.
@@ -91,20 +113,26 @@ func (s *Node) Attr() fuse.Attr {
 		s.loadData()
 	}
 	switch s.cached.GetType() {
-	case ftpb.Data_Directory:
-		return fuse.Attr{
+	case ftpb.Data_Directory, ftpb.Data_HAMTShard:
+		return s.recordedAttr(fuse.Attr{
 			Mode: os.ModeDir | 0555,
 			Uid:  uint32(os.Getuid()),
 			Gid:  uint32(os.Getgid()),
-		}
-	case ftpb.Data_File:
+		})
+	case ftpb.Data_File, ftpb.Data_Metadata:
 		size := s.cached.GetFilesize()
-		return fuse.Attr{
+		return s.recordedAttr(fuse.Attr{
 			Mode:   0444,
 			Size:   uint64(size),
 			Blocks: uint64(len(s.Nd.Links)),
 			Uid:    uint32(os.Getuid()),
 			Gid:    uint32(os.Getgid()),
+		})
+	case ftpb.Data_Symlink:
+		return fuse.Attr{
+			Mode: os.ModeSymlink | 0555,
+			Uid:  uint32(os.Getuid()),
+			Gid:  uint32(os.Getgid()),
 		}
 	case ftpb.Data_Raw:
 		return fuse.Attr{
@@ -130,14 +158,18 @@ func (s *Node) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		return nil, fuse.ENOENT
 	}
 
-	return &Node{Ipfs: s.Ipfs, Nd: nodes[len(nodes)-1]}, nil
+	return &Node{Ipfs: s.Ipfs, Nd: nodes[len(nodes)-1], readAhead: s.readAhead}, nil
 }
 
 // ReadDirAll reads the link structure as directory entries
 func (s *Node) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 	log.Debug("Node ReadDir")
-	entries := make([]fuse.Dirent, len(s.Nd.Links))
-	for i, link := range s.Nd.Links {
+	links, err := uio.DirectoryLinks(s.Ipfs.DAG, s.Nd)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fuse.Dirent, len(links))
+	for i, link := range links {
 		n := link.Name
 		if len(n) == 0 {
 			n = link.Hash.B58String()
@@ -151,9 +183,53 @@ func (s *Node) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 	return nil, fuse.ENOENT
 }
 
-func (s *Node) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+// Readlink returns the target of a symlink node.
+func (s *Node) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string, error) {
+	if s.cached == nil {
+		if err := s.loadData(); err != nil {
+			return "", err
+		}
+	}
+	if s.cached.GetType() != ftpb.Data_Symlink {
+		return "", fuse.Errno(syscall.EINVAL)
+	}
+	return string(s.cached.GetData()), nil
+}
+
+// Open opens a file for reading. Directories are their own handle.
+func (s *Node) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	if req.Dir {
+		return s, nil
+	}
+
+	// the reader outlives this request, until the handle is released
+	r, err := uio.NewDagReader(context.Background(), s.Nd, s.Ipfs.DAG)
+	switch err {
+	case nil:
+	case uio.ErrIsDir:
+		return s, nil
+	default:
+		return nil, err
+	}
+	if s.readAhead != 0 {
+		r.SetReadAhead(s.readAhead)
+	}
+	return &fileHandle{node: s, r: r}, nil
+}
+
+// fileHandle is an open file. Its reader is kept across reads, so that
+// the blocks fetched ahead of one read serve the next ones.
+type fileHandle struct {
+	node *Node
 
-	k, err := s.Nd.Key()
+	mu     sync.Mutex
+	r      *uio.DagReader
+	offset int64
+}
+
+func (h *fileHandle) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+
+	k, err := h.node.Nd.Key()
 	if err != nil {
 		return err
 	}
@@ -166,18 +242,23 @@ func (s *Node) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	lm["req_size"] = req.Size
 	defer log.EventBegin(ctx, "fuseRead", lm).Done()
 
-	r, err := uio.NewDagReader(ctx, s.Nd, s.Ipfs.DAG)
-	if err != nil {
-		return err
-	}
-	o, err := r.Seek(req.Offset, os.SEEK_SET)
-	lm["res_offset"] = o
-	if err != nil {
-		return err
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// seeking drops what was fetched ahead, so only seek off sequential
+	// reads
+	if req.Offset != h.offset {
+		o, err := h.r.Seek(req.Offset, os.SEEK_SET)
+		lm["res_offset"] = o
+		if err != nil {
+			return err
+		}
+		h.offset = o
 	}
 
-	buf := resp.Data[:min(req.Size, int(r.Size()-req.Offset))]
-	n, err := io.ReadFull(r, buf)
+	buf := resp.Data[:min(req.Size, int(h.r.Size()-req.Offset))]
+	n, err := io.ReadFull(h.r, buf)
+	h.offset += int64(n)
 	if err != nil && err != io.EOF {
 		return err
 	}
@@ -186,6 +267,13 @@ func (s *Node) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	return nil // may be non-nil / not succeeded
 }
 
+// Release closes the reader of the handle.
+func (h *fileHandle) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.r.Close()
+}
+
 // to check that out Node implements all the interfaces we want
 type roRoot interface {
 	fs.Node
@@ -197,13 +285,21 @@ var _ roRoot = (*Root)(nil)
 
 type roNode interface {
 	fs.HandleReadDirAller
-	fs.HandleReader
 	fs.Node
+	fs.NodeOpener
 	fs.NodeStringLookuper
+	fs.NodeReadlinker
 }
 
 var _ roNode = (*Node)(nil)
 
+type roHandle interface {
+	fs.HandleReader
+	fs.HandleReleaser
+}
+
+var _ roHandle = (*fileHandle)(nil)
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -441,3 +441,121 @@ func TestSeekingConsistency(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func buildRawTestDag(r io.Reader, ds merkledag.DAGService, spl chunk.BlockSplitter) (*merkledag.Node, error) {
+	dbp := h.DagBuilderParams{
+		Dagserv:   ds,
+		Maxlinks:  h.DefaultLinksPerBlock,
+		RawLeaves: true,
+	}
+
+	return BalancedLayout(dbp.New(spl.Split(r)))
+}
+
+// checkRawLeaves checks that all the leaves under nd are raw, and that
+// all other nodes are not
+func checkRawLeaves(t *testing.T, nd *merkledag.Node, ds merkledag.DAGService) {
+	for _, lnk := range nd.Links {
+		child, err := lnk.GetNode(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(child.Links) == 0 {
+			if child.Format != merkledag.FormatRaw {
+				t.Fatal("expected leaf to be raw")
+			}
+			continue
+		}
+
+		if child.Format == merkledag.FormatRaw {
+			t.Fatal("expected branch not to be raw")
+		}
+		checkRawLeaves(t, child, ds)
+	}
+}
+
+func TestRawLeaves(t *testing.T) {
+	nbytes := 1024 * 1024
+	should := make([]byte, nbytes)
+	u.NewTimeSeededRand().Read(should)
+
+	ds := mdtest.Mock(t)
+	nd, err := buildRawTestDag(bytes.NewReader(should), ds, &chunk.SizeSplitter{Size: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nd.Format == merkledag.FormatRaw {
+		t.Fatal("root should not be raw")
+	}
+
+	// fetch the root again, so the formats of its links are read back
+	k, err := nd.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err = ds.Get(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRawLeaves(t, nd, ds)
+
+	rs, err := uio.NewDagReader(context.Background(), nd, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := arrComp(out, should); err != nil {
+		t.Fatal(err)
+	}
+
+	seeked, err := rs.Seek(int64(nbytes/3), os.SEEK_SET)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = ioutil.ReadAll(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := arrComp(out, should[seeked:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRawLeavesSingleBlockFile(t *testing.T) {
+	should := make([]byte, 100)
+	u.NewTimeSeededRand().Read(should)
+
+	ds := mdtest.Mock(t)
+	nd, err := buildRawTestDag(bytes.NewReader(should), ds, &chunk.SizeSplitter{Size: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nd.Format == merkledag.FormatRaw {
+		t.Fatal("single block file should not be raw")
+	}
+
+	rs, err := uio.NewDagReader(context.Background(), nd, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := arrComp(out, should); err != nil {
+		t.Fatal(err)
+	}
+}
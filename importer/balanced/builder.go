@@ -12,6 +12,9 @@ func BalancedLayout(db *h.DagBuilderHelper) (*dag.Node, error) {
 	for level := 0; !db.Done(); level++ {
 
 		nroot := h.NewUnixfsNode()
+		if level == 0 {
+			nroot = db.NewLeaf()
+		}
 
 		// add our old root as a child of the new root.
 		if root != nil { // nil if it's the first node.
@@ -31,6 +34,10 @@ func BalancedLayout(db *h.DagBuilderHelper) (*dag.Node, error) {
 		root = h.NewUnixfsNode()
 	}
 
+	// a file of a single block is never raw, so that its root can
+	// always be read as a unixfs node
+	root.SetRaw(false)
+
 	return db.Add(root)
 }
 
@@ -52,6 +59,9 @@ func fillNodeRec(db *h.DagBuilderHelper, node *h.UnixfsNode, depth int) error {
 	// while we have room AND we're not done
 	for node.NumChildren() < db.Maxlinks() && !db.Done() {
 		child := h.NewUnixfsNode()
+		if depth == 1 {
+			child = db.NewLeaf()
+		}
 
 		if err := fillNodeRec(db, child, depth-1); err != nil {
 			return err
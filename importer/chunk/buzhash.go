@@ -0,0 +1,108 @@
+package chunk
+
+import (
+	"io"
+)
+
+const (
+	buzhashWindow  = 32
+	buzhashDefMin  = 128 * 1024
+	buzhashDefMax  = 512 * 1024
+	buzhashDefMask = 1<<17 - 1
+)
+
+// buzTable maps every byte to a random 32 bit value. It is generated from a
+// fixed seed: changing it would change how every file is split.
+var buzTable [256]uint32
+
+func init() {
+	x := uint32(2463534242)
+	for i := range buzTable {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		buzTable[i] = x
+	}
+}
+
+func rotl(x uint32, n uint) uint32 {
+	return x<<n | x>>(32-n)
+}
+
+// Buzhash is a content defined splitter, cutting blocks where a cyclic
+// polynomial (buzhash) rolling hash of the last 32 bytes has its low bits
+// unset. It is faster than MaybeRabin, and cuts blocks of 256KiB on
+// average by default.
+type Buzhash struct {
+	MinBlockSize int
+	MaxBlockSize int
+	mask         uint32
+}
+
+func NewBuzhash() *Buzhash {
+	return &Buzhash{
+		MinBlockSize: buzhashDefMin,
+		MaxBlockSize: buzhashDefMax,
+		mask:         buzhashDefMask,
+	}
+}
+
+func (bh *Buzhash) Split(r io.Reader) chan []byte {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+
+		buf := make([]byte, bh.MaxBlockSize)
+		n := 0
+		eof := false
+		for {
+			if !eof {
+				nread, err := io.ReadFull(r, buf[n:])
+				n += nread
+				switch err {
+				case nil:
+				case io.EOF, io.ErrUnexpectedEOF:
+					eof = true
+				default:
+					log.Debugf("Block split error: %s", err)
+					return
+				}
+			}
+			if n == 0 {
+				return
+			}
+
+			cut := n
+			if n > bh.MinBlockSize {
+				cut = bh.cutpoint(buf[:n])
+			}
+
+			chunk := make([]byte, cut)
+			copy(chunk, buf[:cut])
+			out <- chunk
+
+			n = copy(buf, buf[cut:n])
+		}
+	}()
+	return out
+}
+
+// cutpoint returns where the first block of buf ends, or len(buf) if
+// there is no boundary in it.
+func (bh *Buzhash) cutpoint(buf []byte) int {
+	// hash the window preceding the earliest allowed cut
+	var state uint32
+	for _, b := range buf[bh.MinBlockSize-buzhashWindow : bh.MinBlockSize] {
+		state = rotl(state, 1) ^ buzTable[b]
+	}
+
+	for i := bh.MinBlockSize; i < len(buf); i++ {
+		if state&bh.mask == 0 {
+			return i
+		}
+		// the byte leaving the window was rotated by the window size,
+		// 32, which leaves its table value as it is
+		state = rotl(state, 1) ^ buzTable[buf[i-buzhashWindow]] ^ buzTable[buf[i]]
+	}
+	return len(buf)
+}
@@ -0,0 +1,76 @@
+package chunk
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func splitAll(spl BlockSplitter, data []byte) [][]byte {
+	var chunks [][]byte
+	for c := range spl.Split(bytes.NewReader(data)) {
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+func TestBuzhashChunks(t *testing.T) {
+	data := make([]byte, 10<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	bh := NewBuzhash()
+	chunks := splitAll(bh, data)
+	whole := bytes.Join(chunks, nil)
+	if !bytes.Equal(whole, data) {
+		t.Fatal("chunks do not make up the input")
+	}
+	for i, c := range chunks {
+		if len(c) > bh.MaxBlockSize {
+			t.Fatalf("chunk %d is %d bytes, more than the maximum", i, len(c))
+		}
+		if len(c) < bh.MinBlockSize && i != len(chunks)-1 {
+			t.Fatalf("chunk %d is %d bytes, less than the minimum", i, len(c))
+		}
+	}
+	avg := len(data) / len(chunks)
+	if avg < 192<<10 || avg > 320<<10 {
+		t.Fatalf("average chunk size is %d", avg)
+	}
+}
+
+func TestBuzhashIsContentDefined(t *testing.T) {
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	a := splitAll(NewBuzhash(), data)
+	// insert a few bytes at the start: only the first chunk should change
+	b := splitAll(NewBuzhash(), append([]byte("prefix"), data...))
+
+	if len(a) != len(b) {
+		t.Fatalf("got %d chunks, then %d", len(a), len(b))
+	}
+	for i := 1; i < len(a); i++ {
+		if !bytes.Equal(a[i], b[i]) {
+			t.Fatalf("chunk %d changed", i)
+		}
+	}
+}
+
+func TestShortInputs(t *testing.T) {
+	spls := []BlockSplitter{NewBuzhash(), NewMaybeRabin(1024), DefaultSplitter}
+	for _, size := range []int{0, 1, 15, 16, 17, 1000} {
+		data := make([]byte, size)
+		rand.New(rand.NewSource(3)).Read(data)
+		for _, spl := range spls {
+			chunks := splitAll(spl, data)
+			if !bytes.Equal(bytes.Join(chunks, nil), data) {
+				t.Fatalf("%T: %d byte input split wrong", spl, size)
+			}
+			for _, c := range chunks {
+				if len(c) == 0 {
+					t.Fatalf("%T: empty chunk out of %d bytes", spl, size)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package chunk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxBlockSize is the largest block a splitter may be asked to cut.
+// Larger blocks would not be accepted by the network.
+const MaxBlockSize = 1024 * 1024
+
+// minRabinBlockSize is the smallest block rabin is allowed to cut, which
+// is the size of its rolling window.
+const minRabinBlockSize = 16
+
+var (
+	ErrBadChunker    = errors.New("unrecognized chunker option")
+	ErrSizeTooLarge  = fmt.Errorf("chunks cannot be larger than %d bytes", MaxBlockSize)
+	ErrSizeTooSmall  = errors.New("chunk size must be greater than 0")
+	ErrRabinBadOrder = errors.New("rabin sizes must be ordered min < avg < max")
+	ErrRabinTooSmall = fmt.Errorf("rabin chunks cannot be smaller than %d bytes", minRabinBlockSize)
+)
+
+// FromString returns the splitter described by chunker, which is one of:
+//
+//	default                - the default splitter
+//	size-<bytes>           - fixed size blocks
+//	rabin                  - rabin fingerprinting, of DefaultBlockSize average
+//	rabin-<avg>            - rabin fingerprinting, of the given average
+//	rabin-<min>-<avg>-<max>
+//	buzhash                - buzhash rolling hash
+//
+// The empty string also gives the default splitter.
+func FromString(chunker string) (BlockSplitter, error) {
+	switch {
+	case chunker == "" || chunker == "default":
+		return DefaultSplitter, nil
+
+	case strings.HasPrefix(chunker, "size-"):
+		size, err := strconv.Atoi(chunker[len("size-"):])
+		if err != nil {
+			return nil, ErrBadChunker
+		}
+		if err := checkSize(size); err != nil {
+			return nil, err
+		}
+		return &SizeSplitter{Size: size}, nil
+
+	case chunker == "rabin" || strings.HasPrefix(chunker, "rabin-"):
+		return parseRabin(chunker)
+
+	case chunker == "buzhash":
+		return NewBuzhash(), nil
+
+	default:
+		return nil, ErrBadChunker
+	}
+}
+
+func parseRabin(chunker string) (BlockSplitter, error) {
+	parts := strings.Split(chunker, "-")[1:]
+	sizes := make([]int, len(parts))
+	for i, p := range parts {
+		size, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, ErrBadChunker
+		}
+		sizes[i] = size
+	}
+
+	var min, avg, max int
+	switch len(sizes) {
+	case 0:
+		avg = DefaultBlockSize
+		min, max = avg/2, (avg/2)*3
+	case 1:
+		avg = sizes[0]
+		min, max = avg/2, (avg/2)*3
+	case 3:
+		min, avg, max = sizes[0], sizes[1], sizes[2]
+	default:
+		return nil, ErrBadChunker
+	}
+
+	if err := checkSize(max); err != nil {
+		return nil, err
+	}
+	if min < minRabinBlockSize {
+		return nil, ErrRabinTooSmall
+	}
+	if !(min < avg && avg < max) {
+		return nil, ErrRabinBadOrder
+	}
+	return NewRabinMinMax(min, avg, max), nil
+}
+
+func checkSize(size int) error {
+	if size <= 0 {
+		return ErrSizeTooSmall
+	}
+	if size > MaxBlockSize {
+		return ErrSizeTooLarge
+	}
+	return nil
+}
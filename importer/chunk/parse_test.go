@@ -0,0 +1,47 @@
+package chunk
+
+import (
+	"testing"
+)
+
+func TestParseChunker(t *testing.T) {
+	good := []string{"", "default", "size-1", "size-1048576", "rabin", "rabin-4096", "rabin-16-32-64", "rabin-131072-262144-524288", "buzhash"}
+	for _, s := range good {
+		if _, err := FromString(s); err != nil {
+			t.Errorf("%q: %s", s, err)
+		}
+	}
+
+	bad := map[string]error{
+		"fixed":               ErrBadChunker,
+		"size-":               ErrBadChunker,
+		"size-abc":            ErrBadChunker,
+		"size-0":              ErrSizeTooSmall,
+		"size--5":             ErrSizeTooSmall,
+		"size-1048577":        ErrSizeTooLarge,
+		"rabin-1-2":           ErrBadChunker,
+		"rabin-8-32-64":       ErrRabinTooSmall,
+		"rabin-30":            ErrRabinTooSmall,
+		"rabin-64-32-128":     ErrRabinBadOrder,
+		"rabin-32-64-64":      ErrRabinBadOrder,
+		"rabin-32-64-2e6":     ErrBadChunker,
+		"rabin-32-64-2000000": ErrSizeTooLarge,
+		"buzhash-5":           ErrBadChunker,
+	}
+	for s, expected := range bad {
+		if _, err := FromString(s); err != expected {
+			t.Errorf("%q: expected %v, got %v", s, expected, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	spl, err := FromString("size-1024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, ok := spl.(*SizeSplitter)
+	if !ok || ss.Size != 1024 {
+		t.Fatalf("expected a 1024 bytes size splitter, got %#v", spl)
+	}
+}
@@ -3,7 +3,6 @@ package chunk
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
 	"math"
 )
@@ -25,9 +24,21 @@ func NewMaybeRabin(avgBlkSize int) *MaybeRabin {
 	return rb
 }
 
+// NewRabinMinMax returns a rabin splitter cutting blocks of avgBlkSize
+// bytes on average, never smaller than minBlkSize nor larger than
+// maxBlkSize (but for the last one).
+func NewRabinMinMax(minBlkSize, avgBlkSize, maxBlkSize int) *MaybeRabin {
+	rb := NewMaybeRabin(avgBlkSize)
+	rb.MinBlockSize = minBlkSize
+	rb.MaxBlockSize = maxBlkSize
+	return rb
+}
+
 func (mr *MaybeRabin) Split(r io.Reader) chan []byte {
 	out := make(chan []byte, 16)
 	go func() {
+		defer close(out)
+
 		inbuf := bufio.NewReader(r)
 		blkbuf := new(bytes.Buffer)
 
@@ -57,7 +68,10 @@ func (mr *MaybeRabin) Split(r io.Reader) chan []byte {
 		for ; i < mr.windowSize; i++ {
 			b, err := inbuf.ReadByte()
 			if err != nil {
-				fmt.Println(err)
+				// input shorter than the window
+				if blkbuf.Len() > 0 {
+					out <- blkbuf.Bytes()
+				}
 				return
 			}
 			blkbuf.WriteByte(b)
@@ -87,8 +101,9 @@ func (mr *MaybeRabin) Split(r io.Reader) chan []byte {
 			}
 		}
 		io.Copy(blkbuf, inbuf)
-		out <- blkbuf.Bytes()
-		close(out)
+		if blkbuf.Len() > 0 {
+			out <- blkbuf.Bytes()
+		}
 	}()
 	return out
 }
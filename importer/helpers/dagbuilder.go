@@ -1,6 +1,10 @@
 package helpers
 
 import (
+	"os"
+	"runtime"
+	"time"
+
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	"github.com/ipfs/go-ipfs/pin"
 )
@@ -8,11 +12,18 @@ import (
 // DagBuilderHelper wraps together a bunch of objects needed to
 // efficiently create unixfs dag trees
 type DagBuilderHelper struct {
-	dserv    dag.DAGService
-	mp       pin.ManualPinner
-	in       <-chan []byte
-	nextData []byte // the next item to return.
-	maxlinks int
+	dserv     dag.DAGService
+	mp        pin.ManualPinner
+	in        <-chan []byte
+	nextData  []byte // the next item to return.
+	maxlinks  int
+	rawLeaves bool
+	hashFunc  int
+	mode      os.FileMode
+	modTime   time.Time
+
+	// encoders limits the number of leaves encoded at once
+	encoders chan struct{}
 }
 
 type DagBuilderParams struct {
@@ -24,16 +35,48 @@ type DagBuilderParams struct {
 
 	// Pinner to use for pinning files (optionally nil)
 	Pinner pin.ManualPinner
+
+	// RawLeaves stores file data in raw blocks, rather than
+	// wrapping it in unixfs nodes
+	RawLeaves bool
+
+	// HashFunc is the multihash code of the hash function nodes are
+	// hashed with (optionally 0, for the default)
+	HashFunc int
+
+	// Mode and ModTime are recorded in the root node of the file, unless
+	// they are zero
+	Mode    os.FileMode
+	ModTime time.Time
+
+	// Workers is the number of leaves encoded and hashed at once. The
+	// zero value uses one per CPU, and 1 encodes them one after the
+	// other. The dag built is the same either way.
+	Workers int
 }
 
 // Generate a new DagBuilderHelper from the given params, using 'in' as a
 // data source
 func (dbp *DagBuilderParams) New(in <-chan []byte) *DagBuilderHelper {
+	workers := dbp.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	var encoders chan struct{}
+	if workers > 1 {
+		encoders = make(chan struct{}, workers)
+	}
+
 	return &DagBuilderHelper{
-		dserv:    dbp.Dagserv,
-		mp:       dbp.Pinner,
-		in:       in,
-		maxlinks: dbp.Maxlinks,
+		dserv:     dbp.Dagserv,
+		mp:        dbp.Pinner,
+		in:        in,
+		maxlinks:  dbp.Maxlinks,
+		rawLeaves: dbp.RawLeaves,
+		hashFunc:  dbp.HashFunc,
+		mode:      dbp.Mode,
+		modTime:   dbp.ModTime,
+		encoders:  encoders,
 	}
 }
 
@@ -78,6 +121,14 @@ func (db *DagBuilderHelper) Next() []byte {
 	return d
 }
 
+// NewLeaf creates a new file node to hold a single block of data. If the
+// helper stores raw leaves, the node is written as a raw block.
+func (db *DagBuilderHelper) NewLeaf() *UnixfsNode {
+	n := NewUnixfsNode()
+	n.raw = db.rawLeaves
+	return n
+}
+
 // GetDagServ returns the dagservice object this Helper is using
 func (db *DagBuilderHelper) GetDagServ() dag.DAGService {
 	return db.dserv
@@ -92,6 +143,7 @@ func (db *DagBuilderHelper) FillNodeLayer(node *UnixfsNode) error {
 	// while we have room AND we're not done
 	for node.NumChildren() < db.maxlinks && !db.Done() {
 		child := NewUnixfsBlock()
+		child.raw = db.rawLeaves
 
 		if err := db.FillNodeWithData(child); err != nil {
 			return err
@@ -119,11 +171,33 @@ func (db *DagBuilderHelper) FillNodeWithData(node *UnixfsNode) error {
 	return nil
 }
 
+// encode encodes and hashes nd on its own goroutine, once one of the
+// encoders is free. The result is sent on the returned channel.
+func (db *DagBuilderHelper) encode(nd *dag.Node) <-chan error {
+	done := make(chan error, 1)
+	db.encoders <- struct{}{}
+	go func() {
+		_, err := nd.Encoded(false)
+		<-db.encoders
+		done <- err
+	}()
+	return done
+}
+
+// Add writes node, the root of a file, to the DAGService and pins it.
 func (db *DagBuilderHelper) Add(node *UnixfsNode) (*dag.Node, error) {
+	if db.mode != 0 {
+		node.ufmt.SetMode(db.mode)
+	}
+	if !db.modTime.IsZero() {
+		node.ufmt.SetModTime(db.modTime)
+	}
+
 	dn, err := node.GetDagNode()
 	if err != nil {
 		return nil, err
 	}
+	dn.HashFunc = db.hashFunc
 
 	key, err := db.dserv.Add(dn)
 	if err != nil {
@@ -35,11 +35,28 @@ var DefaultLinksPerBlock = (roughLinkBlockSize / roughLinkSize)
 // ErrSizeLimitExceeded signals that a block is larger than BlockSizeLimit.
 var ErrSizeLimitExceeded = fmt.Errorf("object size limit exceeded")
 
+// ErrRawWithChildren signals an attempt to store a node with children as
+// a raw block.
+var ErrRawWithChildren = fmt.Errorf("raw nodes cannot have children")
+
 // UnixfsNode is a struct created to aid in the generation
 // of unixfs DAG trees
 type UnixfsNode struct {
 	node *dag.Node
 	ufmt *ft.FSNode
+
+	// raw nodes are stored as a raw block of their data
+	raw bool
+
+	// children still being encoded, in order, to be linked once done
+	pending []pendingChild
+}
+
+// pendingChild is a leaf being encoded by the encoders of db
+type pendingChild struct {
+	node *dag.Node
+	done <-chan error
+	db   *DagBuilderHelper
 }
 
 // NewUnixfsNode creates a new Unixfs node to represent a file
@@ -60,6 +77,14 @@ func NewUnixfsBlock() *UnixfsNode {
 
 // NewUnixfsNodeFromDag reconstructs a Unixfs node from a given dag node
 func NewUnixfsNodeFromDag(nd *dag.Node) (*UnixfsNode, error) {
+	if nd.Format == dag.FormatRaw {
+		return &UnixfsNode{
+			node: nd,
+			ufmt: &ft.FSNode{Type: ft.TRaw, Data: nd.Data},
+			raw:  true,
+		}, nil
+	}
+
 	mb, err := ft.FSNodeFromBytes(nd.Data)
 	if err != nil {
 		return nil, err
@@ -76,6 +101,10 @@ func (n *UnixfsNode) NumChildren() int {
 }
 
 func (n *UnixfsNode) GetChild(i int, ds dag.DAGService) (*UnixfsNode, error) {
+	if err := n.flush(); err != nil {
+		return nil, err
+	}
+
 	nd, err := n.node.Links[i].GetNode(ds)
 	if err != nil {
 		return nil, err
@@ -87,6 +116,10 @@ func (n *UnixfsNode) GetChild(i int, ds dag.DAGService) (*UnixfsNode, error) {
 // addChild will add the given UnixfsNode as a child of the receiver.
 // the passed in DagBuilderHelper is used to store the child node an
 // pin it locally so it doesnt get lost
+//
+// Leaves are encoded and hashed concurrently, if db has several
+// workers: they are linked, stored and pinned, in order, when the
+// receiver itself is needed.
 func (n *UnixfsNode) AddChild(child *UnixfsNode, db *DagBuilderHelper) error {
 	n.ufmt.AddBlockSize(child.ufmt.FileSize())
 
@@ -94,10 +127,29 @@ func (n *UnixfsNode) AddChild(child *UnixfsNode, db *DagBuilderHelper) error {
 	if err != nil {
 		return err
 	}
+	childnode.HashFunc = db.hashFunc
+
+	if db.encoders != nil && child.NumChildren() == 0 {
+		n.pending = append(n.pending, pendingChild{
+			node: childnode,
+			done: db.encode(childnode),
+			db:   db,
+		})
+		return nil
+	}
 
+	// keep the children in order
+	if err := n.flush(); err != nil {
+		return err
+	}
+	return n.linkChild(childnode, db)
+}
+
+// linkChild links, stores and pins the encoded childnode
+func (n *UnixfsNode) linkChild(childnode *dag.Node, db *DagBuilderHelper) error {
 	// Add a link to this node without storing a reference to the memory
 	// This way, we avoid nodes building up and consuming all of our RAM
-	err = n.node.AddNodeLinkClean("", childnode)
+	err := n.node.AddNodeLinkClean("", childnode)
 	if err != nil {
 		return err
 	}
@@ -115,7 +167,34 @@ func (n *UnixfsNode) AddChild(child *UnixfsNode, db *DagBuilderHelper) error {
 	return nil
 }
 
-// Removes the child node at the given index
+// flush waits for the children being encoded, and links them
+func (n *UnixfsNode) flush() error {
+	for len(n.pending) > 0 {
+		pc := n.pending[0]
+		n.pending = n.pending[1:]
+		if err := <-pc.done; err != nil {
+			n.drain()
+			return err
+		}
+		if err := n.linkChild(pc.node, pc.db); err != nil {
+			n.drain()
+			return err
+		}
+	}
+	n.pending = nil
+	return nil
+}
+
+// drain waits for the children being encoded, dropping them
+func (n *UnixfsNode) drain() {
+	for _, pc := range n.pending {
+		<-pc.done
+	}
+	n.pending = nil
+}
+
+// Removes the child node at the given index. Children are only linked
+// once the node has been flushed, by GetChild or GetDagNode.
 func (n *UnixfsNode) RemoveChild(index int, dbh *DagBuilderHelper) {
 	k := u.Key(n.node.Links[index].Hash)
 	if dbh.mp != nil {
@@ -129,9 +208,27 @@ func (n *UnixfsNode) SetData(data []byte) {
 	n.ufmt.Data = data
 }
 
+// SetRaw sets whether the node is stored as a raw block. Only nodes
+// without children may be raw.
+func (n *UnixfsNode) SetRaw(raw bool) {
+	n.raw = raw
+}
+
 // getDagNode fills out the proper formatting for the unixfs node
 // inside of a DAG node and returns the dag node
 func (n *UnixfsNode) GetDagNode() (*dag.Node, error) {
+	if err := n.flush(); err != nil {
+		return nil, err
+	}
+
+	if n.raw {
+		if n.NumChildren() > 0 {
+			return nil, ErrRawWithChildren
+		}
+		n.node = dag.NewRawNode(n.ufmt.Data)
+		return n.node, nil
+	}
+
 	data, err := n.ufmt.GetBytes()
 	if err != nil {
 		return nil, err
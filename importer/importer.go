@@ -40,15 +40,25 @@ func BuildDagFromFile(fpath string, ds dag.DAGService, mp pin.ManualPinner) (*da
 }
 
 func BuildDagFromReader(r io.Reader, ds dag.DAGService, mp pin.ManualPinner, spl chunk.BlockSplitter) (*dag.Node, error) {
-	// Start the splitter
-	blkch := spl.Split(r)
-
 	dbp := h.DagBuilderParams{
 		Dagserv:  ds,
 		Maxlinks: h.DefaultLinksPerBlock,
 		Pinner:   mp,
 	}
 
+	return BuildDagFromReaderParams(r, spl, dbp)
+}
+
+// BuildDagFromReaderParams builds a balanced DAG from the given reader,
+// as configured by dbp. Maxlinks defaults to h.DefaultLinksPerBlock.
+func BuildDagFromReaderParams(r io.Reader, spl chunk.BlockSplitter, dbp h.DagBuilderParams) (*dag.Node, error) {
+	if dbp.Maxlinks == 0 {
+		dbp.Maxlinks = h.DefaultLinksPerBlock
+	}
+
+	// Start the splitter
+	blkch := spl.Split(r)
+
 	return bal.BalancedLayout(dbp.New(blkch))
 }
 
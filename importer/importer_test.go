@@ -4,10 +4,20 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"runtime"
 	"testing"
 
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	dssync "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/sync"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
+	offline "github.com/ipfs/go-ipfs/exchange/offline"
+	bal "github.com/ipfs/go-ipfs/importer/balanced"
 	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	h "github.com/ipfs/go-ipfs/importer/helpers"
+	trickle "github.com/ipfs/go-ipfs/importer/trickle"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
@@ -60,6 +70,96 @@ func TestBalancedDag(t *testing.T) {
 	}
 }
 
+type layout func(*h.DagBuilderHelper) (*dag.Node, error)
+
+func buildWithWorkers(t testing.TB, lay layout, data []byte, dbp h.DagBuilderParams) (*dag.Node, dag.DAGService) {
+	dserv := mdtest.Mock(t)
+	dbp.Dagserv = dserv
+	dbp.Maxlinks = h.DefaultLinksPerBlock
+	spl := &chunk.SizeSplitter{Size: 1024}
+	nd, err := lay(dbp.New(spl.Split(bytes.NewReader(data))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nd, dserv
+}
+
+func TestParallelImportIsDeterministic(t *testing.T) {
+	data := make([]byte, 3000*1024+17)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	layouts := map[string]layout{"balanced": bal.BalancedLayout, "trickle": trickle.TrickleLayout}
+	for name, lay := range layouts {
+		for _, raw := range []bool{false, true} {
+			seq, _ := buildWithWorkers(t, lay, data, h.DagBuilderParams{Workers: 1, RawLeaves: raw})
+			seqk, err := seq.Key()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, workers := range []int{2, 8} {
+				par, dserv := buildWithWorkers(t, lay, data, h.DagBuilderParams{Workers: workers, RawLeaves: raw})
+				park, err := par.Key()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if park != seqk {
+					t.Fatalf("%s (raw %v): %d workers built %s, not %s", name, raw, workers, park, seqk)
+				}
+
+				dr, err := uio.NewDagReader(context.Background(), par, dserv)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out, err := ioutil.ReadAll(dr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(out, data) {
+					t.Fatalf("%s (raw %v): %d workers stored the wrong data", name, raw, workers)
+				}
+			}
+		}
+	}
+}
+
+// runImportBench imports size bytes of random data, storing the blocks
+// nowhere, so that large imports measure the importer alone. Pass
+// -benchtime with a small -bench pattern to import several GB.
+func runImportBench(b *testing.B, size int64, workers int) {
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewNullDatastore()))
+	bs, err := bserv.New(bstore, offline.Exchange(bstore))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer bs.Close()
+	dbp := h.DagBuilderParams{
+		Dagserv:  dag.NewDAGService(bs),
+		Maxlinks: h.DefaultLinksPerBlock,
+		Workers:  workers,
+	}
+
+	b.SetBytes(size)
+	for i := 0; i < b.N; i++ {
+		r := io.LimitReader(rand.New(rand.NewSource(int64(i))), size)
+		if _, err := bal.BalancedLayout(dbp.New(chunk.DefaultSplitter.Split(r))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkImport1GBSequential(b *testing.B) {
+	runImportBench(b, 1<<30, 1)
+}
+
+func BenchmarkImport1GBParallel(b *testing.B) {
+	runImportBench(b, 1<<30, runtime.NumCPU())
+}
+
+func BenchmarkImport4GBParallel(b *testing.B) {
+	runImportBench(b, 4<<30, runtime.NumCPU())
+}
+
 func BenchmarkBalancedReadSmallBlock(b *testing.B) {
 	b.StopTimer()
 	nbytes := int64(10000000)
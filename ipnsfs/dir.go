@@ -160,10 +160,33 @@ func (d *Directory) childUnsync(name string) (FSNode, error) {
 	if err == nil {
 		return fi, nil
 	}
+	link, err := d.childLink(name)
+	if err == nil {
+		return link, nil
+	}
 
 	return nil, os.ErrNotExist
 }
 
+// childLink returns a symlink under this directory by the given name if it
+// exists.
+func (d *Directory) childLink(name string) (*Symlink, error) {
+	nd, err := d.childFromDag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := ft.FromBytes(nd.Data)
+	if err != nil {
+		return nil, err
+	}
+	if i.GetType() != ufspb.Data_Symlink {
+		return nil, ErrInvalidChild
+	}
+
+	return &Symlink{name: name, node: nd, Target: string(i.GetData())}, nil
+}
+
 func (d *Directory) List() []string {
 	d.lock.Lock()
 	defer d.lock.Unlock()
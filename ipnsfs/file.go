@@ -3,7 +3,6 @@ package ipnsfs
 import (
 	"sync"
 
-	chunk "github.com/ipfs/go-ipfs/importer/chunk"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	mod "github.com/ipfs/go-ipfs/unixfs/mod"
 
@@ -23,7 +22,7 @@ type File struct {
 
 // NewFile returns a NewFile object with the given parameters
 func NewFile(name string, node *dag.Node, parent childCloser, fs *Filesystem) (*File, error) {
-	dmod, err := mod.NewDagModifier(context.Background(), node, fs.dserv, fs.pins.GetManual(), chunk.DefaultSplitter)
+	dmod, err := mod.NewDagModifier(context.Background(), node, fs.dserv, fs.pins.GetManual(), fs.splitter)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,34 @@
+package ipnsfs
+
+import (
+	"sync"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+)
+
+// Symlink is a symbolic link in the ipns filesystem. Symlinks can be read,
+// but not yet created or changed.
+type Symlink struct {
+	name   string
+	node   *dag.Node
+	Target string
+
+	lock sync.Mutex
+}
+
+// GetNode returns the dag node of the symlink
+func (s *Symlink) GetNode() (*dag.Node, error) {
+	return s.node, nil
+}
+
+func (s *Symlink) Type() NodeType {
+	return TSymlink
+}
+
+func (s *Symlink) Lock() {
+	s.lock.Lock()
+}
+
+func (s *Symlink) Unlock() {
+	s.lock.Unlock()
+}
@@ -17,11 +17,14 @@ import (
 	"sync"
 	"time"
 
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	namesys "github.com/ipfs/go-ipfs/namesys"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	pin "github.com/ipfs/go-ipfs/pin"
 	ft "github.com/ipfs/go-ipfs/unixfs"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	u "github.com/ipfs/go-ipfs/util"
 
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
@@ -40,7 +43,11 @@ type Filesystem struct {
 
 	pins pin.Pinner
 
-	roots map[string]*KeyRoot
+	splitter chunk.BlockSplitter
+
+	// rootsLk guards roots, which change as keys are added and removed
+	rootsLk sync.Mutex
+	roots   map[string]*KeyRoot
 }
 
 // NewFilesystem instantiates an ipns filesystem using the given parameters and locally owned keys
@@ -51,6 +58,8 @@ func NewFilesystem(ctx context.Context, ds dag.DAGService, nsys namesys.NameSyst
 		nsys:  nsys,
 		dserv: ds,
 		pins:  pins,
+
+		splitter: chunk.DefaultSplitter,
 	}
 	for _, k := range keys {
 		pkh, err := k.GetPublic().Hash()
@@ -68,7 +77,15 @@ func NewFilesystem(ctx context.Context, ds dag.DAGService, nsys namesys.NameSyst
 	return fs, nil
 }
 
+// SetSplitter sets how the data written to files is split into blocks
+func (fs *Filesystem) SetSplitter(spl chunk.BlockSplitter) {
+	fs.splitter = spl
+}
+
 func (fs *Filesystem) Close() error {
+	fs.rootsLk.Lock()
+	defer fs.rootsLk.Unlock()
+
 	wg := sync.WaitGroup{}
 	for _, r := range fs.roots {
 		wg.Add(1)
@@ -87,6 +104,8 @@ func (fs *Filesystem) Close() error {
 
 // GetRoot returns the KeyRoot of the given name
 func (fs *Filesystem) GetRoot(name string) (*KeyRoot, error) {
+	fs.rootsLk.Lock()
+	defer fs.rootsLk.Unlock()
 	r, ok := fs.roots[name]
 	if ok {
 		return r, nil
@@ -94,6 +113,44 @@ func (fs *Filesystem) GetRoot(name string) (*KeyRoot, error) {
 	return nil, os.ErrNotExist
 }
 
+// AddRoot creates the KeyRoot of a key the filesystem was not started
+// with, such as one generated while the node is running. The root of a
+// key already in the filesystem is returned as is.
+func (fs *Filesystem) AddRoot(ctx context.Context, k ci.PrivKey) (*KeyRoot, error) {
+	pkh, err := k.GetPublic().Hash()
+	if err != nil {
+		return nil, err
+	}
+	name := u.Key(pkh).Pretty()
+
+	fs.rootsLk.Lock()
+	defer fs.rootsLk.Unlock()
+	if r, ok := fs.roots[name]; ok {
+		return r, nil
+	}
+
+	root, err := fs.newKeyRoot(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	fs.roots[name] = root
+	return root, nil
+}
+
+// RemoveRoot stops republishing the KeyRoot of the given name, and leaves
+// it out of the filesystem.
+func (fs *Filesystem) RemoveRoot(name string) error {
+	fs.rootsLk.Lock()
+	defer fs.rootsLk.Unlock()
+	r, ok := fs.roots[name]
+	if !ok {
+		return os.ErrNotExist
+	}
+	r.stop()
+	delete(fs.roots, name)
+	return nil
+}
+
 type childCloser interface {
 	closeChild(string, *dag.Node) error
 }
@@ -103,6 +160,7 @@ type NodeType int
 const (
 	TFile NodeType = iota
 	TDir
+	TSymlink
 )
 
 // FSNode represents any node (directory, root, or file) in the ipns filesystem
@@ -127,6 +185,9 @@ type KeyRoot struct {
 	val FSNode
 
 	repub *Republisher
+
+	// stop ends the republisher routine
+	stop context.CancelFunc
 }
 
 // newKeyRoot creates a new KeyRoot for the given key, and starts up a republisher routine
@@ -159,7 +220,12 @@ func (fs *Filesystem) newKeyRoot(parent context.Context, k ci.PrivKey) (*KeyRoot
 		}
 	}
 
-	mnode, err := fs.dserv.Get(pointsTo)
+	resolver := &path.Resolver{DAG: fs.dserv, ResolveOnce: uio.ResolveUnixfsOnce}
+	mnode, err := resolver.ResolvePath(pointsTo)
+	if err != nil {
+		return nil, err
+	}
+	mkey, err := mnode.Key()
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +233,9 @@ func (fs *Filesystem) newKeyRoot(parent context.Context, k ci.PrivKey) (*KeyRoot
 	root.node = mnode
 
 	root.repub = NewRepublisher(root, time.Millisecond*300, time.Second*3)
-	go root.repub.Run(parent)
+	repubCtx, stop := context.WithCancel(parent)
+	root.stop = stop
+	go root.repub.Run(repubCtx)
 
 	pbn, err := ft.FromBytes(mnode.Data)
 	if err != nil {
@@ -177,9 +245,9 @@ func (fs *Filesystem) newKeyRoot(parent context.Context, k ci.PrivKey) (*KeyRoot
 
 	switch pbn.GetType() {
 	case ft.TDirectory:
-		root.val = NewDirectory(pointsTo.B58String(), mnode, root, fs)
+		root.val = NewDirectory(mkey.B58String(), mnode, root, fs)
 	case ft.TFile, ft.TMetadata, ft.TRaw:
-		fi, err := NewFile(pointsTo.B58String(), mnode, root, fs)
+		fi, err := NewFile(mkey.B58String(), mnode, root, fs)
 		if err != nil {
 			return nil, err
 		}
@@ -226,7 +294,7 @@ func (kr *KeyRoot) Publish(ctx context.Context) error {
 	// network operation
 
 	fmt.Println("Publishing!")
-	return kr.fs.nsys.Publish(ctx, kr.key, k)
+	return kr.fs.nsys.Publish(ctx, kr.key, path.Path("/ipfs/"+k.B58String()))
 }
 
 // Republisher manages when to publish the ipns entry associated with a given key
@@ -0,0 +1,203 @@
+// package keystore stores the named private keys a node may publish ipns
+// names with, besides its own identity.
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+)
+
+// SelfKeyName is the name of the key of the node's own identity. It is
+// not stored in keystores, and can't be used for other keys.
+const SelfKeyName = "self"
+
+var (
+	ErrNoSuchKey  = errors.New("no key by the given name was found")
+	ErrKeyExists  = errors.New("key by that name already exists, refusing to overwrite")
+	ErrEmptyName  = errors.New("key names must not be empty")
+	ErrSelfName   = fmt.Errorf("the key name %q is reserved for the node's identity", SelfKeyName)
+	ErrNameFormat = errors.New("key names must not contain '/' or start with '.'")
+)
+
+// Keystore provides a key management interface
+type Keystore interface {
+	// Has returns whether a key by the given name is stored
+	Has(name string) (bool, error)
+	// Put stores a key under the given name. It fails if the name is taken.
+	Put(name string, k ci.PrivKey) error
+	// Get returns the key of the given name, or ErrNoSuchKey
+	Get(name string) (ci.PrivKey, error)
+	// Delete removes the key of the given name, or returns ErrNoSuchKey
+	Delete(name string) error
+	// Rename moves the key of name to newName, replacing the key stored
+	// under it at once if overwrite is set, or else failing if the name
+	// is taken.
+	Rename(name, newName string, overwrite bool) error
+	// List returns the names of the keys stored
+	List() ([]string, error)
+}
+
+// ValidateName returns an error if name may not be the name of a key:
+// keys are stored as files named after them.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return ErrEmptyName
+	case name == SelfKeyName:
+		return ErrSelfName
+	case strings.Contains(name, "/") || strings.HasPrefix(name, "."):
+		return ErrNameFormat
+	}
+	return nil
+}
+
+// FSKeystore is a keystore keeping each key in a file of a directory.
+type FSKeystore struct {
+	dir string
+}
+
+// NewFSKeystore returns the keystore of the directory dir, which is
+// created if it doesn't exist.
+func NewFSKeystore(dir string) (*FSKeystore, error) {
+	fi, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			return nil, err
+		}
+		return &FSKeystore{dir}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &FSKeystore{dir}, nil
+}
+
+func (ks *FSKeystore) Has(name string) (bool, error) {
+	if err := ValidateName(name); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(filepath.Join(ks.dir, name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (ks *FSKeystore) Put(name string, k ci.PrivKey) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	b, err := ci.MarshalPrivateKey(k)
+	if err != nil {
+		return err
+	}
+
+	// O_EXCL makes sure no key is overwritten
+	fi, err := os.OpenFile(filepath.Join(ks.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if os.IsExist(err) {
+		return ErrKeyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fi.Write(b)
+	if cerr := fi.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(fi.Name())
+	}
+	return err
+}
+
+func (ks *FSKeystore) Get(name string) (ci.PrivKey, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(ks.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNoSuchKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ci.UnmarshalPrivateKey(b)
+}
+
+func (ks *FSKeystore) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(ks.dir, name))
+	if os.IsNotExist(err) {
+		return ErrNoSuchKey
+	}
+	return err
+}
+
+func (ks *FSKeystore) Rename(name, newName string, overwrite bool) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+
+	oldPath := filepath.Join(ks.dir, name)
+	newPath := filepath.Join(ks.dir, newName)
+	if overwrite {
+		err := os.Rename(oldPath, newPath)
+		if os.IsNotExist(err) {
+			return ErrNoSuchKey
+		}
+		return err
+	}
+
+	// unlike a rename, a link fails if the new name is taken
+	err := os.Link(oldPath, newPath)
+	switch {
+	case os.IsExist(err):
+		return ErrKeyExists
+	case os.IsNotExist(err):
+		return ErrNoSuchKey
+	case err != nil:
+		return err
+	}
+	return os.Remove(oldPath)
+}
+
+func (ks *FSKeystore) List() ([]string, error) {
+	dir, err := os.Open(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	names, err := dir.Readdirnames(0)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		// leave out whatever else may be in the directory
+		if ValidateName(name) == nil {
+			list = append(list, name)
+		}
+	}
+	return list, nil
+}
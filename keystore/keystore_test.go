@@ -0,0 +1,149 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+)
+
+func privKeyOrFatal(t *testing.T) ci.PrivKey {
+	priv, _, err := ci.GenerateKeyPair(ci.RSA, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func testKeystore(t *testing.T, ks Keystore) {
+	k1 := privKeyOrFatal(t)
+	k2 := privKeyOrFatal(t)
+
+	if err := ks.Put("foo", k1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("bar", k2); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("foo", k2); err != ErrKeyExists {
+		t.Fatalf("expected ErrKeyExists overwriting a key, got %v", err)
+	}
+
+	has, err := ks.Has("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has {
+		t.Fatal("should have key foo")
+	}
+
+	k, err := ks.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.Equals(k1) {
+		t.Fatal("got the wrong key back")
+	}
+
+	names, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "bar" || names[1] != "foo" {
+		t.Fatalf("expected keys bar and foo, got %v", names)
+	}
+
+	if err := ks.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Delete("foo"); err != ErrNoSuchKey {
+		t.Fatalf("expected ErrNoSuchKey deleting a key twice, got %v", err)
+	}
+	if _, err := ks.Get("foo"); err != ErrNoSuchKey {
+		t.Fatalf("expected ErrNoSuchKey getting a deleted key, got %v", err)
+	}
+	has, err = ks.Has("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Fatal("should not have deleted key foo")
+	}
+
+	for _, name := range []string{"", SelfKeyName, "a/b", ".hidden"} {
+		if err := ks.Put(name, k1); err == nil {
+			t.Fatalf("key name %q should not be allowed", name)
+		}
+	}
+
+	// renaming replaces a key only when told to
+	if err := ks.Put("foo", k1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Rename("foo", "bar", false); err != ErrKeyExists {
+		t.Fatalf("expected ErrKeyExists renaming over a key, got %v", err)
+	}
+	if err := ks.Rename("foo", "bar", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Rename("foo", "baz", false); err != ErrNoSuchKey {
+		t.Fatalf("expected ErrNoSuchKey renaming a renamed key, got %v", err)
+	}
+	k, err = ks.Get("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.Equals(k1) {
+		t.Fatal("the renamed key did not replace the other")
+	}
+	names, err = ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "bar" {
+		t.Fatalf("expected only key bar, got %v", names)
+	}
+}
+
+func TestFSKeystore(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ks, err := NewFSKeystore(filepath.Join(tmp, "keystore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKeystore(t, ks)
+
+	// files that can't be keys are left out of the list
+	if err := ioutil.WriteFile(filepath.Join(tmp, "keystore", ".tmp"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "bar" {
+		t.Fatalf("expected only key bar, got %v", names)
+	}
+
+	// keys are read back by a new keystore on the same directory
+	ks2, err := NewFSKeystore(filepath.Join(tmp, "keystore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks2.Get("bar"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemKeystore(t *testing.T) {
+	testKeystore(t, NewMemKeystore())
+}
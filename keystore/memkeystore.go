@@ -0,0 +1,103 @@
+package keystore
+
+import (
+	"sync"
+
+	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+)
+
+// MemKeystore is a keystore keeping its keys in memory, for tests and
+// nodes without a repo on disk.
+type MemKeystore struct {
+	mu   sync.Mutex
+	keys map[string]ci.PrivKey
+}
+
+func NewMemKeystore() *MemKeystore {
+	return &MemKeystore{keys: make(map[string]ci.PrivKey)}
+}
+
+func (mk *MemKeystore) Has(name string) (bool, error) {
+	if err := ValidateName(name); err != nil {
+		return false, err
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	_, ok := mk.keys[name]
+	return ok, nil
+}
+
+func (mk *MemKeystore) Put(name string, k ci.PrivKey) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	if _, ok := mk.keys[name]; ok {
+		return ErrKeyExists
+	}
+	mk.keys[name] = k
+	return nil
+}
+
+func (mk *MemKeystore) Get(name string) (ci.PrivKey, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	k, ok := mk.keys[name]
+	if !ok {
+		return nil, ErrNoSuchKey
+	}
+	return k, nil
+}
+
+func (mk *MemKeystore) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	if _, ok := mk.keys[name]; !ok {
+		return ErrNoSuchKey
+	}
+	delete(mk.keys, name)
+	return nil
+}
+
+func (mk *MemKeystore) Rename(name, newName string, overwrite bool) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	k, ok := mk.keys[name]
+	if !ok {
+		return ErrNoSuchKey
+	}
+	if _, ok := mk.keys[newName]; ok && !overwrite {
+		return ErrKeyExists
+	}
+	delete(mk.keys, name)
+	mk.keys[newName] = k
+	return nil
+}
+
+func (mk *MemKeystore) List() ([]string, error) {
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	names := make([]string, 0, len(mk.keys))
+	for name := range mk.keys {
+		names = append(names, name)
+	}
+	return names, nil
+}
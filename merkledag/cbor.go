@@ -0,0 +1,275 @@
+package merkledag
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	cid "github.com/ipfs/go-ipfs/cid"
+	cbor "github.com/ipfs/go-ipfs/thirdparty/cbor"
+)
+
+// Format identifies the wire format of a Node.
+type Format int
+
+const (
+	// FormatProtobuf nodes have opaque data and a list of named links,
+	// encoded as a PBNode protobuf. It is the zero value.
+	FormatProtobuf Format = iota
+
+	// FormatCBOR nodes are structured documents encoded as canonical CBOR.
+	// Links may appear anywhere in the document, as mh.Multihash values
+	// (to protobuf nodes) or *cid.Cid values.
+	FormatCBOR
+
+	// FormatRaw nodes are raw blocks: Data is the whole block, and there
+	// are no links. Unlike the other formats, raw nodes cannot be told
+	// apart by their content, so links to them record their format.
+	FormatRaw
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatProtobuf:
+		return "protobuf"
+	case FormatCBOR:
+		return "cbor"
+	case FormatRaw:
+		return "raw"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// cborLinkTag is the CBOR tag marking a byte string as the binary cid of a
+// linked node. Version 0 cids, bare multihashes, decode as mh.Multihash.
+const cborLinkTag = 42
+
+// ErrNotCBORMap is returned when a CBOR node's document is not a map.
+var ErrNotCBORMap = fmt.Errorf("merkledag: cbor node must be a map")
+
+// NewCBORNode creates a CBOR-format node holding the given document.
+// Links are the mh.Multihash and *cid.Cid values found anywhere within obj;
+// they are named by their path in the document, e.g. "parent/children/0".
+func NewCBORNode(obj map[string]interface{}) (*Node, error) {
+	n := &Node{Format: FormatCBOR, Obj: obj}
+	if err := n.setCBORLinks(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *Node) setCBORLinks() error {
+	n.Links = nil
+	err := walkCBORLinks(n.Obj, nil, func(p []string, l *Link) {
+		l.Name = strings.Join(p, "/")
+		n.Links = append(n.Links, l)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Stable(LinkSlice(n.Links))
+	return nil
+}
+
+func walkCBORLinks(v interface{}, p []string, f func([]string, *Link)) error {
+	switch v := v.(type) {
+	case mh.Multihash:
+		f(p, &Link{Hash: v})
+	case *cid.Cid:
+		l, err := linkForCid(v)
+		if err != nil {
+			return err
+		}
+		f(p, l)
+	case map[string]interface{}:
+		for k, e := range v {
+			if strings.Contains(k, "/") {
+				return fmt.Errorf("merkledag: cbor key %q contains a '/'", k)
+			}
+			if err := walkCBORLinks(e, append(p[:len(p):len(p)], k), f); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			if err := walkCBORLinks(e, append(p[:len(p):len(p)], strconv.Itoa(i)), f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *Node) marshalCBOR() ([]byte, error) {
+	if n.Obj == nil {
+		return nil, ErrNotCBORMap
+	}
+	v, err := toCBORValue(n.Obj)
+	if err != nil {
+		return nil, err
+	}
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("Marshal failed. %v", err)
+	}
+	return data, nil
+}
+
+func (n *Node) unmarshalCBOR(encoded []byte) error {
+	v, err := cbor.Unmarshal(encoded)
+	if err != nil {
+		return fmt.Errorf("Unmarshal failed. %v", err)
+	}
+	v, err = fromCBORValue(v)
+	if err != nil {
+		return err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return ErrNotCBORMap
+	}
+
+	n.Format = FormatCBOR
+	n.Obj = obj
+	n.Data = nil
+	return n.setCBORLinks()
+}
+
+// toCBORValue replaces links in v with their tagged CBOR representation.
+func toCBORValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case mh.Multihash:
+		return cbor.Tag{Number: cborLinkTag, Value: []byte(v)}, nil
+	case *cid.Cid:
+		return cbor.Tag{Number: cborLinkTag, Value: v.Bytes()}, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			ce, err := toCBORValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = ce
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			ce, err := toCBORValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ce
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// fromCBORValue replaces tagged links in v with mh.Multihash or *cid.Cid
+// values.
+func fromCBORValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case cbor.Tag:
+		if v.Number != cborLinkTag {
+			return nil, fmt.Errorf("merkledag: unsupported cbor tag %d", v.Number)
+		}
+		b, ok := v.Value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("merkledag: cbor link is not a byte string")
+		}
+		c, err := cid.Cast(b)
+		if err != nil {
+			return nil, fmt.Errorf("Link hash is not valid cid. %v", err)
+		}
+		if c.Version() == 0 {
+			return c.Hash(), nil
+		}
+		return c, nil
+	case map[string]interface{}:
+		for k, e := range v {
+			ge, err := fromCBORValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = ge
+		}
+		return v, nil
+	case []interface{}:
+		for i, e := range v {
+			ge, err := fromCBORValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ge
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// Resolve walks the given path segments into the node. If the walk crosses
+// a link, it returns that *Link along with the segments remaining beyond it.
+// For CBOR nodes, a path ending inside the document returns the value found
+// there and no remaining segments. ErrNotFound is returned if a segment
+// names nothing in the node, along with the segments from that one on.
+func (n *Node) Resolve(p []string) (interface{}, []string, error) {
+	if len(p) == 0 {
+		return n, nil, nil
+	}
+
+	if n.Format != FormatCBOR {
+		for _, l := range n.Links {
+			if l.Name == p[0] {
+				return l, p[1:], nil
+			}
+		}
+		return nil, p, ErrNotFound
+	}
+
+	var cur interface{} = n.Obj
+	for i, seg := range p {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[seg]
+			if !ok {
+				return nil, p[i:], ErrNotFound
+			}
+			cur = next
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, p[i:], ErrNotFound
+			}
+			cur = v[idx]
+		default:
+			return nil, p[i:], ErrNotFound
+		}
+
+		if isCBORLink(cur) {
+			name := strings.Join(p[:i+1], "/")
+			for _, l := range n.Links {
+				if l.Name == name {
+					return l, p[i+1:], nil
+				}
+			}
+			return nil, p[i:], ErrNotFound
+		}
+	}
+	return cur, nil, nil
+}
+
+func isCBORLink(v interface{}) bool {
+	switch v.(type) {
+	case mh.Multihash, *cid.Cid:
+		return true
+	default:
+		return false
+	}
+}
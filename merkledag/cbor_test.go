@@ -0,0 +1,126 @@
+package merkledag_test
+
+import (
+	"bytes"
+	"testing"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+	. "github.com/ipfs/go-ipfs/merkledag"
+)
+
+func TestCBORNodeRoundTrip(t *testing.T) {
+	child := &Node{Data: []byte("child")}
+	ch, err := child.Multihash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := NewCBORNode(map[string]interface{}{
+		"name": "beep",
+		"meta": map[string]interface{}{
+			"size":  int64(5),
+			"child": ch,
+		},
+		"list": []interface{}{"a", ch},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nd.Links) != 2 {
+		t.Fatalf("expected 2 links, got %d", len(nd.Links))
+	}
+	if nd.Links[0].Name != "list/1" || nd.Links[1].Name != "meta/child" {
+		t.Fatalf("unexpected link names: %q %q", nd.Links[0].Name, nd.Links[1].Name)
+	}
+
+	enc, err := nd.Encoded(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Decoded(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Format != FormatCBOR {
+		t.Fatal("decoded node should be a cbor node")
+	}
+	if len(out.Links) != 2 || !bytes.Equal(out.Links[1].Hash, ch) {
+		t.Fatal("links not preserved")
+	}
+
+	k1, err := nd.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := out.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 != k2 {
+		t.Fatal("re-encoded cbor node should have the same key")
+	}
+}
+
+func TestCBORNodeResolve(t *testing.T) {
+	h, err := mh.Sum([]byte("beep"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := NewCBORNode(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b":    "value",
+			"link": h,
+		},
+		"list": []interface{}{int64(1), int64(2)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, rest, err := nd.Resolve([]string{"a", "link", "more", "parts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lnk, ok := v.(*Link)
+	if !ok {
+		t.Fatalf("expected a link, got %#v", v)
+	}
+	if !bytes.Equal(lnk.Hash, h) || len(rest) != 2 || rest[0] != "more" {
+		t.Fatal("resolved the wrong link")
+	}
+
+	v, rest, err = nd.Resolve([]string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "value" || len(rest) != 0 {
+		t.Fatalf("expected a value, got %#v", v)
+	}
+
+	v, _, err = nd.Resolve([]string{"list", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != int64(2) {
+		t.Fatalf("expected list element, got %#v", v)
+	}
+
+	if _, rest, err := nd.Resolve([]string{"a", "nope", "more"}); err != ErrNotFound || len(rest) != 2 || rest[0] != "nope" {
+		t.Fatalf("expected ErrNotFound at nope, got %v at %v", err, rest)
+	}
+	if _, _, err := nd.Resolve([]string{"list", "5"}); err != ErrNotFound {
+		t.Fatal("expected ErrNotFound, got ", err)
+	}
+}
+
+func TestCBORNodeRejectsNonMap(t *testing.T) {
+	if _, err := NewCBORNode(map[string]interface{}{"a/b": "c"}); err == nil {
+		t.Fatal("keys containing '/' should be rejected")
+	}
+	if _, err := (&Node{Format: FormatCBOR}).Marshal(); err != ErrNotCBORMap {
+		t.Fatal("expected ErrNotCBORMap, got ", err)
+	}
+}
@@ -0,0 +1,77 @@
+package merkledag
+
+import (
+	"fmt"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	cid "github.com/ipfs/go-ipfs/cid"
+)
+
+// Codec returns the cid codec of nodes in format f.
+func (f Format) Codec() uint64 {
+	switch f {
+	case FormatCBOR:
+		return cid.DagCBOR
+	case FormatRaw:
+		return cid.Raw
+	default:
+		return cid.DagProtobuf
+	}
+}
+
+// FormatForCodec returns the format of nodes with the given cid codec.
+func FormatForCodec(codec uint64) (Format, error) {
+	switch codec {
+	case cid.DagProtobuf:
+		return FormatProtobuf, nil
+	case cid.DagCBOR:
+		return FormatCBOR, nil
+	case cid.Raw:
+		return FormatRaw, nil
+	default:
+		return 0, fmt.Errorf("merkledag: unsupported codec %s", cid.CodecName(codec))
+	}
+}
+
+// makeCid returns the cid of a node in format f with multihash h. Protobuf
+// nodes hashed with SHA2_256 keep their version 0 cid, the bare multihash.
+func makeCid(f Format, h mh.Multihash) *cid.Cid {
+	if f == FormatProtobuf && len(h) > 0 && h[0] == mh.SHA2_256 {
+		return cid.NewCidV0(h)
+	}
+	return cid.NewCidV1(f.Codec(), h)
+}
+
+// Cid returns the content identifier of this node.
+func (n *Node) Cid() (*cid.Cid, error) {
+	h, err := n.Multihash()
+	if err != nil {
+		return nil, err
+	}
+	return makeCid(n.Format, h), nil
+}
+
+// Cid returns the content identifier of the target object.
+func (l *Link) Cid() *cid.Cid {
+	return makeCid(l.Format, l.Hash)
+}
+
+// linkForCid returns a link to the object c identifies.
+func linkForCid(c *cid.Cid) (*Link, error) {
+	f, err := FormatForCodec(c.Codec())
+	if err != nil {
+		return nil, err
+	}
+	return &Link{Hash: c.Hash(), Format: f}, nil
+}
+
+// GetCid retrieves the node c identifies from serv, decoding it in the
+// format named by its codec.
+func GetCid(serv DAGService, c *cid.Cid) (*Node, error) {
+	f, err := FormatForCodec(c.Codec())
+	if err != nil {
+		return nil, err
+	}
+	return serv.GetFormat(c.Key(), f)
+}
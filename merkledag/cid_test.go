@@ -0,0 +1,97 @@
+package merkledag_test
+
+import (
+	"bytes"
+	"testing"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+	cid "github.com/ipfs/go-ipfs/cid"
+	. "github.com/ipfs/go-ipfs/merkledag"
+)
+
+func TestNodeCid(t *testing.T) {
+	pb := &Node{Data: []byte("beep")}
+	c, err := pb.Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := pb.Multihash()
+	if c.Version() != 0 || !bytes.Equal(c.Hash(), h) {
+		t.Fatal("protobuf node should have a version 0 cid")
+	}
+
+	pb3 := &Node{Data: []byte("beep"), HashFunc: mh.SHA3}
+	c, err = pb3.Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 1 || c.Codec() != cid.DagProtobuf {
+		t.Fatal("sha3 protobuf node should have a version 1 dag-pb cid")
+	}
+
+	c, err = NewRawNode([]byte("beep")).Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 1 || c.Codec() != cid.Raw {
+		t.Fatal("raw node should have a version 1 raw cid")
+	}
+}
+
+func TestCBORCidLinks(t *testing.T) {
+	dsp := getDagservAndPinner(t)
+
+	raw := NewRawNode([]byte("\xff raw block data"))
+	if _, err := dsp.ds.Add(raw); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := raw.Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := NewCBORNode(map[string]interface{}{"data": rc})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := nd.Encoded(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Decoded(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oc, ok := out.Obj["data"].(*cid.Cid)
+	if !ok || !oc.Equals(rc) {
+		t.Fatal("cid link did not round trip")
+	}
+	if len(out.Links) != 1 || out.Links[0].Format != FormatRaw {
+		t.Fatal("cid link should record the format of its target")
+	}
+
+	if _, err := dsp.ds.Add(out); err != nil {
+		t.Fatal(err)
+	}
+	c, err := out.Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetCid(dsp.ds, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != FormatCBOR {
+		t.Fatal("GetCid should decode the node in the cid's format")
+	}
+
+	child, err := got.Links[0].GetNode(dsp.ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(child.Data, raw.Data) {
+		t.Fatal("linked raw node did not round trip")
+	}
+}
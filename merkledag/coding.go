@@ -7,6 +7,7 @@ import (
 	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 
 	pb "github.com/ipfs/go-ipfs/merkledag/internal/pb"
+	cbor "github.com/ipfs/go-ipfs/thirdparty/cbor"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
@@ -14,8 +15,15 @@ import (
 // because native go objects are nice.
 
 // Unmarshal decodes raw data into a *Node instance.
-// The conversion uses an intermediate PBNode.
+// The conversion uses an intermediate PBNode, unless the data is a CBOR
+// map, which is decoded as a FormatCBOR node.
 func (n *Node) Unmarshal(encoded []byte) error {
+	// no PBNode field encodes to a leading byte in the CBOR map range,
+	// so the two formats can be told apart by their first byte.
+	if cbor.IsMap(encoded) {
+		return n.unmarshalCBOR(encoded)
+	}
+
 	var pbn pb.PBNode
 	if err := pbn.Unmarshal(encoded); err != nil {
 		return fmt.Errorf("Unmarshal failed. %v", err)
@@ -24,7 +32,7 @@ func (n *Node) Unmarshal(encoded []byte) error {
 	pbnl := pbn.GetLinks()
 	n.Links = make([]*Link, len(pbnl))
 	for i, l := range pbnl {
-		n.Links[i] = &Link{Name: l.GetName(), Size: l.GetTsize()}
+		n.Links[i] = &Link{Name: l.GetName(), Size: l.GetTsize(), Format: Format(l.GetFormat())}
 		h, err := mh.Cast(l.GetHash())
 		if err != nil {
 			return fmt.Errorf("Link hash is not valid multihash. %v", err)
@@ -34,12 +42,26 @@ func (n *Node) Unmarshal(encoded []byte) error {
 	sort.Stable(LinkSlice(n.Links)) // keep links sorted
 
 	n.Data = pbn.GetData()
+	n.Format = FormatProtobuf
+	n.Obj = nil
 	return nil
 }
 
 // MarshalTo encodes a *Node instance into a given byte slice.
 // The conversion uses an intermediate PBNode.
 func (n *Node) MarshalTo(encoded []byte) error {
+	if n.Format != FormatProtobuf {
+		data, err := n.Marshal()
+		if err != nil {
+			return err
+		}
+		if len(encoded) < len(data) {
+			return fmt.Errorf("Marshal failed. buffer too small")
+		}
+		copy(encoded, data)
+		return nil
+	}
+
 	pbn := n.getPBNode()
 	if _, err := pbn.MarshalTo(encoded); err != nil {
 		return fmt.Errorf("Marshal failed. %v", err)
@@ -48,8 +70,15 @@ func (n *Node) MarshalTo(encoded []byte) error {
 }
 
 // Marshal encodes a *Node instance into a new byte slice.
-// The conversion uses an intermediate PBNode.
+// The conversion uses an intermediate PBNode for protobuf nodes.
 func (n *Node) Marshal() ([]byte, error) {
+	switch n.Format {
+	case FormatCBOR:
+		return n.marshalCBOR()
+	case FormatRaw:
+		return n.Data, nil
+	}
+
 	pbn := n.getPBNode()
 	data, err := pbn.Marshal()
 	if err != nil {
@@ -68,6 +97,10 @@ func (n *Node) getPBNode() *pb.PBNode {
 		pbn.Links[i].Name = &l.Name
 		pbn.Links[i].Tsize = &l.Size
 		pbn.Links[i].Hash = []byte(l.Hash)
+		if l.Format != FormatProtobuf {
+			f := uint64(l.Format)
+			pbn.Links[i].Format = &f
+		}
 	}
 
 	pbn.Data = n.Data
@@ -84,12 +117,25 @@ func (n *Node) Encoded(force bool) ([]byte, error) {
 		if err != nil {
 			return []byte{}, err
 		}
-		n.cached = u.Hash(n.encoded)
+		n.cached, err = u.HashWith(n.encoded, n.HashFunc)
+		if err != nil {
+			return []byte{}, err
+		}
 	}
 
 	return n.encoded, nil
 }
 
+// DecodedFormat decodes raw data of the given format and returns a new
+// Node instance. Protobuf and CBOR nodes are told apart by their content,
+// so only FormatRaw changes how the data is decoded.
+func DecodedFormat(encoded []byte, f Format) (*Node, error) {
+	if f == FormatRaw {
+		return NewRawNode(encoded), nil
+	}
+	return Decoded(encoded)
+}
+
 // Decoded decodes raw data and returns a new Node instance.
 func Decoded(encoded []byte) (*Node, error) {
 	n := new(Node)
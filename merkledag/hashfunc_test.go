@@ -0,0 +1,102 @@
+package merkledag_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	imp "github.com/ipfs/go-ipfs/importer"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	h "github.com/ipfs/go-ipfs/importer/helpers"
+	. "github.com/ipfs/go-ipfs/merkledag"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+// checkHashFunc checks that nd and all nodes below it are hashed with code
+func checkHashFunc(t *testing.T, nd *Node, ds DAGService, code int) {
+	k, err := nd.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kcode, err := k.HashFunc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kcode != code {
+		t.Fatalf("node hashed with %d, expected %d", kcode, code)
+	}
+
+	for _, lnk := range nd.Links {
+		child, err := lnk.GetNode(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHashFunc(t, child, ds, code)
+	}
+}
+
+func TestNodeHashFunc(t *testing.T) {
+	dsp := getDagservAndPinner(t)
+
+	nd := &Node{Data: []byte("hashed with sha3"), HashFunc: mh.SHA3}
+	k, err := dsp.ds.Add(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := dsp.ds.Get(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.HashFunc != mh.SHA3 {
+		t.Fatal("node fetched from the dag should keep its hash function")
+	}
+
+	// changes to the node are hashed with the same function
+	err = out.AddNodeLink("child", &Node{Data: []byte("child"), HashFunc: mh.SHA3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHashFunc(t, out.Copy(), dsp.ds, mh.SHA3)
+}
+
+func TestImportHashFunc(t *testing.T) {
+	dsp := getDagservAndPinner(t)
+
+	data := make([]byte, 100*1024)
+	u.NewTimeSeededRand().Read(data)
+
+	for _, code := range []int{mh.SHA2_512, mh.SHA3, mh.BLAKE2B} {
+		dbp := h.DagBuilderParams{
+			Dagserv:   dsp.ds,
+			RawLeaves: true,
+			HashFunc:  code,
+		}
+
+		root, err := imp.BuildDagFromReaderParams(bytes.NewReader(data), &chunk.SizeSplitter{Size: 4096}, dbp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkHashFunc(t, root, dsp.ds, code)
+
+		dr, err := uio.NewDagReader(context.TODO(), root, dsp.ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ioutil.ReadAll(dr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out, data) {
+			t.Fatal("data read back does not match")
+		}
+	}
+}
@@ -48,7 +48,9 @@ type PBLink struct {
 	// utf string name. should be unique per object
 	Name *string `protobuf:"bytes,2,opt" json:"Name,omitempty"`
 	// cumulative size of target object
-	Tsize            *uint64 `protobuf:"varint,3,opt" json:"Tsize,omitempty"`
+	Tsize *uint64 `protobuf:"varint,3,opt" json:"Tsize,omitempty"`
+	// format of the target object, if it cannot be told from its data
+	Format           *uint64 `protobuf:"varint,4,opt" json:"Format,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -76,6 +78,13 @@ func (m *PBLink) GetTsize() uint64 {
 	return 0
 }
 
+func (m *PBLink) GetFormat() uint64 {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return 0
+}
+
 // An IPFS MerkleDAG Node
 type PBNode struct {
 	// refs to other objects
@@ -185,6 +194,23 @@ func (m *PBLink) Unmarshal(data []byte) error {
 				}
 			}
 			m.Tsize = &v
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Format", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if index >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[index]
+				index++
+				v |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Format = &v
 		default:
 			var sizeOfWire int
 			for {
@@ -303,6 +329,7 @@ func (this *PBLink) String() string {
 		`Hash:` + valueToStringMerkledag(this.Hash) + `,`,
 		`Name:` + valueToStringMerkledag(this.Name) + `,`,
 		`Tsize:` + valueToStringMerkledag(this.Tsize) + `,`,
+		`Format:` + valueToStringMerkledag(this.Format) + `,`,
 		`XXX_unrecognized:` + fmt1.Sprintf("%v", this.XXX_unrecognized) + `,`,
 		`}`,
 	}, "")
@@ -342,6 +369,9 @@ func (m *PBLink) Size() (n int) {
 	if m.Tsize != nil {
 		n += 1 + sovMerkledag(uint64(*m.Tsize))
 	}
+	if m.Format != nil {
+		n += 1 + sovMerkledag(uint64(*m.Format))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -396,8 +426,12 @@ func NewPopulatedPBLink(r randyMerkledag, easy bool) *PBLink {
 		v3 := uint64(r.Uint32())
 		this.Tsize = &v3
 	}
+	if r.Intn(10) != 0 {
+		v4 := uint64(r.Uint32())
+		this.Format = &v4
+	}
 	if !easy && r.Intn(10) != 0 {
-		this.XXX_unrecognized = randUnrecognizedMerkledag(r, 4)
+		this.XXX_unrecognized = randUnrecognizedMerkledag(r, 5)
 	}
 	return this
 }
@@ -405,16 +439,16 @@ func NewPopulatedPBLink(r randyMerkledag, easy bool) *PBLink {
 func NewPopulatedPBNode(r randyMerkledag, easy bool) *PBNode {
 	this := &PBNode{}
 	if r.Intn(10) != 0 {
-		v4 := r.Intn(10)
-		this.Links = make([]*PBLink, v4)
-		for i := 0; i < v4; i++ {
+		v5 := r.Intn(10)
+		this.Links = make([]*PBLink, v5)
+		for i := 0; i < v5; i++ {
 			this.Links[i] = NewPopulatedPBLink(r, easy)
 		}
 	}
 	if r.Intn(10) != 0 {
-		v5 := r.Intn(100)
-		this.Data = make([]byte, v5)
-		for i := 0; i < v5; i++ {
+		v6 := r.Intn(100)
+		this.Data = make([]byte, v6)
+		for i := 0; i < v6; i++ {
 			this.Data[i] = byte(r.Intn(256))
 		}
 	}
@@ -526,6 +560,11 @@ func (m *PBLink) MarshalTo(data []byte) (n int, err error) {
 		i++
 		i = encodeVarintMerkledag(data, i, uint64(*m.Tsize))
 	}
+	if m.Format != nil {
+		data[i] = 0x20
+		i++
+		i = encodeVarintMerkledag(data, i, uint64(*m.Format))
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -600,7 +639,7 @@ func (this *PBLink) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings1.Join([]string{`&merkledag_pb.PBLink{` + `Hash:` + valueToGoStringMerkledag(this.Hash, "byte"), `Name:` + valueToGoStringMerkledag(this.Name, "string"), `Tsize:` + valueToGoStringMerkledag(this.Tsize, "uint64"), `XXX_unrecognized:` + fmt2.Sprintf("%#v", this.XXX_unrecognized) + `}`}, ", ")
+	s := strings1.Join([]string{`&merkledag_pb.PBLink{` + `Hash:` + valueToGoStringMerkledag(this.Hash, "byte"), `Name:` + valueToGoStringMerkledag(this.Name, "string"), `Tsize:` + valueToGoStringMerkledag(this.Tsize, "uint64"), `Format:` + valueToGoStringMerkledag(this.Format, "uint64"), `XXX_unrecognized:` + fmt2.Sprintf("%#v", this.XXX_unrecognized) + `}`}, ", ")
 	return s
 }
 func (this *PBNode) GoString() string {
@@ -676,6 +715,15 @@ func (this *PBLink) VerboseEqual(that interface{}) error {
 	} else if that1.Tsize != nil {
 		return fmt3.Errorf("Tsize this(%v) Not Equal that(%v)", this.Tsize, that1.Tsize)
 	}
+	if this.Format != nil && that1.Format != nil {
+		if *this.Format != *that1.Format {
+			return fmt3.Errorf("Format this(%v) Not Equal that(%v)", *this.Format, *that1.Format)
+		}
+	} else if this.Format != nil {
+		return fmt3.Errorf("this.Format == nil && that.Format != nil")
+	} else if that1.Format != nil {
+		return fmt3.Errorf("Format this(%v) Not Equal that(%v)", this.Format, that1.Format)
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return fmt3.Errorf("XXX_unrecognized this(%v) Not Equal that(%v)", this.XXX_unrecognized, that1.XXX_unrecognized)
 	}
@@ -722,6 +770,15 @@ func (this *PBLink) Equal(that interface{}) bool {
 	} else if that1.Tsize != nil {
 		return false
 	}
+	if this.Format != nil && that1.Format != nil {
+		if *this.Format != *that1.Format {
+			return false
+		}
+	} else if this.Format != nil {
+		return false
+	} else if that1.Format != nil {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
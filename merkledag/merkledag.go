@@ -22,6 +22,10 @@ type DAGService interface {
 	Get(u.Key) (*Node, error)
 	Remove(*Node) error
 
+	// GetFormat retrieves a node whose format is known, such as
+	// from the link pointing to it.
+	GetFormat(u.Key, Format) (*Node, error)
+
 	// GetDAG returns, in order, all the single leve child
 	// nodes of the passed in node.
 	GetDAG(context.Context, *Node) []NodeGetter
@@ -84,6 +88,12 @@ func (n *dagService) AddRecursive(nd *Node) error {
 
 // Get retrieves a node from the dagService, fetching the block in the BlockService
 func (n *dagService) Get(k u.Key) (*Node, error) {
+	return n.GetFormat(k, FormatProtobuf)
+}
+
+// GetFormat retrieves a node from the dagService, decoding the block as
+// the given format
+func (n *dagService) GetFormat(k u.Key, f Format) (*Node, error) {
 	if n == nil {
 		return nil, fmt.Errorf("dagService is nil")
 	}
@@ -99,7 +109,22 @@ func (n *dagService) Get(k u.Key) (*Node, error) {
 		return nil, err
 	}
 
-	return Decoded(b.Data)
+	return decodeBlock(b, f)
+}
+
+// decodeBlock decodes a block as a node of the given format, hashed with
+// the same function as the block
+func decodeBlock(b *blocks.Block, f Format) (*Node, error) {
+	nd, err := DecodedFormat(b.Data, f)
+	if err != nil {
+		return nil, err
+	}
+
+	nd.HashFunc, err = b.Key().HashFunc()
+	if err != nil {
+		return nil, err
+	}
+	return nd, nil
 }
 
 // Remove deletes the given node and all of its children from the BlockService
@@ -170,16 +195,24 @@ func FindLinks(links []u.Key, k u.Key, start int) []int {
 // all the child nodes of 'root' on, in proper order.
 func (ds *dagService) GetDAG(ctx context.Context, root *Node) []NodeGetter {
 	var keys []u.Key
+	var formats []Format
 	for _, lnk := range root.Links {
 		keys = append(keys, u.Key(lnk.Hash))
+		formats = append(formats, lnk.Format)
 	}
 
-	return ds.GetNodes(ctx, keys)
+	return ds.getNodes(ctx, keys, formats)
 }
 
 // GetNodes returns an array of 'NodeGetter' promises, with each corresponding
 // to the key with the same index as the passed in keys
 func (ds *dagService) GetNodes(ctx context.Context, keys []u.Key) []NodeGetter {
+	return ds.getNodes(ctx, keys, nil)
+}
+
+// getNodes is GetNodes, decoding each block as the format with the same
+// index in formats, if given
+func (ds *dagService) getNodes(ctx context.Context, keys []u.Key, formats []Format) []NodeGetter {
 
 	// Early out if no work to do
 	if len(keys) == 0 {
@@ -206,14 +239,19 @@ func (ds *dagService) GetNodes(ctx context.Context, keys []u.Key) []NodeGetter {
 					return
 				}
 
-				nd, err := Decoded(blk.Data)
-				if err != nil {
-					// NB: can happen with improperly formatted input data
-					log.Debug("Got back bad block!")
-					return
-				}
 				is := FindLinks(keys, blk.Key(), 0)
 				for _, i := range is {
+					f := FormatProtobuf
+					if formats != nil {
+						f = formats[i]
+					}
+
+					nd, err := decodeBlock(blk, f)
+					if err != nil {
+						// NB: can happen with improperly formatted input data
+						log.Debug("Got back bad block!")
+						return
+					}
 					count++
 					sendChans[i] <- nd
 				}
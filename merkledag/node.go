@@ -18,6 +18,17 @@ type Node struct {
 	Links []*Link
 	Data  []byte
 
+	// Format is the wire format of the node. For FormatCBOR nodes, the
+	// content lives in Obj, and Links are derived from it (read-only).
+	// FormatRaw nodes only have Data.
+	Format Format
+	Obj    map[string]interface{}
+
+	// HashFunc is the multihash code of the hash function the node is
+	// hashed with. The zero value selects the default, SHA2_256. It must
+	// be set before the node is first hashed.
+	HashFunc int
+
 	// cache encoded/marshaled value
 	encoded []byte
 
@@ -49,6 +60,9 @@ type Link struct {
 	// multihash of the target object
 	Hash mh.Multihash
 
+	// format of the target object
+	Format Format
+
 	// a ptr to the actual node for graph manipulation
 	Node *Node
 }
@@ -71,8 +85,9 @@ func MakeLink(n *Node) (*Link, error) {
 		return nil, err
 	}
 	return &Link{
-		Size: s,
-		Hash: h,
+		Size:   s,
+		Hash:   h,
+		Format: n.Format,
 	}, nil
 }
 
@@ -82,7 +97,12 @@ func (l *Link) GetNode(serv DAGService) (*Node, error) {
 		return l.Node, nil
 	}
 
-	return serv.Get(u.Key(l.Hash))
+	return serv.GetFormat(u.Key(l.Hash), l.Format)
+}
+
+// NewRawNode creates a FormatRaw node holding the given block data.
+func NewRawNode(data []byte) *Node {
+	return &Node{Data: data, Format: FormatRaw}
 }
 
 // AddNodeLink adds a link to another node.
@@ -126,7 +146,8 @@ func (n *Node) RemoveNodeLink(name string) error {
 }
 
 // Copy returns a copy of the node.
-// NOTE: does not make copies of Node objects in the links.
+// NOTE: does not make copies of Node objects in the links, nor of the
+// document of a CBOR node.
 func (n *Node) Copy() *Node {
 	nnode := new(Node)
 	nnode.Data = make([]byte, len(n.Data))
@@ -134,6 +155,10 @@ func (n *Node) Copy() *Node {
 
 	nnode.Links = make([]*Link, len(n.Links))
 	copy(nnode.Links, n.Links)
+
+	nnode.Format = n.Format
+	nnode.Obj = n.Obj
+	nnode.HashFunc = n.HashFunc
 	return nnode
 }
 
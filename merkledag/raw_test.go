@@ -0,0 +1,55 @@
+package merkledag_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/ipfs/go-ipfs/merkledag"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+func TestRawNodeLinks(t *testing.T) {
+	dsp := getDagservAndPinner(t)
+
+	// data that would not decode as a protobuf node
+	data := []byte("\xff raw block data")
+	raw := NewRawNode(data)
+
+	k, err := dsp.ds.Add(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k != u.Key(u.Hash(data)) {
+		t.Fatal("raw node should be keyed by the hash of its data")
+	}
+
+	parent := new(Node)
+	err = parent.AddNodeLinkClean("raw", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := parent.Encoded(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err = Decoded(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent.Links[0].Format != FormatRaw {
+		t.Fatalf("expected link format raw, got %s", parent.Links[0].Format)
+	}
+
+	nd, err := parent.Links[0].GetNode(dsp.ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nd.Format != FormatRaw || !bytes.Equal(nd.Data, data) {
+		t.Fatal("raw node did not round trip")
+	}
+}
@@ -4,7 +4,12 @@ package traverse
 import (
 	"errors"
 
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+
+	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
+	u "github.com/ipfs/go-ipfs/util"
 )
 
 // Order is an identifier for traversal algorithm orders
@@ -24,12 +29,16 @@ type Options struct {
 	ErrFunc ErrFunc         // see ErrFunc. Optional
 
 	SkipDuplicates bool // whether to skip duplicate nodes
+	MaxDepth       int  // if positive, do not visit nodes deeper than this
 }
 
 // State is a current traversal state
 type State struct {
 	Node  *mdag.Node
 	Depth int
+
+	Parent *mdag.Node // the node linking to Node. nil for the root
+	Link   *mdag.Link // the link from Parent to Node. nil for the root
 }
 
 type traversal struct {
@@ -37,20 +46,23 @@ type traversal struct {
 	seen map[string]struct{}
 }
 
-func (t *traversal) shouldSkip(n *mdag.Node) (bool, error) {
-	if t.opts.SkipDuplicates {
-		k, err := n.Key()
-		if err != nil {
-			return true, err
-		}
+// shouldSkip returns whether to skip the node with key k. Duplicates are
+// detected by key, so they are skipped without being fetched.
+func (t *traversal) shouldSkip(k u.Key) bool {
+	if !t.opts.SkipDuplicates {
+		return false
+	}
 
-		if _, found := t.seen[string(k)]; found {
-			return true, nil
-		}
-		t.seen[string(k)] = struct{}{}
+	if _, found := t.seen[string(k)]; found {
+		return true
 	}
+	t.seen[string(k)] = struct{}{}
+	return false
+}
 
-	return false, nil
+// shouldDescend returns whether to visit the children of a node at depth.
+func (t *traversal) shouldDescend(depth int) bool {
+	return t.opts.MaxDepth <= 0 || depth < t.opts.MaxDepth
 }
 
 func (t *traversal) callFunc(next State) error {
@@ -62,21 +74,19 @@ func (t *traversal) callFunc(next State) error {
 //
 // the error handling is a little complicated.
 func (t *traversal) getNode(link *mdag.Link) (*mdag.Node, error) {
-
-	getNode := func(l *mdag.Link) (*mdag.Node, error) {
-		next, err := l.GetNode(t.opts.DAG)
-		if err != nil {
+	// links to nodes in memory may be stale, so key those by the node.
+	k := u.Key(link.Hash)
+	if link.Node != nil {
+		var err error
+		if k, err = link.Node.Key(); err != nil {
 			return nil, err
 		}
-
-		skip, err := t.shouldSkip(next)
-		if skip {
-			next = nil
-		}
-		return next, err
+	}
+	if t.shouldSkip(k) {
+		return nil, nil
 	}
 
-	next, err := getNode(link)
+	next, err := link.GetNode(t.opts.DAG)
 	if err != nil && t.opts.ErrFunc != nil { // attempt recovery.
 		err = t.opts.ErrFunc(err)
 		next = nil // skip regardless
@@ -101,6 +111,16 @@ type Func func(current State) error
 //
 type ErrFunc func(err error) error
 
+// SkipMissing is an ErrFunc that skips nodes which could not be found, and
+// halts on any other error. Used with a DAGService that does not fetch from
+// the network, it stops the traversal at nodes missing locally.
+func SkipMissing(err error) error {
+	if err == blockstore.ErrNotFound || err == bserv.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
 func Traverse(root *mdag.Node, o Options) error {
 	t := traversal{
 		opts: o,
@@ -124,6 +144,31 @@ func Traverse(root *mdag.Node, o Options) error {
 	}
 }
 
+// Stream traverses the dag like Traverse, but sends each State visited on
+// the returned channel instead of calling o.Func. The traversal stops early
+// if ctx is done. Once it has finished, its error (nil on success) is sent
+// on the error channel, and both channels are closed.
+func Stream(ctx context.Context, root *mdag.Node, o Options) (<-chan State, <-chan error) {
+	out := make(chan State)
+	errs := make(chan error, 1)
+
+	o.Func = func(current State) error {
+		select {
+		case out <- current:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+		errs <- Traverse(root, o)
+	}()
+	return out, errs
+}
+
 type dfsFunc func(state State, t *traversal) error
 
 func dfsPreTraverse(state State, t *traversal) error {
@@ -147,6 +192,10 @@ func dfsPostTraverse(state State, t *traversal) error {
 }
 
 func dfsDescend(df dfsFunc, curr State, t *traversal) error {
+	if !t.shouldDescend(curr.Depth) {
+		return nil
+	}
+
 	for _, l := range curr.Node.Links {
 		node, err := t.getNode(l)
 		if err != nil {
@@ -157,8 +206,10 @@ func dfsDescend(df dfsFunc, curr State, t *traversal) error {
 		}
 
 		next := State{
-			Node:  node,
-			Depth: curr.Depth + 1,
+			Node:   node,
+			Depth:  curr.Depth + 1,
+			Parent: curr.Node,
+			Link:   l,
 		}
 		if err := df(next, t); err != nil {
 			return err
@@ -169,8 +220,12 @@ func dfsDescend(df dfsFunc, curr State, t *traversal) error {
 
 func bfsTraverse(root State, t *traversal) error {
 
-	if skip, err := t.shouldSkip(root.Node); skip || err != nil {
-		return err
+	if t.opts.SkipDuplicates {
+		k, err := root.Node.Key()
+		if err != nil {
+			return err
+		}
+		t.shouldSkip(k)
 	}
 
 	var q queue
@@ -186,6 +241,10 @@ func bfsTraverse(root State, t *traversal) error {
 			return err
 		}
 
+		if !t.shouldDescend(curr.Depth) {
+			continue
+		}
+
 		for _, l := range curr.Node.Links {
 			node, err := t.getNode(l)
 			if err != nil {
@@ -196,8 +255,10 @@ func bfsTraverse(root State, t *traversal) error {
 			}
 
 			q.enq(State{
-				Node:  node,
-				Depth: curr.Depth + 1,
+				Node:   node,
+				Depth:  curr.Depth + 1,
+				Parent: curr.Node,
+				Link:   l,
 			})
 		}
 	}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"testing"
 
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+
 	mdag "github.com/ipfs/go-ipfs/merkledag"
+	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
 )
 
 func TestDFSPreNoSkip(t *testing.T) {
@@ -314,6 +317,120 @@ func TestBFSSkip(t *testing.T) {
 `))
 }
 
+func TestDFSPreMaxDepth(t *testing.T) {
+	opts := Options{Order: DFSPre, MaxDepth: 2}
+
+	testWalkOutputs(t, newLinkedList(t), opts, []byte(`
+0 /a
+1 /a/aa
+2 /a/aa/aaa
+`))
+
+	testWalkOutputs(t, newBinaryTree(t), opts, []byte(`
+0 /a
+1 /a/aa
+2 /a/aa/aaa
+2 /a/aa/aab
+1 /a/ab
+2 /a/ab/aba
+2 /a/ab/abb
+`))
+}
+
+func TestBFSMaxDepth(t *testing.T) {
+	opts := Options{Order: BFS, MaxDepth: 1}
+
+	testWalkOutputs(t, newBinaryTree(t), opts, []byte(`
+0 /a
+1 /a/aa
+1 /a/ab
+`))
+
+	opts.SkipDuplicates = true
+	testWalkOutputs(t, newBinaryDAG(t), opts, []byte(`
+0 /a
+1 /a/aa
+`))
+}
+
+func TestStream(t *testing.T) {
+	opts := Options{Order: BFS}
+
+	states, errs := Stream(context.Background(), newBinaryTree(t), opts)
+
+	var buf bytes.Buffer
+	for s := range states {
+		if s.Depth > 0 && s.Link.Node != s.Node {
+			t.Fatal("state link does not point to its node")
+		}
+		fmt.Fprintf(&buf, "%d %s\n", s.Depth, s.Node.Data)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `0 /a
+1 /a/aa
+1 /a/ab
+2 /a/aa/aaa
+2 /a/aa/aab
+2 /a/ab/aba
+2 /a/ab/abb
+`
+	if buf.String() != expect {
+		t.Fatalf("expect:\n%s\nactual:\n%s", expect, buf.String())
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	states, errs := Stream(ctx, newLinkedList(t), Options{Order: DFSPre})
+
+	<-states
+	cancel()
+
+	for range states {
+	}
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSkipMissing(t *testing.T) {
+	ds := mdtest.Mock(t)
+
+	// build bottom up, so the links hold the final hashes.
+	a := &mdag.Node{Data: []byte("/a")}
+	aa := &mdag.Node{Data: []byte("/a/aa")}
+	ab := &mdag.Node{Data: []byte("/a/ab")}
+	addChild(t, aa, "aaa")
+	addLink(t, a, aa)
+	addLink(t, a, ab)
+	for _, n := range []*mdag.Node{a, aa, ab} {
+		if _, err := ds.Add(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// fetch everything through the DAGService; /a/aa/aaa was never added.
+	for _, n := range []*mdag.Node{a, aa, ab} {
+		for _, l := range n.Links {
+			l.Node = nil
+		}
+	}
+
+	opts := Options{DAG: ds, Order: DFSPre, Func: func(State) error { return nil }}
+	if err := Traverse(a, opts); err == nil {
+		t.Fatal("expected missing node to halt the traversal")
+	}
+
+	opts.ErrFunc = SkipMissing
+	testWalkOutputs(t, a, opts, []byte(`
+0 /a
+1 /a/aa
+1 /a/ab
+`))
+}
+
 func testWalkOutputs(t *testing.T, root *mdag.Node, opts Options, expect []byte) {
 	expect = bytes.TrimLeft(expect, "\n")
 
@@ -0,0 +1,54 @@
+package namesys
+
+import (
+	gopath "path"
+	"strings"
+	"time"
+
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	path "github.com/ipfs/go-ipfs/path"
+)
+
+// resolver resolves names a single step: the value of a name may be
+// another /ipns/ path.
+type resolver interface {
+	// resolveOnce looks up the value of name, and tells how long it may
+	// be cached for.
+	resolveOnce(ctx context.Context, name string) (value path.Path, ttl time.Duration, err error)
+
+	// CanResolve checks whether this resolver can resolve a name
+	CanResolve(name string) bool
+}
+
+// resolve resolves name with r, following the /ipns/ paths it resolves
+// to for as long as r can resolve them, up to depth steps. The path
+// below a name is kept below the value it resolves to.
+func resolve(ctx context.Context, r resolver, name string, depth int) (path.Path, error) {
+	name = strings.TrimPrefix(name, "/ipns/")
+	if name == "" {
+		return "", ErrResolveFailed
+	}
+	p := path.Path("/ipns/" + name)
+
+	seen := make(map[string]bool)
+	for i := 0; depth == UnlimitedDepth || i < depth; i++ {
+		segments := p.Segments()
+		key, rest := segments[1], segments[2:]
+		if seen[key] {
+			return "", ErrResolveCycle
+		}
+		seen[key] = true
+
+		val, _, err := r.resolveOnce(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		log.Debugf("resolved %s to %s", key, val)
+
+		p = path.Path(gopath.Join(append([]string{val.String()}, rest...)...))
+		if !p.IsIpns() || !r.CanResolve(p.Segments()[1]) {
+			return p, nil
+		}
+	}
+	return p, ErrResolveRecursion
+}
@@ -0,0 +1,114 @@
+package namesys
+
+import (
+	"sync/atomic"
+	"time"
+
+	lru "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/hashicorp/golang-lru"
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	path "github.com/ipfs/go-ipfs/path"
+)
+
+// DefaultResolverCacheTTL is how long resolved names are cached for, when
+// their records don't say otherwise.
+const DefaultResolverCacheTTL = time.Minute
+
+// DefaultResolverCacheSize is how many resolved names are cached.
+const DefaultResolverCacheSize = 128
+
+// CacheStats counts the names resolved through a cache.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// CachingNameSystem is a NameSystem keeping the names it resolves in a
+// cache, until the time their records allow them to be cached for is up.
+type CachingNameSystem interface {
+	NameSystem
+
+	// CacheStats returns the counts of the cache.
+	CacheStats() CacheStats
+}
+
+type noCacheKey struct{}
+
+// NoCache returns a context under which names are resolved again, rather
+// than from the cache. The values resolved still update the cache.
+func NoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func useCache(ctx context.Context) bool {
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return !noCache
+}
+
+// cacheTTL returns how long a record of the given ttl, valid until eol,
+// may be cached for. A ttl of zero gives DefaultResolverCacheTTL.
+func cacheTTL(ttl time.Duration, eol time.Time) time.Duration {
+	if ttl <= 0 {
+		ttl = DefaultResolverCacheTTL
+	}
+	if left := eol.Sub(time.Now()); left < ttl {
+		ttl = left
+	}
+	return ttl
+}
+
+type cacheEntry struct {
+	val path.Path
+	eol time.Time
+}
+
+// resolveCache is a cache of the values names resolve to in one step, expiring each on its own
+// time, and leaving out the least recently used past its size.
+type resolveCache struct {
+	// accessed atomically, first to be aligned on 32 bit platforms
+	hits   uint64
+	misses uint64
+
+	entries *lru.Cache
+}
+
+func newResolveCache(size int) *resolveCache {
+	entries, err := lru.New(size)
+	if err != nil {
+		panic(err) // only a size <= 0 is an error
+	}
+	return &resolveCache{entries: entries}
+}
+
+// get returns the value cached for name, if it has not expired.
+func (c *resolveCache) get(name string) (path.Path, bool) {
+	v, ok := c.entries.Get(name)
+	if ok {
+		e := v.(cacheEntry)
+		if time.Now().Before(e.eol) {
+			atomic.AddUint64(&c.hits, 1)
+			return e.val, true
+		}
+		c.entries.Remove(name)
+	}
+	atomic.AddUint64(&c.misses, 1)
+	return "", false
+}
+
+// put caches val for name, for ttl. Values that may not be cached are
+// dropped, along with what was cached for name.
+func (c *resolveCache) put(name string, val path.Path, ttl time.Duration) {
+	if ttl <= 0 {
+		c.entries.Remove(name)
+		return
+	}
+	c.entries.Add(name, cacheEntry{val: val, eol: time.Now().Add(ttl)})
+}
+
+func (c *resolveCache) stats() CacheStats {
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: c.entries.Len(),
+	}
+}
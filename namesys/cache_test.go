@@ -0,0 +1,104 @@
+package namesys
+
+import (
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	path "github.com/ipfs/go-ipfs/path"
+	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
+	u "github.com/ipfs/go-ipfs/util"
+	testutil "github.com/ipfs/go-ipfs/util/testutil"
+)
+
+func TestResolveCache(t *testing.T) {
+	ctx := context.Background()
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	publisher := NewNameSystem(d, ds.NewMapDatastore(), nil)
+	resolver := NewNameSystem(d, ds.NewMapDatastore(), nil)
+
+	privk, pubk, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkhash, err := pubk.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := u.Key(pkhash).Pretty()
+
+	checkStats := func(ns NameSystem, hits, misses uint64, entries int) {
+		stats := ns.(CachingNameSystem).CacheStats()
+		if stats.Hits != hits || stats.Misses != misses || stats.Entries != entries {
+			t.Fatalf("expected %d hits, %d misses and %d entries, got %+v", hits, misses, entries, stats)
+		}
+	}
+	resolve := func(ctx context.Context, ns NameSystem, expected path.Path) {
+		val, err := ns.Resolve(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != expected {
+			t.Fatalf("expected %s, got %s", expected, val)
+		}
+	}
+
+	h1 := path.Path("/ipfs/" + u.Key(u.Hash([]byte("one"))).B58String())
+	if err := publisher.Publish(ctx, privk, h1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the publisher knows the value it published
+	resolve(ctx, publisher, h1)
+	checkStats(publisher, 1, 0, 1)
+
+	resolve(ctx, resolver, h1)
+	resolve(ctx, resolver, h1)
+	checkStats(resolver, 1, 1, 1)
+
+	h2 := path.Path("/ipfs/" + u.Key(u.Hash([]byte("two"))).B58String())
+	if err := publisher.Publish(ctx, privk, h2); err != nil {
+		t.Fatal(err)
+	}
+	resolve(ctx, publisher, h2)
+
+	// the resolver keeps the value cached, until asked not to
+	resolve(ctx, resolver, h1)
+	resolve(NoCache(ctx), resolver, h2)
+	resolve(ctx, resolver, h2)
+	checkStats(resolver, 3, 1, 1)
+}
+
+func TestResolveCacheExpiry(t *testing.T) {
+	c := newResolveCache(2)
+
+	c.put("short", "a", time.Millisecond*10)
+	c.put("long", "b", time.Hour)
+	c.put("never", "c", 0)
+	if _, ok := c.get("never"); ok {
+		t.Fatal("values with no ttl should not be cached")
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	if _, ok := c.get("short"); ok {
+		t.Fatal("expected the value to have expired")
+	}
+	if val, ok := c.get("long"); !ok || val != "b" {
+		t.Fatal("expected the value to be cached")
+	}
+
+	// past its size, the least recently used are left out
+	c.put("x", "x", time.Hour)
+	c.put("y", "y", time.Hour)
+	if _, ok := c.get("long"); ok {
+		t.Fatal("expected the value to be left out")
+	}
+
+	if eol := time.Now().Add(time.Second); cacheTTL(time.Hour, eol) > time.Second {
+		t.Fatal("records should not be cached past their EOL")
+	}
+	if ttl := cacheTTL(0, time.Now().Add(time.Hour)); ttl != DefaultResolverCacheTTL {
+		t.Fatalf("expected the default ttl, got %s", ttl)
+	}
+}
@@ -1,20 +1,31 @@
 package namesys
 
 import (
-	"net"
+	"sort"
+	"strings"
+	"time"
 
-	b58 "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-base58"
 	isd "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-is-domain"
-	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 
-	u "github.com/ipfs/go-ipfs/util"
+	path "github.com/ipfs/go-ipfs/path"
 )
 
-// DNSResolver implements a Resolver on DNS domains
+// dnslinkPrefix starts the TXT records that link a domain to a path.
+const dnslinkPrefix = "dnslink="
+
+// DNSResolver implements a Resolver on DNS domains. Names resolved are
+// cached for the ttl of their records, and not at all if it is zero. The
+// resolver of the system does not tell ttls, and its names are cached for
+// DefaultResolverCacheTTL.
 type DNSResolver struct {
-	// TODO: maybe some sort of caching?
-	// cache would need a timeout
+	txt TXTResolver
+}
+
+// NewDNSResolver constructs a name resolver looking up the TXT records of
+// domains with txt. A nil txt uses the resolver of the system.
+func NewDNSResolver(txt TXTResolver) *DNSResolver {
+	return &DNSResolver{txt: txt}
 }
 
 // CanResolve implements Resolver
@@ -22,28 +33,96 @@ func (r *DNSResolver) CanResolve(name string) bool {
 	return isd.IsDomain(name)
 }
 
-// Resolve implements Resolver
-// TXT records for a given domain name should contain a b58
-// encoded multihash.
-func (r *DNSResolver) Resolve(ctx context.Context, name string) (u.Key, error) {
-	log.Info("DNSResolver resolving %v", name)
-	txt, err := net.LookupTXT(name)
+// Resolve implements Resolver.
+func (r *DNSResolver) Resolve(ctx context.Context, name string) (path.Path, error) {
+	return r.ResolveN(ctx, name, DefaultDepthLimit)
+}
+
+// ResolveN implements Resolver.
+func (r *DNSResolver) ResolveN(ctx context.Context, name string, depth int) (path.Path, error) {
+	return resolve(ctx, r, name, depth)
+}
+
+type lookupResult struct {
+	path path.Path
+	ttl  time.Duration
+	err  error
+}
+
+// resolveOnce implements resolver.
+// The link of a domain is looked up in the TXT records of its _dnslink
+// subdomain, and in its own if there is none there. Both are looked up at
+// once.
+func (r *DNSResolver) resolveOnce(ctx context.Context, name string) (path.Path, time.Duration, error) {
+	log.Infof("DNSResolver resolving %v", name)
+
+	subdomain := make(chan lookupResult, 1)
+	go func() {
+		p, ttl, err := r.lookupLink(ctx, "_dnslink."+name)
+		subdomain <- lookupResult{p, ttl, err}
+	}()
+	domain := make(chan lookupResult, 1)
+	go func() {
+		p, ttl, err := r.lookupLink(ctx, name)
+		domain <- lookupResult{p, ttl, err}
+	}()
+
+	res := <-subdomain
+	if res.err != nil {
+		res = <-domain
+	}
+	if res.err != nil {
+		return "", 0, res.err
+	}
+	return res.path, res.ttl, nil
+}
+
+// lookupLink returns the link found in the TXT records of domain.
+func (r *DNSResolver) lookupLink(ctx context.Context, domain string) (path.Path, time.Duration, error) {
+	txt := r.txt
+	if txt == nil {
+		txt = systemResolver{}
+	}
+	records, ttl, err := txt.LookupTXT(ctx, domain)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	for _, t := range txt {
-		chk := b58.Decode(t)
-		if len(chk) == 0 {
-			continue
-		}
+	p, err := parseDNSLinks(records)
+	if err != nil {
+		return "", 0, err
+	}
+	return p, ttl, nil
+}
 
-		_, err := mh.Cast(chk)
-		if err != nil {
-			continue
+// parseDNSLinks returns the link held by the given TXT records. A record
+// like "dnslink=/ipfs/<hash>" holds an /ipfs/ or /ipns/ path. Records
+// holding just the path, or the b58 encoded multihash of an ipfs object,
+// are read too, but only if there are no dnslink= records. So that the
+// same records always give the same link, whatever order they come in,
+// the lowest of several links is chosen.
+func parseDNSLinks(records []string) (path.Path, error) {
+	var links, bare []string
+	for _, t := range records {
+		t = strings.TrimSpace(t)
+		if strings.HasPrefix(t, dnslinkPrefix) {
+			if p, err := path.ParsePath(strings.TrimPrefix(t, dnslinkPrefix)); err == nil {
+				links = append(links, p.String())
+			}
+		} else if p, err := path.ParsePath(t); err == nil {
+			bare = append(bare, p.String())
 		}
-		return u.Key(chk), nil
+	}
+	if len(links) == 0 {
+		links = bare
+	}
+	if len(links) == 0 {
+		return "", ErrResolveFailed
 	}
 
-	return "", ErrResolveFailed
+	sort.Strings(links)
+	if len(links) > 1 && links[0] != links[len(links)-1] {
+		log.Warningf("found %d different dns links, using %s", len(links), links[0])
+	}
+	return path.Path(links[0]), nil
 }
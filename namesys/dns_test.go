@@ -0,0 +1,334 @@
+package namesys
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	path "github.com/ipfs/go-ipfs/path"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+// mockTXT answers with the records of a name, which, as with the system
+// resolver, are cached for DefaultResolverCacheTTL.
+type mockTXT map[string][]string
+
+func (m mockTXT) LookupTXT(ctx context.Context, name string) ([]string, time.Duration, error) {
+	txt, ok := m[name]
+	if !ok {
+		return nil, 0, errNoSuchDomain
+	}
+	return txt, DefaultResolverCacheTTL, nil
+}
+
+func TestDNSResolve(t *testing.T) {
+	a := u.Key(u.Hash([]byte("a"))).B58String()
+	b := u.Key(u.Hash([]byte("b"))).B58String()
+	c := u.Key(u.Hash([]byte("c"))).B58String()
+
+	// of several dnslink= records, the lowest is used
+	lowest := "/ipfs/" + a
+	if "/ipfs/"+c < lowest {
+		lowest = "/ipfs/" + c
+	}
+
+	r := NewDNSResolver(mockTXT{
+		"_dnslink.sub.example.com":  {"dnslink=/ipfs/" + a},
+		"sub.example.com":           {"dnslink=/ipfs/" + b},
+		"bare.example.com":          {"v=spf1 -all", "dnslink=/ipfs/" + b},
+		"legacy.example.com":        {c},
+		"multi.example.com":         {"dnslink=/ipfs/" + c, "dnslink=/ipfs/" + a, "/ipfs/" + b},
+		"multi2.example.com":        {"/ipfs/" + b, "dnslink=/ipfs/" + a, "dnslink=/ipfs/" + c},
+		"_dnslink.junk.example.com": {"dnslink=junk"},
+		"junk.example.com":          {"dnslink=/ipns/sub.example.com"},
+		"bad.example.com":           {"dnslink=/foo/" + a, "not a link"},
+	})
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"sub.example.com", "/ipfs/" + a},
+		{"bare.example.com", "/ipfs/" + b},
+		{"legacy.example.com", "/ipfs/" + c},
+		{"multi.example.com", lowest},
+		{"multi2.example.com", lowest},
+		{"junk.example.com", "/ipns/sub.example.com"},
+	}
+	for _, test := range tests {
+		p, ttl, err := r.resolveOnce(context.Background(), test.name)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if p != path.Path(test.expected) {
+			t.Fatalf("%s: expected %s, got %s", test.name, test.expected, p)
+		}
+		if ttl != DefaultResolverCacheTTL {
+			t.Fatalf("%s: expected a ttl of %s, got %s", test.name, DefaultResolverCacheTTL, ttl)
+		}
+	}
+
+	if _, _, err := r.resolveOnce(context.Background(), "bad.example.com"); err != ErrResolveFailed {
+		t.Fatalf("expected %s, got %v", ErrResolveFailed, err)
+	}
+	if _, _, err := r.resolveOnce(context.Background(), "none.example.com"); err == nil {
+		t.Fatal("expected an error for a domain without records")
+	}
+}
+
+// dnsStandIn answers the TXT queries it is sent from records, by name.
+// Over udp, answers longer than 512 bytes are truncated.
+type dnsStandIn struct {
+	records map[string][]string
+	ttl     uint32
+	tcp     int32 // queries answered over tcp
+}
+
+func (s *dnsStandIn) answer(query []byte, udp bool) []byte {
+	var labels []string
+	off := dnsHeaderLen
+	for off < len(query) && query[off] != 0 {
+		n := int(query[off])
+		labels = append(labels, string(query[off+1:off+1+n]))
+		off += 1 + n
+	}
+	off += 5 // the root label, type and class
+
+	resp := append([]byte(nil), query[:off]...)
+	binary.BigEndian.PutUint16(resp[6:], 0)
+	binary.BigEndian.PutUint16(resp[10:], 0)
+
+	txts, ok := s.records[strings.Join(labels, ".")]
+	if !ok {
+		binary.BigEndian.PutUint16(resp[2:], dnsFlagQR|dnsFlagRD|dnsRcodeNameError)
+		return resp
+	}
+	binary.BigEndian.PutUint16(resp[2:], dnsFlagQR|dnsFlagRD)
+	binary.BigEndian.PutUint16(resp[6:], uint16(len(txts)))
+
+	full := resp
+	for _, txt := range txts {
+		var rdata []byte
+		for len(txt) > 0 {
+			n := len(txt)
+			if n > 255 {
+				n = 255
+			}
+			rdata = append(rdata, byte(n))
+			rdata = append(rdata, txt[:n]...)
+			txt = txt[n:]
+		}
+		full = append(full, 0xC0, dnsHeaderLen) // the name of the question
+		full = appendUint16(full, dnsTypeTXT)
+		full = appendUint16(full, dnsClassINET)
+		full = appendUint16(full, uint16(s.ttl>>16))
+		full = appendUint16(full, uint16(s.ttl))
+		full = appendUint16(full, uint16(len(rdata)))
+		full = append(full, rdata...)
+	}
+
+	if udp && len(full) > 512 {
+		binary.BigEndian.PutUint16(resp[2:], dnsFlagQR|dnsFlagRD|dnsFlagTC)
+		binary.BigEndian.PutUint16(resp[6:], 0)
+		return resp
+	}
+	return full
+}
+
+// serve answers queries over udp and tcp on the same port, and returns
+// its address.
+func (s *dnsStandIn) serve(t *testing.T) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		t.Skip("cannot listen on tcp next to udp:", err)
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(s.answer(buf[:n], true), addr)
+		}
+	}()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var size [2]byte
+			if _, err := io.ReadFull(c, size[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(size[:]))
+				if _, err := io.ReadFull(c, query); err == nil {
+					atomic.AddInt32(&s.tcp, 1)
+					resp := s.answer(query, false)
+					c.Write(append(appendUint16(nil, uint16(len(resp))), resp...))
+				}
+			}
+			c.Close()
+		}
+	}()
+
+	return pc.LocalAddr().String(), func() {
+		pc.Close()
+		l.Close()
+	}
+}
+
+func TestNameserverResolver(t *testing.T) {
+	a := u.Key(u.Hash([]byte("a"))).B58String()
+	filler := strings.Repeat("x", 300)
+	s := &dnsStandIn{
+		records: map[string][]string{
+			"_dnslink.example.com": {"dnslink=/ipfs/" + a},
+			"big.example.com":      {filler, filler, "dnslink=/ipfs/" + a},
+		},
+		ttl: 300,
+	}
+	addr, stop := s.serve(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	r := NewDNSResolver(NewNameserverResolver(addr))
+	for _, name := range []string{"example.com", "big.example.com"} {
+		p, ttl, err := r.resolveOnce(ctx, name)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if p != path.Path("/ipfs/"+a) {
+			t.Fatalf("%s: expected /ipfs/%s, got %s", name, a, p)
+		}
+		if ttl != time.Second*300 {
+			t.Fatalf("%s: expected the ttl of the records, got %s", name, ttl)
+		}
+	}
+	if n := atomic.LoadInt32(&s.tcp); n == 0 {
+		t.Fatal("truncated answers should be queried again over tcp")
+	}
+
+	_, _, err := NewNameserverResolver(addr).LookupTXT(ctx, "none.example.com")
+	if err != errNoSuchDomain {
+		t.Fatalf("expected %s, got %v", errNoSuchDomain, err)
+	}
+
+	// records with a ttl of zero are not cached
+	zero := &dnsStandIn{records: s.records}
+	addr, stop = zero.serve(t)
+	defer stop()
+	ns := NewNameSystem(nil, nil, NewNameserverResolver(addr)).(*ipns)
+	if _, err := ns.Resolve(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ns.cache.get("example.com"); ok {
+		t.Fatal("a name with a ttl of zero was cached")
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	a := u.Key(u.Hash([]byte("a"))).B58String()
+	s := &dnsStandIn{
+		records: map[string][]string{"example.com": {"dnslink=/ipfs/" + a}},
+		ttl:     60,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" || req.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		query, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(s.answer(query, false))
+	}))
+	defer server.Close()
+
+	p, ttl, err := NewDNSResolver(NewDoHResolver(server.URL)).resolveOnce(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != path.Path("/ipfs/"+a) || ttl != time.Minute {
+		t.Fatalf("expected /ipfs/%s for a minute, got %s for %s", a, p, ttl)
+	}
+
+	// queries end when their context is cancelled
+	hang := make(chan struct{})
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-hang
+	}))
+	defer stuck.Close()
+	defer close(hang)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := NewDoHResolver(stuck.URL).LookupTXT(ctx, "example.com")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error from a cancelled query")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("cancelled query did not return")
+	}
+}
+
+func TestParseTXTResolver(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected TXTResolver
+	}{
+		{"", nil},
+		{"10.0.0.1", &nameserverResolver{addr: "10.0.0.1:53"}},
+		{"ns.example.com:5353", &nameserverResolver{addr: "ns.example.com:5353"}},
+		{"::1", &nameserverResolver{addr: "[::1]:53"}},
+		{"https://example.com/dns-query", &dohResolver{url: "https://example.com/dns-query"}},
+	}
+	for _, test := range tests {
+		r, err := ParseTXTResolver(test.addr)
+		if err != nil {
+			t.Fatalf("%q: %s", test.addr, err)
+		}
+		switch expected := test.expected.(type) {
+		case nil:
+			if r != nil {
+				t.Fatalf("%q: expected the system resolver, got %#v", test.addr, r)
+			}
+		case *nameserverResolver:
+			if ns, ok := r.(*nameserverResolver); !ok || *ns != *expected {
+				t.Fatalf("%q: expected %#v, got %#v", test.addr, expected, r)
+			}
+		case *dohResolver:
+			if doh, ok := r.(*dohResolver); !ok || *doh != *expected {
+				t.Fatalf("%q: expected %#v, got %#v", test.addr, expected, r)
+			}
+		}
+	}
+
+	if _, err := ParseTXTResolver("tls://example.com"); err == nil {
+		t.Fatal("expected an error for an unsupported resolver")
+	}
+}
@@ -0,0 +1,165 @@
+package namesys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The few parts of the DNS wire format (RFC 1035) needed to look up TXT
+// records from a nameserver of our choosing.
+
+const (
+	dnsHeaderLen = 12
+
+	dnsTypeTXT   = 16
+	dnsTypeOPT   = 41
+	dnsClassINET = 1
+
+	dnsFlagQR = 1 << 15 // the message is a response
+	dnsFlagTC = 1 << 9  // the response was truncated
+	dnsFlagRD = 1 << 8  // recursion is desired
+
+	dnsRcodeNameError = 3
+
+	// dnsUDPSize is the size of the udp responses we tell nameservers we
+	// can take, in an EDNS0 OPT record.
+	dnsUDPSize = 4096
+)
+
+var (
+	errDNSTruncated = errors.New("dns response truncated")
+	errDNSMalformed = errors.New("malformed dns message")
+
+	// errNoSuchDomain is returned when the nameserver says the name
+	// does not exist.
+	errNoSuchDomain = errors.New("no such domain")
+)
+
+// packTXTQuery returns the message asking for the TXT records of name.
+func packTXTQuery(id uint16, name string) ([]byte, error) {
+	msg := make([]byte, dnsHeaderLen, 512)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], dnsFlagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1)  // questions
+	binary.BigEndian.PutUint16(msg[10:], 1) // additional records
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("invalid domain name %q", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = appendUint16(msg, dnsTypeTXT)
+	msg = appendUint16(msg, dnsClassINET)
+
+	// the OPT record: root name, type, udp size as class, no ttl or data
+	msg = append(msg, 0)
+	msg = appendUint16(msg, dnsTypeOPT)
+	msg = appendUint16(msg, dnsUDPSize)
+	msg = append(msg, 0, 0, 0, 0, 0, 0)
+	return msg, nil
+}
+
+// unpackTXTResponse returns the TXT records of the response to the query
+// of the given id, and the lowest of their ttls. The strings of each
+// record are joined, as net.LookupTXT does.
+func unpackTXTResponse(id uint16, msg []byte) ([]string, time.Duration, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, 0, errDNSMalformed
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, 0, errors.New("dns response does not match the query")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&dnsFlagQR == 0 {
+		return nil, 0, errDNSMalformed
+	}
+	if flags&dnsFlagTC != 0 {
+		return nil, 0, errDNSTruncated
+	}
+	switch rcode := flags & 0xF; rcode {
+	case 0:
+	case dnsRcodeNameError:
+		return nil, 0, errNoSuchDomain
+	default:
+		return nil, 0, fmt.Errorf("dns server failure (rcode %d)", rcode)
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answers := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := dnsHeaderLen
+	for i := 0; i < questions; i++ {
+		off = skipDNSName(msg, off)
+		if off < 0 || off+4 > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		off += 4 // type and class
+	}
+
+	var txts []string
+	var ttl time.Duration
+	for i := 0; i < answers; i++ {
+		off = skipDNSName(msg, off)
+		if off < 0 || off+10 > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		rrttl := time.Duration(binary.BigEndian.Uint32(msg[off+4:])) * time.Second
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		rdata := msg[off : off+rdlen]
+		off += rdlen
+
+		// other answers, like the CNAMEs leading to the records, are
+		// of no use to us
+		if typ != dnsTypeTXT {
+			continue
+		}
+
+		var txt []byte
+		for len(rdata) > 0 {
+			n := int(rdata[0])
+			if 1+n > len(rdata) {
+				return nil, 0, errDNSMalformed
+			}
+			txt = append(txt, rdata[1:1+n]...)
+			rdata = rdata[1+n:]
+		}
+		txts = append(txts, string(txt))
+		if len(txts) == 1 || rrttl < ttl {
+			ttl = rrttl
+		}
+	}
+	return txts, ttl, nil
+}
+
+// skipDNSName returns the offset past the name at off, or -1 if the name
+// runs past the message.
+func skipDNSName(msg []byte, off int) int {
+	for off >= 0 && off < len(msg) {
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1
+		case n&0xC0 == 0xC0:
+			// a pointer to the rest of the name ends it
+			return off + 2
+		default:
+			off += 1 + n
+		}
+	}
+	return -1
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
@@ -3,10 +3,22 @@ package namesys
 
 import (
 	"errors"
+	"time"
 
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
-	u "github.com/ipfs/go-ipfs/util"
+	path "github.com/ipfs/go-ipfs/path"
+)
+
+const (
+	// DefaultDepthLimit is the default depth limit used by Resolve.
+	DefaultDepthLimit = 32
+
+	// UnlimitedDepth allows infinite recursion in ResolveN. You probably
+	// don't want to use this, but it's here if you absolutely trust resolution
+	// to eventually complete and can't put an upper limit on how many
+	// steps it will take.
+	UnlimitedDepth = 0
 )
 
 // ErrResolveFailed signals an error when attempting to resolve.
@@ -15,6 +27,13 @@ var ErrResolveFailed = errors.New("could not resolve name.")
 // ErrPublishFailed signals an error when attempting to publish.
 var ErrPublishFailed = errors.New("could not publish name.")
 
+// ErrResolveRecursion signals a recursion-depth limit.
+var ErrResolveRecursion = errors.New("could not resolve name (recursion limit exceeded).")
+
+// ErrResolveCycle signals a name that resolves, through other names, to
+// itself.
+var ErrResolveCycle = errors.New("could not resolve name (names resolve to each other).")
+
 // Namesys represents a cohesive name publishing and resolving system.
 //
 // Publishing a name is the process of establishing a mapping, a key-value
@@ -30,17 +49,49 @@ type NameSystem interface {
 // Resolver is an object capable of resolving names.
 type Resolver interface {
 
-	// Resolve looks up a name, and returns the value previously published.
-	Resolve(ctx context.Context, name string) (value u.Key, err error)
+	// Resolve performs a recursive lookup, returning the dereferenced
+	// path. For example, if ipfs.io has a DNS TXT record pointing to
+	//   /ipns/QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+	// and there is a DHT IPNS entry for
+	//   QmatmE9msSfkKxoffpHwNLNKgwZG8eT9Bud6YoPab52vpy
+	//   -> /ipfs/Qmcqtw8FfrVSBaRmbWwHxt3AuySBhJLcvmFYi3Lbc4xnwj
+	// then
+	//   Resolve(ctx, "/ipns/ipfs.io")
+	// will resolve both names, returning
+	//   /ipfs/Qmcqtw8FfrVSBaRmbWwHxt3AuySBhJLcvmFYi3Lbc4xnwj
+	//
+	// There is a default depth-limit to avoid infinite recursion. Most
+	// users will be fine with this default limit, but if you need to
+	// adjust the limit you can use ResolveN.
+	Resolve(ctx context.Context, name string) (value path.Path, err error)
+
+	// ResolveN performs a recursive lookup, returning the dereferenced
+	// path. The only difference from Resolve is that the depth limit
+	// is configurable. You can use DefaultDepthLimit, UnlimitedDepth,
+	// or a depth limit of your own choosing.
+	//
+	// When the limit is reached on a name that resolves further, the path
+	// resolved so far is returned along with ErrResolveRecursion. Names
+	// that resolve to themselves fail with ErrResolveCycle.
+	ResolveN(ctx context.Context, name string, depth int) (value path.Path, err error)
 
 	// CanResolve checks whether this Resolver can resolve a name
 	CanResolve(name string) bool
 }
 
+// DefaultRecordLifetime is how long published records are valid for,
+// unless told otherwise.
+const DefaultRecordLifetime = time.Hour * 24
+
 // Publisher is an object capable of publishing particular names.
 type Publisher interface {
 
-	// Publish establishes a name-value mapping.
+	// Publish establishes a name-value mapping. The value is an /ipfs/
+	// or /ipns/ path.
 	// TODO make this not PrivKey specific.
-	Publish(ctx context.Context, name ci.PrivKey, value u.Key) error
+	Publish(ctx context.Context, name ci.PrivKey, value path.Path) error
+
+	// PublishWithEOL establishes a name-value mapping valid until eol,
+	// which resolvers may cache for ttl. A ttl of zero leaves it to them.
+	PublishWithEOL(ctx context.Context, name ci.PrivKey, value path.Path, eol time.Time, ttl time.Duration) error
 }
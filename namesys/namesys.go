@@ -1,8 +1,13 @@
 package namesys
 
 import (
+	"strings"
+	"time"
+
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	routing "github.com/ipfs/go-ipfs/routing"
 	u "github.com/ipfs/go-ipfs/util"
 )
@@ -16,35 +21,65 @@ import (
 //
 // It can only publish to: (a) ipfs routing naming.
 //
+// Names may resolve to names of any of them in turn, which are resolved
+// as well. Each step is cached, for as long as its record allows.
+//
 type ipns struct {
-	resolvers []Resolver
+	resolvers []resolver
 	publisher Publisher
+	cache     *resolveCache
 }
 
-// NewNameSystem will construct the IPFS naming system based on Routing
-func NewNameSystem(r routing.IpfsRouting) NameSystem {
+// NewNameSystem will construct the IPFS naming system based on Routing,
+// keeping the records it publishes in dstore, and looking up dns links
+// with txt, or with the system resolver if it is nil.
+func NewNameSystem(r routing.IpfsRouting, dstore ds.Datastore, txt TXTResolver) NameSystem {
 	return &ipns{
-		resolvers: []Resolver{
-			new(DNSResolver),
+		resolvers: []resolver{
+			NewDNSResolver(txt),
 			new(ProquintResolver),
-			NewRoutingResolver(r),
+			&routingResolver{routing: r},
 		},
-		publisher: NewRoutingPublisher(r),
+		publisher: NewRoutingPublisher(r, dstore),
+		cache:     newResolveCache(DefaultResolverCacheSize),
 	}
 }
 
-// Resolve implements Resolver
-func (ns *ipns) Resolve(ctx context.Context, name string) (u.Key, error) {
+// Resolve implements Resolver.
+func (ns *ipns) Resolve(ctx context.Context, name string) (path.Path, error) {
+	return ns.ResolveN(ctx, name, DefaultDepthLimit)
+}
+
+// ResolveN implements Resolver.
+func (ns *ipns) ResolveN(ctx context.Context, name string, depth int) (path.Path, error) {
+	return resolve(ctx, ns, name, depth)
+}
+
+// resolveOnce implements resolver. Names are resolved from the cache,
+// unless ctx comes from NoCache.
+func (ns *ipns) resolveOnce(ctx context.Context, name string) (path.Path, time.Duration, error) {
+	if useCache(ctx) {
+		if val, ok := ns.cache.get(name); ok {
+			return val, 0, nil
+		}
+	}
+
 	for _, r := range ns.resolvers {
 		if r.CanResolve(name) {
-			return r.Resolve(ctx, name)
+			val, ttl, err := r.resolveOnce(ctx, name)
+			if err != nil {
+				return "", 0, err
+			}
+			ns.cache.put(name, val, ttl)
+			return val, ttl, nil
 		}
 	}
-	return "", ErrResolveFailed
+	return "", 0, ErrResolveFailed
 }
 
 // CanResolve implements Resolver
 func (ns *ipns) CanResolve(name string) bool {
+	name = strings.TrimPrefix(name, "/ipns/")
 	for _, r := range ns.resolvers {
 		if r.CanResolve(name) {
 			return true
@@ -54,6 +89,32 @@ func (ns *ipns) CanResolve(name string) bool {
 }
 
 // Publish implements Publisher
-func (ns *ipns) Publish(ctx context.Context, name ci.PrivKey, value u.Key) error {
-	return ns.publisher.Publish(ctx, name, value)
+func (ns *ipns) Publish(ctx context.Context, name ci.PrivKey, value path.Path) error {
+	return ns.PublishWithEOL(ctx, name, value, time.Now().Add(DefaultRecordLifetime), 0)
+}
+
+// PublishWithEOL implements Publisher. The value published replaces the
+// one cached for the name.
+func (ns *ipns) PublishWithEOL(ctx context.Context, name ci.PrivKey, value path.Path, eol time.Time, ttl time.Duration) error {
+	value, err := path.ParsePath(value.String())
+	if err != nil {
+		return err
+	}
+
+	err = ns.publisher.PublishWithEOL(ctx, name, value, eol, ttl)
+	if err != nil {
+		return err
+	}
+
+	h, err := name.GetPublic().Hash()
+	if err != nil {
+		return err
+	}
+	ns.cache.put(u.Key(h).Pretty(), value, cacheTTL(ttl, eol))
+	return nil
+}
+
+// CacheStats implements CachingNameSystem
+func (ns *ipns) CacheStats() CacheStats {
+	return ns.cache.stats()
 }
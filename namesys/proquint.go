@@ -2,9 +2,11 @@ package namesys
 
 import (
 	"errors"
+	"time"
 
 	proquint "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/bren2010/proquint"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	path "github.com/ipfs/go-ipfs/path"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
@@ -16,11 +18,22 @@ func (r *ProquintResolver) CanResolve(name string) bool {
 	return err == nil && ok
 }
 
-// Resolve implements Resolver. Decodes the proquint string.
-func (r *ProquintResolver) Resolve(ctx context.Context, name string) (u.Key, error) {
+// Resolve implements Resolver.
+func (r *ProquintResolver) Resolve(ctx context.Context, name string) (path.Path, error) {
+	return r.ResolveN(ctx, name, DefaultDepthLimit)
+}
+
+// ResolveN implements Resolver.
+func (r *ProquintResolver) ResolveN(ctx context.Context, name string, depth int) (path.Path, error) {
+	return resolve(ctx, r, name, depth)
+}
+
+// resolveOnce implements resolver. Decodes the proquint string to the
+// /ipfs/ path of the key it encodes.
+func (r *ProquintResolver) resolveOnce(ctx context.Context, name string) (path.Path, time.Duration, error) {
 	ok := r.CanResolve(name)
 	if !ok {
-		return "", errors.New("not a valid proquint string")
+		return "", 0, errors.New("not a valid proquint string")
 	}
-	return u.Key(proquint.Decode(name)), nil
+	return path.Path("/ipfs/" + u.Key(proquint.Decode(name)).B58String()), DefaultResolverCacheTTL, nil
 }
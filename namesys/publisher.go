@@ -2,17 +2,21 @@ package namesys
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 
 	dag "github.com/ipfs/go-ipfs/merkledag"
 	pb "github.com/ipfs/go-ipfs/namesys/internal/pb"
 	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
 	pin "github.com/ipfs/go-ipfs/pin"
 	routing "github.com/ipfs/go-ipfs/routing"
 	record "github.com/ipfs/go-ipfs/routing/record"
@@ -28,51 +32,88 @@ var ErrExpiredRecord = errors.New("expired record")
 // unknown validity type.
 var ErrUnrecognizedValidity = errors.New("unrecognized validity type")
 
+// ErrSignature is returned when an ipns record is not signed by the
+// key of its name.
+var ErrSignature = errors.New("record signature verification failed")
+
+// publishedPrefix is where the last record published for each name is
+// kept in the datastore, for it to be republished.
+var publishedPrefix = ds.NewKey("/local/ipns/published")
+
 // ipnsPublisher is capable of publishing and resolving names to the IPFS
 // routing system.
 type ipnsPublisher struct {
 	routing routing.IpfsRouting
+	ds      ds.Datastore
 }
 
-// NewRoutingPublisher constructs a publisher for the IPFS Routing name system.
-func NewRoutingPublisher(route routing.IpfsRouting) Publisher {
-	return &ipnsPublisher{routing: route}
+// NewRoutingPublisher constructs a publisher for the IPFS Routing name
+// system, keeping the last record it published for each name in dstore.
+func NewRoutingPublisher(route routing.IpfsRouting, dstore ds.Datastore) Publisher {
+	return &ipnsPublisher{routing: route, ds: dstore}
 }
 
 // Publish implements Publisher. Accepts a keypair and a value,
 // and publishes it out to the routing system
-func (p *ipnsPublisher) Publish(ctx context.Context, k ci.PrivKey, value u.Key) error {
+func (p *ipnsPublisher) Publish(ctx context.Context, k ci.PrivKey, value path.Path) error {
+	return p.PublishWithEOL(ctx, k, value, time.Now().Add(DefaultRecordLifetime), 0)
+}
+
+// PublishWithEOL implements Publisher. The record published follows the
+// last one published here, or found for the name, by its sequence number.
+func (p *ipnsPublisher) PublishWithEOL(ctx context.Context, k ci.PrivKey, value path.Path, eol time.Time, ttl time.Duration) error {
 	log.Debugf("namesys: Publish %s", value)
 
-	// validate `value` is a ref (multihash)
-	_, err := mh.FromB58String(value.Pretty())
+	// validate `value` is an /ipfs/ or /ipns/ path
+	value, err := path.ParsePath(value.String())
 	if err != nil {
-		return fmt.Errorf("publish value must be str multihash. %v", err)
+		return fmt.Errorf("publish value must be an ipfs or ipns path. %v", err)
 	}
 
-	data, err := createRoutingEntryData(k, value)
-	if err != nil {
+	var seq uint64
+	if last, err := p.lastPublished(k.GetPublic()); err != nil {
 		return err
+	} else if last != nil {
+		seq = last.GetSequence()
+	}
+	if last := p.lastEntry(ctx, k.GetPublic()); last != nil && last.GetSequence() > seq {
+		seq = last.GetSequence()
 	}
-	pubkey := k.GetPublic()
-	pkbytes, err := pubkey.Bytes()
+	return p.put(ctx, k, value, seq+1, eol, ttl)
+}
+
+// put stores the record of value, and the public key it is signed with,
+// in the routing system. The record is kept in the datastore first, so
+// that its sequence number is never used again.
+func (p *ipnsPublisher) put(ctx context.Context, k ci.PrivKey, value path.Path, seq uint64, eol time.Time, ttl time.Duration) error {
+	pkbytes, err := k.GetPublic().Bytes()
 	if err != nil {
 		return err
 	}
 
 	nameb := u.Hash(pkbytes)
 	namekey := u.Key("/pk/" + string(nameb))
+	ipnskey := u.Key("/ipns/" + string(nameb))
+
+	data, err := createRoutingEntryData(k, value, seq, eol, ttl)
+	if err != nil {
+		return err
+	}
+
+	err = p.ds.Put(publishedKey(nameb), data)
+	if err != nil {
+		return err
+	}
 
 	log.Debugf("Storing pubkey at: %s", namekey)
-	// Store associated public key
+	// Store associated public key, for resolvers that don't read it
+	// from the record
 	timectx, _ := context.WithDeadline(ctx, time.Now().Add(time.Second*10))
 	err = p.routing.PutValue(timectx, namekey, pkbytes)
 	if err != nil {
 		return err
 	}
 
-	ipnskey := u.Key("/ipns/" + string(nameb))
-
 	log.Debugf("Storing ipns entry at: %s", ipnskey)
 	// Store ipns entry at "/ipns/"+b58(h(pubkey))
 	timectx, _ = context.WithDeadline(ctx, time.Now().Add(time.Second*10))
@@ -84,13 +125,117 @@ func (p *ipnsPublisher) Publish(ctx context.Context, k ci.PrivKey, value u.Key)
 	return nil
 }
 
-func createRoutingEntryData(pk ci.PrivKey, val u.Key) ([]byte, error) {
+// lastEntry returns the last record published for pubkey, or nil if none
+// is found. Records not signed by pubkey are not trusted.
+func (p *ipnsPublisher) lastEntry(ctx context.Context, pubkey ci.PubKey) *pb.IpnsEntry {
+	pkhash, err := pubkey.Hash()
+	if err != nil {
+		return nil
+	}
+	ipnskey := u.Key("/ipns/" + string(pkhash))
+
+	timectx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	val, err := p.routing.GetValue(timectx, ipnskey)
+	if err != nil {
+		log.Debugf("no previous record for %s: %s", ipnskey, err)
+		return nil
+	}
+
+	entry := new(pb.IpnsEntry)
+	if err := proto.Unmarshal(val, entry); err != nil {
+		return nil
+	}
+	if ok, err := pubkey.Verify(ipnsEntryDataForSig(entry), entry.GetSignature()); err != nil || !ok {
+		return nil
+	}
+	return entry
+}
+
+// publishedKey returns the key of the datastore under which the last
+// record published for the name of pkhash is kept.
+func publishedKey(pkhash []byte) ds.Key {
+	return publishedPrefix.ChildString(u.Key(pkhash).B58String())
+}
+
+// lastPublished returns the last record published here for pubkey, or nil
+// if there is none.
+func (p *ipnsPublisher) lastPublished(pubkey ci.PubKey) (*pb.IpnsEntry, error) {
+	pkhash, err := pubkey.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := p.ds.Get(publishedKey(pkhash))
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := val.([]byte)
+	if !ok {
+		return nil, u.ErrCast()
+	}
+
+	entry := new(pb.IpnsEntry)
+	if err := proto.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Republish publishes the value of the last record of k published through
+// dstore again, valid until eol, or until the record was if that is later.
+// The record keeps its ttl. Names published before their records were
+// kept are republished from the record found for them, while it lasts.
+// ErrResolveFailed is returned if k has no record to republish.
+func Republish(ctx context.Context, r routing.IpfsRouting, dstore ds.Datastore, k ci.PrivKey, eol time.Time) (path.Path, error) {
+	p := &ipnsPublisher{routing: r, ds: dstore}
+	last, err := p.lastPublished(k.GetPublic())
+	if err != nil {
+		return "", err
+	}
+	if last == nil {
+		last = p.lastEntry(ctx, k.GetPublic())
+	}
+	if last == nil {
+		return "", ErrResolveFailed
+	}
+
+	value, err := entryValuePath(last.GetValue())
+	if err != nil {
+		return "", err
+	}
+	if prev, err := u.ParseRFC3339(string(last.GetValidity())); err == nil && prev.After(eol) {
+		eol = prev
+	}
+
+	err = p.put(ctx, k, value, last.GetSequence()+1, eol, time.Duration(last.GetTtl()))
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func createRoutingEntryData(pk ci.PrivKey, val path.Path, seq uint64, eol time.Time, ttl time.Duration) ([]byte, error) {
+	pkbytes, err := pk.GetPublic().Bytes()
+	if err != nil {
+		return nil, err
+	}
+
 	entry := new(pb.IpnsEntry)
 
 	entry.Value = []byte(val)
+	entry.PubKey = pkbytes
 	typ := pb.IpnsEntry_EOL
 	entry.ValidityType = &typ
-	entry.Validity = []byte(u.FormatRFC3339(time.Now().Add(time.Hour * 24)))
+	entry.Validity = []byte(u.FormatRFC3339(eol))
+	entry.Sequence = proto.Uint64(seq)
+	if ttl > 0 {
+		entry.Ttl = proto.Uint64(uint64(ttl.Nanoseconds()))
+	}
 
 	sig, err := pk.Sign(ipnsEntryDataForSig(entry))
 	if err != nil {
@@ -100,13 +245,63 @@ func createRoutingEntryData(pk ci.PrivKey, val u.Key) ([]byte, error) {
 	return proto.Marshal(entry)
 }
 
+// entryValuePath returns the path held by the value of an ipns entry.
+// Entries of older publishers hold the bare multihash of an ipfs object.
+func entryValuePath(val []byte) (path.Path, error) {
+	if bytes.HasPrefix(val, []byte("/")) {
+		return path.ParsePath(string(val))
+	}
+	h, err := mh.Cast(val)
+	if err != nil {
+		return "", err
+	}
+	return path.Path("/ipfs/" + h.B58String()), nil
+}
+
+// entryPublicKey returns the public key embedded in entry, once checked
+// to hash to pkhash, the name of the entry. Entries of older publishers
+// embed no key, and nil is returned for them.
+func entryPublicKey(pkhash []byte, entry *pb.IpnsEntry) (ci.PubKey, error) {
+	if entry.PubKey == nil {
+		return nil, nil
+	}
+	if !bytes.Equal(u.Hash(entry.PubKey), pkhash) {
+		return nil, record.ErrPublicKeyMismatch
+	}
+	return ci.UnmarshalPublicKey(entry.PubKey)
+}
+
+// ipnsSigV2 starts the signed data of records with a sequence number or
+// a ttl. Neither the value nor the validity of the records of older
+// publishers, whose signed data they start, begin with a zero byte.
+var ipnsSigV2 = []byte("\x00ipns-signature-v2\x00")
+
+// ipnsEntryDataForSig returns the data of e that is signed. Records with
+// a sequence number or a ttl sign their fields length prefixed, and their
+// numbers as 8 big endian bytes, after ipnsSigV2. Records without them,
+// from older publishers, sign the value, validity and validity type run
+// together, and still verify.
 func ipnsEntryDataForSig(e *pb.IpnsEntry) []byte {
-	return bytes.Join([][]byte{
-		e.Value,
-		e.Validity,
-		[]byte(fmt.Sprint(e.GetValidityType())),
-	},
-		[]byte{})
+	if e.Sequence == nil && e.Ttl == nil {
+		return bytes.Join([][]byte{
+			e.Value,
+			e.Validity,
+			[]byte(fmt.Sprint(e.GetValidityType())),
+		}, []byte{})
+	}
+
+	data := append([]byte(nil), ipnsSigV2...)
+	var n [8]byte
+	for _, field := range [][]byte{e.Value, e.Validity} {
+		binary.BigEndian.PutUint64(n[:], uint64(len(field)))
+		data = append(data, n[:]...)
+		data = append(data, field...)
+	}
+	for _, v := range []uint64{uint64(e.GetValidityType()), e.GetSequence(), e.GetTtl()} {
+		binary.BigEndian.PutUint64(n[:], v)
+		data = append(data, n[:]...)
+	}
+	return data
 }
 
 var IpnsRecordValidator = &record.ValidChecker{
@@ -115,13 +310,20 @@ var IpnsRecordValidator = &record.ValidChecker{
 }
 
 // ValidateIpnsRecord implements ValidatorFunc and verifies that the
-// given 'val' is an IpnsEntry and that that entry is valid.
+// given 'val' is an IpnsEntry and that that entry is valid. Entries that
+// embed the public key of their name must be signed with it. Those of
+// older publishers, which don't, can't be verified here: resolvers check
+// them with the key of the name.
 func ValidateIpnsRecord(k u.Key, val []byte) error {
 	entry := new(pb.IpnsEntry)
 	err := proto.Unmarshal(val, entry)
 	if err != nil {
 		return err
 	}
+	return validateEntry(k, entry)
+}
+
+func validateEntry(k u.Key, entry *pb.IpnsEntry) error {
 	switch entry.GetValidityType() {
 	case pb.IpnsEntry_EOL:
 		t, err := u.ParseRFC3339(string(entry.GetValidity()))
@@ -135,9 +337,71 @@ func ValidateIpnsRecord(k u.Key, val []byte) error {
 	default:
 		return ErrUnrecognizedValidity
 	}
+
+	pkhash := strings.TrimPrefix(string(k), "/ipns/")
+	pubkey, err := entryPublicKey([]byte(pkhash), entry)
+	if err != nil {
+		return err
+	}
+	if pubkey != nil {
+		if ok, err := pubkey.Verify(ipnsEntryDataForSig(entry), entry.GetSignature()); err != nil || !ok {
+			return ErrSignature
+		}
+	}
 	return nil
 }
 
+// LocalPublicKeyFunc returns the public key hashing to pkhash, if it is
+// stored locally, without looking it up in the network.
+type LocalPublicKeyFunc func(pkhash []byte) (ci.PubKey, error)
+
+// NewIpnsSelectorFunc returns a SelectorFunc choosing the record with the
+// highest sequence number, and of those the one valid the longest. Only
+// records whose signature verifies are chosen: with the public key they
+// embed, or else with the one localKey finds for their name. Records
+// that are not valid, can't be read or can't be verified are never
+// chosen, and ErrResolveFailed is returned if none can.
+func NewIpnsSelectorFunc(localKey LocalPublicKeyFunc) record.SelectorFunc {
+	return func(k u.Key, vals [][]byte) (int, error) {
+		pkhash := []byte(strings.TrimPrefix(string(k), "/ipns/"))
+
+		best := -1
+		var bestSeq uint64
+		var bestEOL time.Time
+		for i, val := range vals {
+			entry := new(pb.IpnsEntry)
+			if err := proto.Unmarshal(val, entry); err != nil {
+				continue
+			}
+			if err := validateEntry(k, entry); err != nil {
+				continue
+			}
+			if entry.PubKey == nil {
+				pubkey, err := localKey(pkhash)
+				if err != nil || pubkey == nil {
+					continue
+				}
+				if ok, err := pubkey.Verify(ipnsEntryDataForSig(entry), entry.GetSignature()); err != nil || !ok {
+					continue
+				}
+			}
+			eol, err := u.ParseRFC3339(string(entry.GetValidity()))
+			if err != nil {
+				continue
+			}
+
+			seq := entry.GetSequence()
+			if best < 0 || seq > bestSeq || (seq == bestSeq && eol.After(bestEOL)) {
+				best, bestSeq, bestEOL = i, seq, eol
+			}
+		}
+		if best < 0 {
+			return 0, ErrResolveFailed
+		}
+		return best, nil
+	}
+}
+
 // InitializeKeyspace sets the ipns record for the given key to
 // point to an empty directory.
 // TODO: this doesnt feel like it belongs here
@@ -160,7 +424,7 @@ func InitializeKeyspace(ctx context.Context, ds dag.DAGService, pub Publisher, p
 		return err
 	}
 
-	err = pub.Publish(ctx, key, nodek)
+	err = pub.Publish(ctx, key, path.Path("/ipfs/"+nodek.B58String()))
 	if err != nil {
 		return err
 	}
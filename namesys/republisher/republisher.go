@@ -0,0 +1,167 @@
+// package republisher keeps the ipns names published by a node from
+// expiring, by publishing their records again before they do.
+package republisher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	namesys "github.com/ipfs/go-ipfs/namesys"
+	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	peer "github.com/ipfs/go-ipfs/p2p/peer"
+	path "github.com/ipfs/go-ipfs/path"
+	routing "github.com/ipfs/go-ipfs/routing"
+	eventlog "github.com/ipfs/go-ipfs/thirdparty/eventlog"
+)
+
+var log = eventlog.Logger("ipns-repub")
+
+// DefaultRepublishPeriod is how often names are republished, unless told
+// otherwise. It leaves records published with the default lifetime a
+// few chances to be republished before they expire.
+const DefaultRepublishPeriod = time.Hour * 4
+
+// ErrNotPublished is the error of names that have no record to republish.
+var ErrNotPublished = errors.New("name has not been published")
+
+// Status tells when the name of a key was last republished.
+type Status struct {
+	Name string
+
+	// Value is the path the name was last republished to.
+	Value path.Path `json:",omitempty"`
+
+	// LastRepublish is zero until the name is first republished.
+	LastRepublish time.Time
+
+	// Error is the error of the last attempt, if it failed.
+	Error string `json:",omitempty"`
+}
+
+type entry struct {
+	key    ci.PrivKey
+	status Status
+}
+
+// Republisher republishes the names of the keys it is given, every
+// Period, for RecordLifetime. The records republished are the last ones
+// published through its datastore, even once they expired.
+type Republisher struct {
+	// The routing system to republish records through
+	r routing.IpfsRouting
+	// The datastore the records published are kept in
+	ds ds.Datastore
+
+	Period         time.Duration
+	RecordLifetime time.Duration
+
+	mu      sync.Mutex
+	entries map[peer.ID]*entry
+}
+
+func NewRepublisher(r routing.IpfsRouting, dstore ds.Datastore) *Republisher {
+	return &Republisher{
+		r:              r,
+		ds:             dstore,
+		Period:         DefaultRepublishPeriod,
+		RecordLifetime: namesys.DefaultRecordLifetime,
+		entries:        make(map[peer.ID]*entry),
+	}
+}
+
+// AddKey makes the name of k be republished. Keys already added are
+// left as they are.
+func (rp *Republisher) AddKey(k ci.PrivKey) error {
+	id, err := peer.IDFromPrivateKey(k)
+	if err != nil {
+		return err
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if _, ok := rp.entries[id]; !ok {
+		rp.entries[id] = &entry{key: k, status: Status{Name: id.Pretty()}}
+	}
+	return nil
+}
+
+// RemoveKey stops republishing the name of the key of the given id.
+func (rp *Republisher) RemoveKey(id peer.ID) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	delete(rp.entries, id)
+}
+
+// Run republishes the names every Period, until ctx is done.
+func (rp *Republisher) Run(ctx context.Context) {
+	// like the reprovider, dont republish right away: the daemon may be
+	// shut down as soon as it is started. Shorter periods are kept to.
+	delay := time.Minute
+	if rp.Period < delay {
+		delay = rp.Period
+	}
+	after := time.After(delay)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-after:
+			if err := rp.Republish(ctx); err != nil {
+				log.Debug(err)
+			}
+			after = time.After(rp.Period)
+		}
+	}
+}
+
+// Republish republishes the names of all the keys now, and returns the
+// first error met. Names that have not been published are skipped.
+func (rp *Republisher) Republish(ctx context.Context) error {
+	rp.mu.Lock()
+	entries := make([]*entry, 0, len(rp.entries))
+	for _, e := range rp.entries {
+		entries = append(entries, e)
+	}
+	rp.mu.Unlock()
+
+	var firstErr error
+	for _, e := range entries {
+		eol := time.Now().Add(rp.RecordLifetime)
+		value, err := namesys.Republish(ctx, rp.r, rp.ds, e.key, eol)
+		if err == namesys.ErrResolveFailed {
+			err = ErrNotPublished
+		}
+
+		rp.mu.Lock()
+		if err != nil {
+			e.status.Error = err.Error()
+		} else {
+			e.status.Value = value
+			e.status.LastRepublish = time.Now()
+			e.status.Error = ""
+		}
+		rp.mu.Unlock()
+
+		if err != nil && err != ErrNotPublished {
+			log.Errorf("republishing %s: %s", e.status.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Status returns the status of the name of each key.
+func (rp *Republisher) Status() []Status {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	statuses := make([]Status, 0, len(rp.entries))
+	for _, e := range rp.entries {
+		statuses = append(statuses, e.status)
+	}
+	return statuses
+}
@@ -0,0 +1,121 @@
+package republisher
+
+import (
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	namesys "github.com/ipfs/go-ipfs/namesys"
+	peer "github.com/ipfs/go-ipfs/p2p/peer"
+	path "github.com/ipfs/go-ipfs/path"
+	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
+	u "github.com/ipfs/go-ipfs/util"
+	testutil "github.com/ipfs/go-ipfs/util/testutil"
+)
+
+func TestRepublish(t *testing.T) {
+	ctx := context.Background()
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	dstore := ds.NewMapDatastore()
+	publisher := namesys.NewRoutingPublisher(d, dstore)
+
+	published, _, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpublished, _, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(published)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
+	err = publisher.PublishWithEOL(ctx, published, value, time.Now().Add(time.Millisecond*200), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp := NewRepublisher(d, dstore)
+	rp.RecordLifetime = time.Hour
+	if err := rp.AddKey(published); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.AddKey(unpublished); err != nil {
+		t.Fatal(err)
+	}
+
+	// names that were not published are not an error
+	if err := rp.Republish(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range rp.Status() {
+		if s.Name == id.Pretty() {
+			if s.LastRepublish.IsZero() || s.Value != value || s.Error != "" {
+				t.Fatalf("name was not republished: %+v", s)
+			}
+		} else {
+			if !s.LastRepublish.IsZero() || s.Error != ErrNotPublished.Error() {
+				t.Fatalf("unpublished name should not be republished: %+v", s)
+			}
+		}
+	}
+
+	// the record outlives the lifetime it was first published with
+	time.Sleep(time.Millisecond * 300)
+	resolved, err := namesys.NewRoutingResolver(d).Resolve(ctx, id.Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != value {
+		t.Fatalf("expected %s, got %s", value, resolved)
+	}
+
+	rp.RemoveKey(id)
+	if n := len(rp.Status()); n != 1 {
+		t.Fatalf("expected 1 key left, got %d", n)
+	}
+}
+
+func TestRepublishLost(t *testing.T) {
+	ctx := context.Background()
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	dstore := ds.NewMapDatastore()
+
+	k, _, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
+	err = namesys.NewRoutingPublisher(d, dstore).Publish(ctx, k, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the record is republished from the datastore, to a routing system
+	// that has lost it
+	lost := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	rp := NewRepublisher(lost, dstore)
+	if err := rp.AddKey(k); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.Republish(ctx); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := namesys.NewRoutingResolver(lost).Resolve(ctx, id.Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != value {
+		t.Fatalf("expected %s, got %s", value, resolved)
+	}
+}
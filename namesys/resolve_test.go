@@ -1,10 +1,19 @@
 package namesys
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	pb "github.com/ipfs/go-ipfs/namesys/internal/pb"
+	ci "github.com/ipfs/go-ipfs/p2p/crypto"
+	path "github.com/ipfs/go-ipfs/path"
+	routing "github.com/ipfs/go-ipfs/routing"
 	mockrouting "github.com/ipfs/go-ipfs/routing/mock"
+	record "github.com/ipfs/go-ipfs/routing/record"
 	u "github.com/ipfs/go-ipfs/util"
 	testutil "github.com/ipfs/go-ipfs/util/testutil"
 )
@@ -13,7 +22,7 @@ func TestRoutingResolve(t *testing.T) {
 	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
 
 	resolver := NewRoutingResolver(d)
-	publisher := NewRoutingPublisher(d)
+	publisher := NewRoutingPublisher(d, ds.NewMapDatastore())
 
 	privk, pubk, err := testutil.RandTestKeyPair(512)
 	if err != nil {
@@ -22,10 +31,10 @@ func TestRoutingResolve(t *testing.T) {
 
 	err = publisher.Publish(context.Background(), privk, "Hello")
 	if err == nil {
-		t.Fatal("should have errored out when publishing a non-multihash val")
+		t.Fatal("should have errored out when publishing a non-path val")
 	}
 
-	h := u.Key(u.Hash([]byte("Hello")))
+	h := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
 	err = publisher.Publish(context.Background(), privk, h)
 	if err != nil {
 		t.Fatal(err)
@@ -46,3 +55,312 @@ func TestRoutingResolve(t *testing.T) {
 		t.Fatal("Got back incorrect value.")
 	}
 }
+
+func TestPublishSequence(t *testing.T) {
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	publisher := NewRoutingPublisher(d, ds.NewMapDatastore())
+
+	privk, pubk, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkb, err := pubk.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipnskey := u.Key("/ipns/" + string(u.Hash(pubkb)))
+
+	h := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
+	for seq := uint64(1); seq <= 3; seq++ {
+		err = publisher.PublishWithEOL(context.Background(), privk, h, time.Now().Add(time.Hour), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		val, err := d.GetValue(context.Background(), ipnskey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := new(pb.IpnsEntry)
+		if err := proto.Unmarshal(val, entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.GetSequence() != seq {
+			t.Fatalf("expected sequence %d, got %d", seq, entry.GetSequence())
+		}
+		if time.Duration(entry.GetTtl()) != time.Minute {
+			t.Fatalf("expected a ttl of %s, got %s", time.Minute, time.Duration(entry.GetTtl()))
+		}
+		if ok, err := pubk.Verify(ipnsEntryDataForSig(entry), entry.GetSignature()); err != nil || !ok {
+			t.Fatal("record signature does not verify")
+		}
+	}
+}
+
+func TestIpnsSelectorFunc(t *testing.T) {
+	privk, pubk, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkhash, err := pubk.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipnskey := u.Key("/ipns/" + string(pkhash))
+	h := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
+
+	recordBy := func(k ci.PrivKey, seq uint64, eol time.Time) []byte {
+		data, err := createRoutingEntryData(k, h, seq, eol, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	record := func(seq uint64, eol time.Time) []byte {
+		return recordBy(privk, seq, eol)
+	}
+	// keyless is a record without the key of its name, as older
+	// publishers made them
+	keyless := func(k ci.PrivKey, seq uint64) []byte {
+		entry := new(pb.IpnsEntry)
+		if err := proto.Unmarshal(recordBy(k, seq, time.Now().Add(time.Hour)), entry); err != nil {
+			t.Fatal(err)
+		}
+		entry.PubKey = nil
+		if entry.Signature, err = k.Sign(ipnsEntryDataForSig(entry)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := proto.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	now := time.Now()
+
+	tests := []struct {
+		vals [][]byte
+		best int
+	}{
+		{[][]byte{record(1, now.Add(time.Hour)), record(2, now.Add(time.Minute))}, 1},
+		{[][]byte{record(3, now.Add(time.Hour)), record(2, now.Add(time.Hour*2))}, 0},
+		{[][]byte{record(2, now.Add(time.Minute)), record(2, now.Add(time.Hour))}, 1},
+		{[][]byte{[]byte("junk"), record(1, now.Add(time.Hour))}, 1},
+		{[][]byte{record(1, now.Add(time.Hour)), record(5, now.Add(-time.Hour))}, 0},
+		{[][]byte{record(1, now.Add(time.Hour)), recordBy(other, 9, now.Add(time.Hour))}, 0},
+		{[][]byte{record(1, now.Add(time.Hour)), keyless(privk, 2)}, 1},
+		{[][]byte{record(1, now.Add(time.Hour)), keyless(other, 9)}, 0},
+	}
+	localKey := func(pkhash []byte) (ci.PubKey, error) {
+		return pubk, nil
+	}
+	for i, test := range tests {
+		best, err := NewIpnsSelectorFunc(localKey)(ipnskey, test.vals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best != test.best {
+			t.Fatalf("test %d: expected record %d, got %d", i, test.best, best)
+		}
+	}
+
+	// without the key of the name, records without it are never chosen
+	noKey := NewIpnsSelectorFunc(func(pkhash []byte) (ci.PubKey, error) {
+		return nil, routing.ErrNotFound
+	})
+	best, err := noKey(ipnskey, [][]byte{keyless(privk, 9), record(1, now.Add(time.Hour))})
+	if err != nil || best != 1 {
+		t.Fatalf("expected record 1, got %d (%v)", best, err)
+	}
+	for _, vals := range [][][]byte{{[]byte("junk")}, {keyless(privk, 1)}} {
+		if _, err := noKey(ipnskey, vals); err != ErrResolveFailed {
+			t.Fatalf("expected %s, got %v", ErrResolveFailed, err)
+		}
+	}
+}
+
+func TestEntryDataForSig(t *testing.T) {
+	// the fields of records with a sequence number are told apart
+	entry := func(value, validity string) []byte {
+		return ipnsEntryDataForSig(&pb.IpnsEntry{
+			Value:    []byte(value),
+			Validity: []byte(validity),
+			Sequence: proto.Uint64(1),
+		})
+	}
+	if bytes.Equal(entry("/ipfs/ab", "c"), entry("/ipfs/a", "bc")) {
+		t.Fatal("bytes moved between fields sign the same data")
+	}
+
+	// and from those of records without one
+	legacy := &pb.IpnsEntry{Value: []byte("/ipfs/a"), Validity: []byte("b")}
+	if bytes.HasPrefix(ipnsEntryDataForSig(legacy), ipnsSigV2) {
+		t.Fatal("records without a sequence number sign the data of newer records")
+	}
+	legacy.Sequence = proto.Uint64(0)
+	if !bytes.HasPrefix(ipnsEntryDataForSig(legacy), ipnsSigV2) {
+		t.Fatal("records with a sequence number sign the data of older records")
+	}
+}
+
+func TestEmbeddedPublicKey(t *testing.T) {
+	ctx := context.Background()
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+
+	privk, pubk, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, otherpub, err := testutil.RandTestKeyPair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkhash, err := pubk.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipnskey := u.Key("/ipns/" + string(pkhash))
+
+	h := path.Path("/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String())
+	data, err := createRoutingEntryData(privk, h, 1, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateIpnsRecord(ipnskey, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// without the /pk/ record, the name resolves with the embedded key
+	if err := d.PutValue(ctx, ipnskey, data); err != nil {
+		t.Fatal(err)
+	}
+	res, err := NewRoutingResolver(d).Resolve(ctx, u.Key(pkhash).Pretty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != h {
+		t.Fatalf("expected %s, got %s", h, res)
+	}
+
+	// records of another key can't be stored under the name
+	forged, err := createRoutingEntryData(other, h, 2, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateIpnsRecord(ipnskey, forged); err != record.ErrPublicKeyMismatch {
+		t.Fatalf("expected %s, got %v", record.ErrPublicKeyMismatch, err)
+	}
+
+	// nor claim its key without its signature
+	entry := new(pb.IpnsEntry)
+	if err := proto.Unmarshal(forged, entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.PubKey, err = pubk.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err = proto.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateIpnsRecord(ipnskey, forged); err != ErrSignature {
+		t.Fatalf("expected %s, got %v", ErrSignature, err)
+	}
+
+	// records without a key are still valid, as older publishers made them
+	entry.PubKey = nil
+	entry.Signature, err = other.Sign(ipnsEntryDataForSig(entry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := proto.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherhash, err := otherpub.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateIpnsRecord(u.Key("/ipns/"+string(otherhash)), legacy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveRecursive(t *testing.T) {
+	ctx := context.Background()
+	d := mockrouting.NewServer().Client(testutil.RandIdentityOrFatal(t))
+	ns := NewNameSystem(d, ds.NewMapDatastore(), nil)
+
+	var names []string
+	var keys []ci.PrivKey
+	for i := 0; i < 3; i++ {
+		privk, pubk, err := testutil.RandTestKeyPair(512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkhash, err := pubk.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, privk)
+		names = append(names, u.Key(pkhash).Pretty())
+	}
+	publish := func(i int, val string) {
+		if err := ns.Publish(ctx, keys[i], path.Path(val)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := "/ipfs/" + u.Key(u.Hash([]byte("Hello"))).B58String()
+
+	// 0 -> 1/a, 1 -> 2/b, 2 -> h
+	publish(0, "/ipns/"+names[1]+"/a")
+	publish(1, "/ipns/"+names[2]+"/b")
+	publish(2, h)
+
+	res, err := ns.ResolveN(ctx, names[0], 1)
+	if err != ErrResolveRecursion {
+		t.Fatalf("expected ErrResolveRecursion, got %v", err)
+	}
+	if expected := path.Path("/ipns/" + names[1] + "/a"); res != expected {
+		t.Fatalf("expected %s, got %s", expected, res)
+	}
+
+	res, err = ns.Resolve(ctx, "/ipns/"+names[0]+"/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.Path(h + "/b/a/c"); res != expected {
+		t.Fatalf("expected %s, got %s", expected, res)
+	}
+
+	// 2 -> 0/d makes a cycle
+	publish(2, "/ipns/"+names[0]+"/d")
+	if _, err := ns.Resolve(ctx, names[0]); err != ErrResolveCycle {
+		t.Fatalf("expected ErrResolveCycle, got %v", err)
+	}
+}
+
+func TestEntryValuePath(t *testing.T) {
+	h := u.Hash([]byte("Hello"))
+	expected := path.Path("/ipfs/" + h.B58String())
+
+	// older publishers put the bare multihash in the record
+	for _, val := range [][]byte{[]byte(h), []byte(expected)} {
+		p, err := entryValuePath(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != expected {
+			t.Fatalf("expected %s, got %s", expected, p)
+		}
+	}
+
+	if _, err := entryValuePath([]byte("junk")); err == nil {
+		t.Fatal("expected an error reading a junk value")
+	}
+}
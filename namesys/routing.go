@@ -2,11 +2,13 @@ package namesys
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
 	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	pb "github.com/ipfs/go-ipfs/namesys/internal/pb"
+	path "github.com/ipfs/go-ipfs/path"
 	routing "github.com/ipfs/go-ipfs/routing"
 	u "github.com/ipfs/go-ipfs/util"
 )
@@ -36,12 +38,23 @@ func (r *routingResolver) CanResolve(name string) bool {
 
 // Resolve implements Resolver. Uses the IPFS routing system to resolve SFS-like
 // names.
-func (r *routingResolver) Resolve(ctx context.Context, name string) (u.Key, error) {
+func (r *routingResolver) Resolve(ctx context.Context, name string) (path.Path, error) {
+	return r.ResolveN(ctx, name, DefaultDepthLimit)
+}
+
+// ResolveN implements Resolver.
+func (r *routingResolver) ResolveN(ctx context.Context, name string, depth int) (path.Path, error) {
+	return resolve(ctx, r, name, depth)
+}
+
+// resolveOnce implements resolver. Records may be cached for their ttl,
+// but not past their EOL.
+func (r *routingResolver) resolveOnce(ctx context.Context, name string) (path.Path, time.Duration, error) {
 	log.Debugf("RoutingResolve: '%s'", name)
 	hash, err := mh.FromB58String(name)
 	if err != nil {
 		log.Warning("RoutingResolve: bad input hash: [%s]\n", name)
-		return "", err
+		return "", 0, err
 	}
 	// name should be a multihash. if it isn't, error out here.
 
@@ -51,21 +64,35 @@ func (r *routingResolver) Resolve(ctx context.Context, name string) (u.Key, erro
 
 	ipnsKey := u.Key(h)
 	val, err := r.routing.GetValue(ctx, ipnsKey)
+	if err == ErrResolveFailed {
+		// none of the records found could be verified. those of older
+		// publishers are, once the key of the name is looked up.
+		if _, kerr := routing.GetPublicKey(r.routing, ctx, hash); kerr == nil {
+			val, err = r.routing.GetValue(ctx, ipnsKey)
+		}
+	}
 	if err != nil {
 		log.Warning("RoutingResolve get failed.")
-		return "", err
+		return "", 0, err
 	}
 
 	entry := new(pb.IpnsEntry)
 	err = proto.Unmarshal(val, entry)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	// name should be a public key retrievable from ipfs
-	pubkey, err := routing.GetPublicKey(r.routing, ctx, hash)
+	// the record should carry the public key of the name. the records
+	// of older publishers don't, and it is looked up in ipfs.
+	pubkey, err := entryPublicKey(hash, entry)
 	if err != nil {
-		return "", err
+		return "", 0, err
+	}
+	if pubkey == nil {
+		pubkey, err = routing.GetPublicKey(r.routing, ctx, hash)
+		if err != nil {
+			return "", 0, err
+		}
 	}
 
 	hsh, _ := pubkey.Hash()
@@ -73,9 +100,18 @@ func (r *routingResolver) Resolve(ctx context.Context, name string) (u.Key, erro
 
 	// check sig with pk
 	if ok, err := pubkey.Verify(ipnsEntryDataForSig(entry), entry.GetSignature()); err != nil || !ok {
-		return "", fmt.Errorf("Invalid value. Not signed by PrivateKey corresponding to %v", pubkey)
+		return "", 0, fmt.Errorf("Invalid value. Not signed by PrivateKey corresponding to %v", pubkey)
 	}
 
 	// ok sig checks out. this is a valid name.
-	return u.Key(entry.GetValue()), nil
+	p, err := entryValuePath(entry.GetValue())
+	if err != nil {
+		return "", 0, err
+	}
+
+	ttl := time.Duration(0)
+	if eol, err := u.ParseRFC3339(string(entry.GetValidity())); err == nil {
+		ttl = cacheTTL(time.Duration(entry.GetTtl()), eol)
+	}
+	return p, ttl, nil
 }
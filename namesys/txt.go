@@ -0,0 +1,225 @@
+package namesys
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	context "github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+)
+
+// DefaultDNSTimeout is how long a lookup from a nameserver may take, when
+// the context of the lookup has no deadline.
+const DefaultDNSTimeout = time.Second * 10
+
+// TXTResolver looks up the TXT records of domains.
+type TXTResolver interface {
+	// LookupTXT returns the TXT records of name, and how long they may
+	// be cached for. Records with a ttl of zero are not cached.
+	LookupTXT(ctx context.Context, name string) ([]string, time.Duration, error)
+}
+
+// ParseTXTResolver returns the resolver of addr. An https:// url is a
+// DNS-over-HTTPS endpoint, and a "host" or "host:port" is a nameserver, on
+// port 53 if none is given. An empty addr gives nil, for the resolver of
+// the system.
+func ParseTXTResolver(addr string) (TXTResolver, error) {
+	switch {
+	case addr == "":
+		return nil, nil
+	case strings.HasPrefix(addr, "https://"):
+		if _, err := url.Parse(addr); err != nil {
+			return nil, err
+		}
+		return NewDoHResolver(addr), nil
+	case strings.Contains(addr, "://"):
+		return nil, fmt.Errorf("unsupported dns resolver %q", addr)
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	return NewNameserverResolver(addr), nil
+}
+
+// systemResolver looks up TXT records with the resolver of the system,
+// which does not tell their ttls. They are cached for
+// DefaultResolverCacheTTL.
+type systemResolver struct{}
+
+type txtResult struct {
+	txt []string
+	err error
+}
+
+func (systemResolver) LookupTXT(ctx context.Context, name string) ([]string, time.Duration, error) {
+	// the lookup can't be cancelled: it is left to finish on its own
+	// when ctx is done first
+	res := make(chan txtResult, 1)
+	go func() {
+		txt, err := net.LookupTXT(name)
+		res <- txtResult{txt, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	case r := <-res:
+		return r.txt, DefaultResolverCacheTTL, r.err
+	}
+}
+
+// nameserverResolver queries a nameserver over udp, and over tcp when the
+// answer does not fit in a datagram.
+type nameserverResolver struct {
+	addr string
+}
+
+// NewNameserverResolver returns a TXTResolver querying the nameserver at
+// addr, a host:port.
+func NewNameserverResolver(addr string) TXTResolver {
+	return &nameserverResolver{addr: addr}
+}
+
+func (r *nameserverResolver) LookupTXT(ctx context.Context, name string) ([]string, time.Duration, error) {
+	// the id of a query is random, so that responses to it are not
+	// easily forged
+	var idb [2]byte
+	if _, err := rand.Read(idb[:]); err != nil {
+		return nil, 0, err
+	}
+	id := binary.BigEndian.Uint16(idb[:])
+	query, err := packTXTQuery(id, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := r.exchange(ctx, "udp", query)
+	if err != nil {
+		return nil, 0, err
+	}
+	txt, ttl, err := unpackTXTResponse(id, resp)
+	if err != errDNSTruncated {
+		return txt, ttl, err
+	}
+
+	resp, err = r.exchange(ctx, "tcp", query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return unpackTXTResponse(id, resp)
+}
+
+// exchange sends the query to the nameserver over the given network, and
+// returns its response. Over tcp, messages are prefixed by their length.
+func (r *nameserverResolver) exchange(ctx context.Context, network string, query []byte) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultDNSTimeout)
+	}
+
+	conn, err := net.DialTimeout(network, r.addr, deadline.Sub(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	// closing the connection unblocks it when ctx is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, dnsUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	msg := appendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(msg, query...)); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dohResolver queries a DNS-over-HTTPS endpoint, posting the queries in
+// the DNS wire format (RFC 8484).
+type dohResolver struct {
+	url string
+}
+
+// NewDoHResolver returns a TXTResolver querying the DNS-over-HTTPS
+// endpoint at the given url.
+func NewDoHResolver(endpoint string) TXTResolver {
+	return &dohResolver{url: endpoint}
+}
+
+func (r *dohResolver) LookupTXT(ctx context.Context, name string) ([]string, time.Duration, error) {
+	// the id of queries over http is 0, so that responses can be cached
+	query, err := packTXTQuery(0, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	timeout := DefaultDNSTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = deadline.Sub(time.Now())
+	}
+	client := &http.Client{Timeout: timeout}
+
+	req, err := http.NewRequest("POST", r.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	req.Cancel = ctx.Done()
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("dns-over-https query failed: %s", res.Status)
+	}
+
+	resp, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp) == 0 {
+		return nil, 0, errors.New("empty dns-over-https response")
+	}
+	return unpackTXTResponse(0, resp)
+}
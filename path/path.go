@@ -1,12 +1,18 @@
 package path
 
 import (
+	"errors"
 	"path"
 	"strings"
 
+	cid "github.com/ipfs/go-ipfs/cid"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
+// ErrBadPath is returned by ParsePath for strings that are not ipfs or
+// ipns paths
+var ErrBadPath = errors.New("invalid ipfs ref path")
+
 // TODO: debate making this a private struct wrapped in a public interface
 // would allow us to control creation, and cache segments.
 type Path string
@@ -36,3 +42,36 @@ func (p Path) Segments() []string {
 func (p Path) String() string {
 	return string(p)
 }
+
+// ParsePath checks that s is an /ipfs/ path starting with a valid cid, or
+// an /ipns/ path, and returns it cleaned up. A bare cid is taken as the
+// /ipfs/ path of it.
+func ParsePath(s string) (Path, error) {
+	if !strings.HasPrefix(s, "/") {
+		if _, err := cid.Decode(s); err != nil {
+			return "", ErrBadPath
+		}
+		return Path("/ipfs/" + s), nil
+	}
+
+	p := Path(path.Clean(s))
+	parts := p.Segments()
+	if len(parts) < 2 || parts[1] == "" {
+		return "", ErrBadPath
+	}
+	switch parts[0] {
+	case "ipfs":
+		if _, err := cid.Decode(parts[1]); err != nil {
+			return "", ErrBadPath
+		}
+	case "ipns":
+	default:
+		return "", ErrBadPath
+	}
+	return p, nil
+}
+
+// IsIpns returns whether p is an /ipns/ path.
+func (p Path) IsIpns() bool {
+	return strings.HasPrefix(string(p), "/ipns/")
+}
@@ -0,0 +1,30 @@
+package path
+
+import "testing"
+
+func TestParsePath(t *testing.T) {
+	h := "QmdfTbBqBPQ7VNxZEYEj14VmRuZBkqFbiwReogJgS1zR1n"
+	cases := map[string]Path{
+		h:                          Path("/ipfs/" + h),
+		"/ipfs/" + h:               Path("/ipfs/" + h),
+		"/ipfs/" + h + "/a/b/":     Path("/ipfs/" + h + "/a/b"),
+		"/ipns/" + h:               Path("/ipns/" + h),
+		"/ipns/example.com/a//b":   Path("/ipns/example.com/a/b"),
+		"/ipfs/" + h + "/a/../b/c": Path("/ipfs/" + h + "/b/c"),
+	}
+	for s, expected := range cases {
+		p, err := ParsePath(s)
+		if err != nil {
+			t.Fatalf("%q: %s", s, err)
+		}
+		if p != expected {
+			t.Fatalf("%q parsed to %q, expected %q", s, p, expected)
+		}
+	}
+
+	for _, s := range []string{"", "/", "/ipfs/", "/ipns", "/ipfs/notacid", "/foo/" + h, "notacid"} {
+		if _, err := ParsePath(s); err != ErrBadPath {
+			t.Fatalf("%q: expected ErrBadPath, got %v", s, err)
+		}
+	}
+}
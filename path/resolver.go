@@ -3,8 +3,10 @@ package path
 
 import (
 	"fmt"
+	"strings"
 
 	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+	cid "github.com/ipfs/go-ipfs/cid"
 	merkledag "github.com/ipfs/go-ipfs/merkledag"
 	u "github.com/ipfs/go-ipfs/util"
 )
@@ -21,15 +23,31 @@ func (e ErrNoLink) Error() string {
 	return fmt.Sprintf("no link named %q under %s", e.name, e.node.B58String())
 }
 
+// ErrNotNode is returned when a path ends at a value inside a structured
+// node rather than at a node
+type ErrNotNode struct {
+	path string
+	node mh.Multihash
+}
+
+func (e ErrNotNode) Error() string {
+	return fmt.Sprintf("path %q under %s is a value, not a node", e.path, e.node.B58String())
+}
+
 // Resolver provides path resolution to IPFS
 // It has a pointer to a DAGService, which is uses to resolve nodes.
 type Resolver struct {
 	DAG merkledag.DAGService
+
+	// ResolveOnce resolves names within a node, as far as its next link.
+	// If nil, the node's own Resolve is used; set it to look names up in
+	// nodes that keep their links elsewhere, like sharded directories.
+	ResolveOnce func(ds merkledag.DAGService, nd *merkledag.Node, names []string) (interface{}, []string, error)
 }
 
 // SplitAbsPath clean up and split fpath. It extracts the first component (which
-// must be a Multihash) and return it separately.
-func SplitAbsPath(fpath Path) (mh.Multihash, []string, error) {
+// must be a Cid, or a bare Multihash) and return it separately.
+func SplitAbsPath(fpath Path) (*cid.Cid, []string, error) {
 
 	log.Debugf("Resolve: '%s'", fpath)
 
@@ -43,14 +61,14 @@ func SplitAbsPath(fpath Path) (mh.Multihash, []string, error) {
 		return nil, nil, fmt.Errorf("ipfs path must contain at least one component")
 	}
 
-	// first element in the path is a b58 hash (for now)
-	h, err := mh.FromB58String(parts[0])
+	// first element in the path is a cid
+	c, err := cid.Decode(parts[0])
 	if err != nil {
-		log.Debug("given path element is not a base58 string.\n")
+		log.Debug("given path element is not a cid.\n")
 		return nil, nil, err
 	}
 
-	return h, parts[1:], nil
+	return c, parts[1:], nil
 }
 
 // ResolvePath fetches the node for given path. It returns the last item
@@ -68,13 +86,13 @@ func (s *Resolver) ResolvePath(fpath Path) (*merkledag.Node, error) {
 // It uses the first path component as a hash (key) of the first node, then
 // resolves all other components walking the links, with ResolveLinks.
 func (s *Resolver) ResolvePathComponents(fpath Path) ([]*merkledag.Node, error) {
-	h, parts, err := SplitAbsPath(fpath)
+	c, parts, err := SplitAbsPath(fpath)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Debug("Resolve dag get.\n")
-	nd, err := s.DAG.Get(u.Key(h))
+	nd, err := merkledag.GetCid(s.DAG, c)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +100,30 @@ func (s *Resolver) ResolvePathComponents(fpath Path) ([]*merkledag.Node, error)
 	return s.ResolveLinks(nd, parts)
 }
 
+// ResolveValue resolves fpath like ResolvePath, but the path may also end
+// inside the document of a CBOR node. In that case the value found there is
+// returned; otherwise the value is the *merkledag.Node the path names.
+func (s *Resolver) ResolveValue(fpath Path) (interface{}, error) {
+	c, parts, err := SplitAbsPath(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	nd, err := merkledag.GetCid(s.DAG, c)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, val, err := s.resolveLinks(nd, parts)
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		return val, nil
+	}
+	return nodes[len(nodes)-1], nil
+}
+
 // ResolveLinks iteratively resolves names by walking the link hierarchy.
 // Every node is fetched from the DAGService, resolving the next name.
 // Returns the list of nodes forming the path, starting with ndd. This list is
@@ -89,37 +131,58 @@ func (s *Resolver) ResolvePathComponents(fpath Path) ([]*merkledag.Node, error)
 //
 // ResolveLinks(nd, []string{"foo", "bar", "baz"})
 // would retrieve "baz" in ("bar" in ("foo" in nd.Links).Links).Links
+//
+// Within CBOR nodes, names walk into the document until they reach a link,
+// so a single link may consume several names.
 func (s *Resolver) ResolveLinks(ndd *merkledag.Node, names []string) (
 	result []*merkledag.Node, err error) {
 
+	result, val, err := s.resolveLinks(ndd, names)
+	if err == nil && val != nil {
+		n, _ := result[len(result)-1].Multihash()
+		err = ErrNotNode{path: strings.Join(names, "/"), node: n}
+	}
+	return result, err
+}
+
+// resolveLinks implements ResolveLinks. If names end inside the document of
+// a CBOR node, the value found there is returned too.
+func (s *Resolver) resolveLinks(ndd *merkledag.Node, names []string) (
+	result []*merkledag.Node, val interface{}, err error) {
+
 	result = make([]*merkledag.Node, 0, len(names)+1)
 	result = append(result, ndd)
 	nd := ndd // dup arg workaround
 
 	// for each of the path components
-	for _, name := range names {
-
-		var next u.Key
-		var nlink *merkledag.Link
-		// for each of the links in nd, the current object
-		for _, link := range nd.Links {
-			if link.Name == name {
-				next = u.Key(link.Hash)
-				nlink = link
-				break
+	for len(names) > 0 {
+
+		v, rest, err := s.resolveOnce(nd, names)
+		if err == merkledag.ErrNotFound {
+			// rest starts with the name that was not found
+			name := names[0]
+			if len(rest) > 0 {
+				name = rest[0]
 			}
+			n, _ := nd.Multihash()
+			return result, nil, ErrNoLink{name: name, node: n}
+		}
+		if err != nil {
+			return result, nil, err
 		}
 
-		if next == "" {
-			n, _ := nd.Multihash()
-			return result, ErrNoLink{name: name, node: n}
+		nlink, ok := v.(*merkledag.Link)
+		if !ok {
+			// the path ends inside nd.
+			return result, v, nil
 		}
+		names = rest
 
 		if nlink.Node == nil {
 			// fetch object for link and assign to nd
-			nd, err = s.DAG.Get(next)
+			nd, err = s.DAG.GetFormat(u.Key(nlink.Hash), nlink.Format)
 			if err != nil {
-				return append(result, nd), err
+				return append(result, nd), nil, err
 			}
 			nlink.Node = nd
 		} else {
@@ -128,5 +191,13 @@ func (s *Resolver) ResolveLinks(ndd *merkledag.Node, names []string) (
 
 		result = append(result, nlink.Node)
 	}
-	return
+	return result, nil, nil
+}
+
+// resolveOnce resolves names within nd, as far as its next link.
+func (s *Resolver) resolveOnce(nd *merkledag.Node, names []string) (interface{}, []string, error) {
+	if s.ResolveOnce != nil {
+		return s.ResolveOnce(s.DAG, nd, names)
+	}
+	return nd.Resolve(names)
 }
@@ -0,0 +1,122 @@
+package path_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	merkledag "github.com/ipfs/go-ipfs/merkledag"
+	dagmock "github.com/ipfs/go-ipfs/merkledag/test"
+	path "github.com/ipfs/go-ipfs/path"
+	hamt "github.com/ipfs/go-ipfs/unixfs/hamt"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+)
+
+func TestResolveThroughFormats(t *testing.T) {
+	dagService := dagmock.Mock(t)
+
+	leaf := &merkledag.Node{Data: []byte("leaf")}
+	dir := &merkledag.Node{Data: []byte("dir")}
+	if err := dir.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	if err := dagService.AddRecursive(dir); err != nil {
+		t.Fatal(err)
+	}
+	dh, err := dir.Multihash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := merkledag.NewCBORNode(map[string]interface{}{
+		"field": map[string]interface{}{
+			"subfield": "hello",
+			"dir":      dh,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := dagService.Add(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &path.Resolver{DAG: dagService}
+
+	nd, err := resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/field/dir/leaf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(nd.Data) != "leaf" {
+		t.Fatalf("resolved the wrong node: %q", nd.Data)
+	}
+
+	val, err := resolver.ResolveValue(path.Path("/ipfs/" + k.B58String() + "/field/subfield"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "hello" {
+		t.Fatalf("expected field value, got %#v", val)
+	}
+
+	_, err = resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/field/subfield"))
+	if _, ok := err.(path.ErrNotNode); !ok {
+		t.Fatal("expected ErrNotNode, got ", err)
+	}
+
+	_, err = resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/field/dir/nope"))
+	if _, ok := err.(path.ErrNoLink); !ok {
+		t.Fatal("expected ErrNoLink, got ", err)
+	}
+
+	// the name reported is the one missing, deep in the document
+	_, err = resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/field/nope/more"))
+	if _, ok := err.(path.ErrNoLink); !ok || !strings.Contains(err.Error(), `"nope"`) {
+		t.Fatal("expected ErrNoLink naming nope, got ", err)
+	}
+}
+
+func TestResolveShardedDirectory(t *testing.T) {
+	dagService := dagmock.Mock(t)
+
+	shard, err := hamt.NewShard(dagService, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		nd := &merkledag.Node{Data: []byte(fmt.Sprintf("file %d", i))}
+		lnk, err := merkledag.MakeLink(nd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dagService.Add(nd); err != nil {
+			t.Fatal(err)
+		}
+		if err := shard.Set(fmt.Sprintf("f%d", i), lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir, err := shard.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := dagService.Add(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &path.Resolver{DAG: dagService, ResolveOnce: uio.ResolveUnixfsOnce}
+	nd, err := resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/f42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(nd.Data) != "file 42" {
+		t.Fatalf("resolved the wrong node: %q", nd.Data)
+	}
+
+	_, err = resolver.ResolvePath(path.Path("/ipfs/" + k.B58String() + "/f100"))
+	if _, ok := err.(path.ErrNoLink); !ok {
+		t.Fatal("expected ErrNoLink, got ", err)
+	}
+}
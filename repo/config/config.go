@@ -26,6 +26,8 @@ type Config struct {
 	Gateway          Gateway               // local node's gateway server options
 	SupernodeRouting SupernodeClientConfig // local node's routing servers (if SupernodeRouting enabled)
 	Log              Log
+	Ipns             Ipns // local node's ipns republishing options
+	DNS              DNS  // local node's dns resolver, for dnslink names
 }
 
 const (
@@ -0,0 +1,9 @@
+package config
+
+// DNS contains options for resolving the dns names of ipfs paths.
+type DNS struct {
+	// Resolver is where the TXT records of domains are looked up: a
+	// nameserver as "host" or "host:port", or a DNS-over-HTTPS endpoint
+	// as an https:// url. Empty is the resolver of the system.
+	Resolver string `json:",omitempty"`
+}
@@ -4,4 +4,8 @@ package config
 type Gateway struct {
 	RootRedirect string
 	Writable bool
+
+	// ReadAhead is the number of bytes of a file the gateway fetches ahead
+	// of what it is serving. Zero means the default.
+	ReadAhead int64
 }
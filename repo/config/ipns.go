@@ -0,0 +1,12 @@
+package config
+
+// Ipns contains options for the names this node publishes.
+type Ipns struct {
+	// RepublishPeriod is how often the names published by this node are
+	// published again, as a duration like "4h". Empty is the default.
+	RepublishPeriod string `json:",omitempty"`
+
+	// RecordLifetime is how long the records republished are valid for.
+	// Empty is the default.
+	RecordLifetime string `json:",omitempty"`
+}
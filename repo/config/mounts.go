@@ -4,4 +4,12 @@ package config
 type Mounts struct {
 	IPFS string
 	IPNS string
+
+	// Chunker selects how files written to the ipns mount are split
+	// into blocks, as 'ipfs add --chunker' does. Empty is the default.
+	Chunker string `json:",omitempty"`
+
+	// ReadAhead is the number of bytes of a file the ipfs mount fetches
+	// ahead of what is being read from it. Zero means the default.
+	ReadAhead int64 `json:",omitempty"`
 }
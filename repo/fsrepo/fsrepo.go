@@ -10,6 +10,7 @@ import (
 	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	levelds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/leveldb"
 	ldbopts "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/syndtr/goleveldb/leveldb/opt"
+	keystore "github.com/ipfs/go-ipfs/keystore"
 	repo "github.com/ipfs/go-ipfs/repo"
 	"github.com/ipfs/go-ipfs/repo/common"
 	config "github.com/ipfs/go-ipfs/repo/config"
@@ -25,6 +26,7 @@ import (
 
 const (
 	defaultDataStoreDirectory = "datastore"
+	defaultKeystoreDirectory  = "keystore"
 )
 
 var (
@@ -61,6 +63,8 @@ type FSRepo struct {
 	config   *config.Config
 	// ds is set on Open
 	ds ds2.ThreadSafeDatastoreCloser
+	// keystore is set on Open
+	keystore keystore.Keystore
 }
 
 var _ repo.Repo = (*FSRepo)(nil)
@@ -113,6 +117,10 @@ func open(repoPath string) (repo.Repo, error) {
 		return nil, err
 	}
 
+	if err := r.openKeystore(); err != nil {
+		return nil, err
+	}
+
 	if err := r.openDatastore(); err != nil {
 		return nil, err
 	}
@@ -233,6 +241,17 @@ func (r *FSRepo) openConfig() error {
 	return nil
 }
 
+// openKeystore opens the keystore of the repo, creating its directory in
+// repos initialized before there was one.
+func (r *FSRepo) openKeystore() error {
+	ks, err := keystore.NewFSKeystore(path.Join(r.path, defaultKeystoreDirectory))
+	if err != nil {
+		return err
+	}
+	r.keystore = ks
+	return nil
+}
+
 // openDatastore returns an error if the config file is not present.
 func (r *FSRepo) openDatastore() error {
 	dsPath := path.Join(r.path, defaultDataStoreDirectory)
@@ -408,6 +427,15 @@ func (r *FSRepo) Datastore() ds.ThreadSafeDatastore {
 	return d
 }
 
+// Keystore returns the keystore of the repo, where the keys other than
+// the node's identity are kept.
+func (r *FSRepo) Keystore() keystore.Keystore {
+	packageLock.Lock()
+	ks := r.keystore
+	packageLock.Unlock()
+	return ks
+}
+
 var _ io.Closer = &FSRepo{}
 var _ repo.Repo = &FSRepo{}
 
@@ -2,8 +2,10 @@ package repo
 
 import (
 	"errors"
+	"sync"
 
 	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	keystore "github.com/ipfs/go-ipfs/keystore"
 	"github.com/ipfs/go-ipfs/repo/config"
 )
 
@@ -13,6 +15,10 @@ var errTODO = errors.New("TODO")
 type Mock struct {
 	C config.Config
 	D ds.ThreadSafeDatastore
+	K keystore.Keystore
+
+	// kOnce gives a Mock built without K a keystore of its own
+	kOnce sync.Once
 }
 
 func (m *Mock) Config() *config.Config {
@@ -34,4 +40,13 @@ func (m *Mock) GetConfigKey(key string) (interface{}, error) {
 
 func (m *Mock) Datastore() ds.ThreadSafeDatastore { return m.D }
 
+func (m *Mock) Keystore() keystore.Keystore {
+	m.kOnce.Do(func() {
+		if m.K == nil {
+			m.K = keystore.NewMemKeystore()
+		}
+	})
+	return m.K
+}
+
 func (m *Mock) Close() error { return errTODO }
@@ -4,6 +4,7 @@ import (
 	"io"
 
 	datastore "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	keystore "github.com/ipfs/go-ipfs/keystore"
 	config "github.com/ipfs/go-ipfs/repo/config"
 )
 
@@ -16,5 +17,8 @@ type Repo interface {
 
 	Datastore() datastore.ThreadSafeDatastore
 
+	// Keystore returns the named keys of the node, besides its identity.
+	Keystore() keystore.Keystore
+
 	io.Closer
 }
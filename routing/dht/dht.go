@@ -55,6 +55,7 @@ type IpfsDHT struct {
 	diaglock sync.Mutex // lock to make diagnostics work better
 
 	Validator record.Validator // record validator funcs
+	Selector  record.Selector  // record selector funcs
 
 	ctxgroup.ContextGroup
 }
@@ -85,6 +86,7 @@ func NewDHT(ctx context.Context, h host.Host, dstore ds.ThreadSafeDatastore) *Ip
 
 	dht.Validator = make(record.Validator)
 	dht.Validator["pk"] = record.PublicKeyValidator
+	dht.Selector = make(record.Selector)
 
 	if doPinging {
 		dht.Children().Add(1)
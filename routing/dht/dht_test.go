@@ -177,6 +177,56 @@ func TestValueGetSet(t *testing.T) {
 	}
 }
 
+func TestValueGetSetSelect(t *testing.T) {
+	ctx := context.Background()
+
+	dhtA := setupDHT(ctx, t)
+	dhtB := setupDHT(ctx, t)
+
+	defer dhtA.Close()
+	defer dhtB.Close()
+	defer dhtA.host.Close()
+	defer dhtB.host.Close()
+
+	// the greatest value is the best
+	sf := func(_ u.Key, vals [][]byte) (int, error) {
+		best := 0
+		for i, v := range vals {
+			if bytes.Compare(v, vals[best]) > 0 {
+				best = i
+			}
+		}
+		return best, nil
+	}
+	dhtA.Selector["v"] = sf
+	dhtB.Selector["v"] = sf
+
+	connect(t, ctx, dhtA, dhtB)
+
+	rec, err := record.MakePutRecord(dhtB.peerstore.PrivKey(dhtB.self), "/v/hello", []byte("2"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dhtB.putLocal("/v/hello", rec); err != nil {
+		t.Fatal(err)
+	}
+
+	// B keeps its better value
+	ctxT, _ := context.WithTimeout(ctx, time.Second)
+	dhtA.PutValue(ctxT, "/v/hello", []byte("1"))
+
+	for _, d := range []*IpfsDHT{dhtA, dhtB} {
+		ctxT, _ = context.WithTimeout(ctx, time.Second*2)
+		val, err := d.GetValue(ctxT, "/v/hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != "2" {
+			t.Fatalf("Expected '2' got '%s'", string(val))
+		}
+	}
+}
+
 func TestProvides(t *testing.T) {
 	// t.Skip("skipping test to debug another")
 	ctx := context.Background()
@@ -111,6 +111,18 @@ func (dht *IpfsDHT) handlePutValue(ctx context.Context, p peer.ID, pmes *pb.Mess
 		return nil, err
 	}
 
+	// keep the record we have, if it is better than the one sent. the
+	// selectors only choose records they can verify, with the keys stored
+	// here: when neither can be, the one sent replaces ours.
+	key := u.Key(pmes.GetKey())
+	if old, err := dht.getLocal(key); err == nil {
+		i, err := dht.Selector.BestRecord(key, [][]byte{pmes.GetRecord().GetValue(), old})
+		if err == nil && i != 0 {
+			log.Debugf("%s handlePutValue %v: kept the record stored", dht.self, dskey)
+			return pmes, nil
+		}
+	}
+
 	data, err := proto.Marshal(pmes.GetRecord())
 	if err != nil {
 		return nil, err
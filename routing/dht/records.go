@@ -95,6 +95,28 @@ func (dht *IpfsDHT) getPublicKeyFromNode(ctx context.Context, p peer.ID) (ci.Pub
 	return pk, nil
 }
 
+// LocalPublicKey returns the public key hashing to pkhash, from the
+// peerstore or the /pk/ records stored locally. The dht is not searched.
+func (dht *IpfsDHT) LocalPublicKey(pkhash []byte) (ci.PubKey, error) {
+	p := peer.ID(pkhash)
+	if pk := dht.peerstore.PubKey(p); pk != nil {
+		return pk, nil
+	}
+
+	val, err := dht.getLocal(routing.KeyForPublicKey(p))
+	if err != nil {
+		return nil, err
+	}
+	pk, err := ci.UnmarshalPublicKey(val)
+	if err != nil {
+		return nil, err
+	}
+	if id, err := peer.IDFromPublicKey(pk); err != nil || id != p {
+		return nil, record.ErrPublicKeyMismatch
+	}
+	return pk, nil
+}
+
 // verifyRecordLocally attempts to verify a record. if we do not have the public
 // key, we fail. we do not search the dht.
 func (dht *IpfsDHT) verifyRecordLocally(r *pb.Record) error {
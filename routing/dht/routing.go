@@ -23,6 +23,10 @@ import (
 // results will wait for the channel to drain.
 var asyncQueryBuffer = 10
 
+// getValueCount is how many values GetValue gathers, for the keys with
+// versions to choose from, before choosing the best.
+var getValueCount = 16
+
 // This file implements the Routing interface for the IpfsDHT struct.
 
 // Basic Put/Get
@@ -81,19 +85,33 @@ func (dht *IpfsDHT) PutValue(ctx context.Context, key u.Key, value []byte) error
 // If the search does not succeed, a multiaddr string of a closer peer is
 // returned along with util.ErrSearchIncomplete
 func (dht *IpfsDHT) GetValue(ctx context.Context, key u.Key) ([]byte, error) {
-	// If we have it local, dont bother doing an RPC!
+	// If we have it local, and it has no other versions to choose from,
+	// dont bother doing an RPC!
+	selects := dht.Selector.Selects(key)
 	val, err := dht.getLocal(key)
 	if err == nil {
 		log.Debug("have it locally")
-		return val, nil
+		if !selects {
+			return val, nil
+		}
 	} else {
 		log.Debug("failed to get value locally: %s", err)
 	}
 
+	// the values found, to choose from
+	var vals [][]byte
+	var valsLk sync.Mutex
+	if val != nil {
+		vals = append(vals, val)
+	}
+
 	// get closest peers in the routing table
 	rtp := dht.routingTable.NearestPeers(kb.ConvertKey(key), AlphaValue)
 	log.Debugf("peers in rt: %s", len(rtp), rtp)
 	if len(rtp) == 0 {
+		if len(vals) > 0 {
+			return vals[0], nil
+		}
 		log.Warning("No peers from routing table!")
 		return nil, errors.Wrap(kb.ErrLookupFailure)
 	}
@@ -112,7 +130,14 @@ func (dht *IpfsDHT) GetValue(ctx context.Context, key u.Key) ([]byte, error) {
 
 		res := &dhtQueryResult{value: val, closerPeers: peers}
 		if val != nil {
+			// gather values until there are enough to choose from
 			res.success = true
+			if selects {
+				valsLk.Lock()
+				vals = append(vals, val)
+				res.success = len(vals) >= getValueCount
+				valsLk.Unlock()
+			}
 		}
 
 		notif.PublishQueryEvent(ctx, &notif.QueryEvent{
@@ -126,6 +151,21 @@ func (dht *IpfsDHT) GetValue(ctx context.Context, key u.Key) ([]byte, error) {
 
 	// run it!
 	result, err := query.Run(ctx, rtp)
+	if selects {
+		valsLk.Lock()
+		defer valsLk.Unlock()
+		if len(vals) == 0 {
+			if err == nil {
+				err = routing.ErrNotFound
+			}
+			return nil, err
+		}
+		i, err := dht.Selector.BestRecord(key, vals)
+		if err != nil {
+			return nil, err
+		}
+		return vals[i], nil
+	}
 	if err != nil {
 		return nil, err
 	}
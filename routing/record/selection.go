@@ -0,0 +1,49 @@
+package record
+
+import (
+	"errors"
+	"strings"
+
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+// ErrNoRecords is returned when there are no records to select from.
+var ErrNoRecords = errors.New("no records given")
+
+// SelectorFunc is a function that picks the best of the records found for
+// a given key, returning its index.
+type SelectorFunc func(u.Key, [][]byte) (int, error)
+
+// Selector chooses between records found for the same key, for the
+// types of record that may have different versions.
+type Selector map[string]SelectorFunc
+
+// BestRecord returns the index of the best of recs. Keys without a
+// selector func have no best record, and the first one is returned.
+func (s Selector) BestRecord(k u.Key, recs [][]byte) (int, error) {
+	if len(recs) == 0 {
+		return 0, ErrNoRecords
+	}
+
+	sel, ok := s.selectorFunc(k)
+	if !ok {
+		return 0, nil
+	}
+	return sel(k, recs)
+}
+
+// Selects returns whether records of key k are chosen between.
+func (s Selector) Selects(k u.Key) bool {
+	_, ok := s.selectorFunc(k)
+	return ok
+}
+
+func (s Selector) selectorFunc(k u.Key) (SelectorFunc, bool) {
+	parts := strings.Split(string(k), "/")
+	if len(parts) < 3 {
+		return nil, false
+	}
+
+	sel, ok := s[parts[1]]
+	return sel, ok
+}
@@ -22,6 +22,10 @@ var ErrBadRecord = errors.New("bad dht record")
 // is not found in the Validator map of the DHT.
 var ErrInvalidRecordType = errors.New("invalid record keytype")
 
+// ErrPublicKeyMismatch is returned when a public key is not the one
+// whose hash the record is stored under.
+var ErrPublicKeyMismatch = errors.New("public key does not match storage key")
+
 // Validator is an object that helps ensure routing records are valid.
 // It is a collection of validator functions, each of which implements
 // its own notion of validity.
@@ -79,7 +83,7 @@ func ValidatePublicKeyRecord(k u.Key, val []byte) error {
 
 	pkh := u.Hash(val)
 	if !bytes.Equal(keyparts[2], pkh) {
-		return errors.New("public key does not match storage key")
+		return ErrPublicKeyMismatch
 	}
 	return nil
 }
@@ -0,0 +1,41 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test hidden and ignored files with ipfs add -r"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make a tree" '
+	mkdir -p tree/.git tree/build tree/src &&
+	echo head >tree/.git/HEAD &&
+	echo out >tree/build/out &&
+	echo main >tree/src/main.c &&
+	echo swap >tree/src/.main.c.swp &&
+	echo obj >tree/src/main.o &&
+	printf "build/\n*.o\n" >tree/.ipfsignore
+'
+
+test_expect_success "'ipfs add -r' leaves out hidden and ignored files" '
+	ipfs add -r tree >actual &&
+	cut -d" " -f3 actual >names &&
+	printf "tree/src/main.c\ntree/src\ntree\n" >expected &&
+	test_cmp expected names
+'
+
+test_expect_success "'ipfs add -r --hidden' includes hidden files" '
+	ipfs add -r --hidden tree >actual &&
+	cut -d" " -f3 actual >names &&
+	printf "tree/.git/HEAD\ntree/.git\ntree/.ipfsignore\ntree/src/.main.c.swp\ntree/src/main.c\ntree/src\ntree\n" >expected &&
+	test_cmp expected names
+'
+
+test_expect_success "ignore rules only apply below their directory" '
+	ipfs add -r tree/src >actual &&
+	grep main.o actual
+'
+
+test_done
@@ -56,6 +56,30 @@ test_expect_success FUSE "cat ipfs/stuff looks good" '
 	test_cmp expected actual
 '
 
+test_expect_success "ipfs add --hash succeeds" '
+	ipfs add --hash=sha3-512 mountdir/hello.txt >actual
+'
+
+test_expect_success "ipfs add --hash output looks good" '
+	SHA3HASH="zBunooFX5Rpygh48iBdCLoY2mrZyJf6knNmUF3idEABzvCfvHHhV16U2FknshiAjP72PSvUiyq6oNFSFtsfRkde9VrhBN" &&
+	echo "added $SHA3HASH mountdir/hello.txt" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "ipfs cat --hash file succeeds" '
+	ipfs cat "$SHA3HASH" >actual
+'
+
+test_expect_success "ipfs cat --hash file output looks good" '
+	echo "Hello Worlds!" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "ipfs cat --hash file by bare multihash succeeds" '
+	ipfs cat 8tUYAFMdgQiQNFywhdt7iYSPx2nNJXPjj5pNGD4RYW5NCkJTCPQWQdZDjCAZoL3Ewgh9hARtKEEtH9god7zkj6mhQU >actual &&
+	test_cmp expected actual
+'
+
 test_expect_success "'ipfs add -q' succeeds" '
 	echo "Hello Venus!" >mountdir/venus.txt &&
 	ipfs add -q mountdir/venus.txt >actual
@@ -123,6 +147,37 @@ test_expect_success FUSE "cat ipfs/bigfile looks good" '
 	test_cmp mountdir/bigfile actual
 '
 
+test_expect_success "'ipfs add --raw-leaves bigfile' succeeds" '
+	ipfs add --raw-leaves mountdir/bigfile >actual
+'
+
+test_expect_success "'ipfs add --raw-leaves bigfile' output looks good" '
+	HASH="QmYNFoQGirNcmYuFyr5WJKuiD81KS5Yns79AuEh6jGAfyB" &&
+	echo "added $HASH mountdir/bigfile" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs cat' raw leaves succeeds" '
+	ipfs cat "$HASH" >actual
+'
+
+test_expect_success "'ipfs cat' raw leaves output looks good" '
+	test_cmp mountdir/bigfile actual
+'
+
+test_expect_success "'ipfs refs' lists raw leaves by cid" '
+	ipfs refs "$HASH" >actual &&
+	echo "zb2rhjpwAAd2udiWirTRgNT5N6B6XBNFZS2EN3XqDCRh7fjwj" >expected &&
+	head -1 actual >actual_first &&
+	test_cmp expected actual_first
+'
+
+test_expect_success "'ipfs cat' raw leaf by cid succeeds" '
+	ipfs cat zb2rhjpwAAd2udiWirTRgNT5N6B6XBNFZS2EN3XqDCRh7fjwj >actual &&
+	head -c 262144 mountdir/bigfile >expected &&
+	test_cmp expected actual
+'
+
 test_expect_success EXPENSIVE "generate 100MB file using go-random" '
 	random 104857600 42 >mountdir/bigfile
 '
@@ -0,0 +1,56 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test recording content types with ipfs add --mime"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make files" '
+	mkdir files &&
+	printf "<html><body>hi</body></html>\n" >files/page.txt &&
+	echo "hello mime" >files/hello
+'
+
+test_expect_success "'ipfs add --mime' succeeds" '
+	HASH=$(ipfs add -r -q --mime files | tail -n 1) &&
+	test "$HASH" = QmbGC8Fg22DajgzavE9ynabWiwJkP7rQndigxUzRzLXoSY
+'
+
+test_expect_success "'ipfs add' without --mime records no types" '
+	PLAIN=$(ipfs add -r -q files | tail -n 1) &&
+	test "$PLAIN" = QmbvdkEnFmnz7VSwhbV7g168ndVmwTtRsN7zYQWph2iwdT
+'
+
+test_expect_success "'ipfs cat' reads through the metadata" '
+	ipfs cat $HASH/hello >actual &&
+	test_cmp files/hello actual
+'
+
+test_expect_success "'ipfs file stat' reports the content type" '
+	ipfs file stat $HASH/page.txt >actual &&
+	printf "Type: file\nSize: 29\nBlocks: 0\nCumulativeSize: 117\nMimeType: text/html; charset=utf-8\n" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file stat' omits unrecorded types" '
+	ipfs file stat $PLAIN/hello >actual &&
+	printf "Type: file\nSize: 11\nBlocks: 0\nCumulativeSize: 19\n" >expected &&
+	test_cmp expected actual
+'
+
+test_config_ipfs_gateway_readonly $ADDR_GWAY
+test_launch_ipfs_daemon
+
+test_expect_success "gateway prefers the recorded content type" '
+	curl -s -D headers -o actual "http://127.0.0.1:$PORT_GWAY/ipfs/$HASH/page.txt" &&
+	test_cmp files/page.txt actual &&
+	grep -i "^Content-Type: text/html" headers
+'
+
+test_kill_ipfs_daemon
+
+test_done
@@ -0,0 +1,60 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test ipfs file ls and stat"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make files" '
+	mkdir files &&
+	head -c 600000 /dev/zero | tr "\0" a >files/big &&
+	echo small >files/small &&
+	ln -s small files/link &&
+	HASH=$(ipfs add -r -q files | tail -n 1) &&
+	test "$HASH" = QmXF6Ex1ehMVzXRtyCJfUSjb6gWwuEq5fztX91d6KZyiXD
+'
+
+test_expect_success "'ipfs file ls' lists entries with their sizes" '
+	ipfs file ls $HASH >actual &&
+	cat <<-\EOF >expected &&
+	QmafMza76epQKEHgwNP5sJ7yKcbiFJpddrBzp9A9uTXNgp 600000 big
+	QmUmo9PHfZoidrJtMtaWH5nLsDt1xqjfNB2sUzFffhVhi1 5      link
+	QmcRiXm2bMK6dhPpSaZxXmVmvRK6F5kkVv74H16Lg9Mgnk 6      small
+	EOF
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file ls' lists a file by its path" '
+	ipfs file ls $HASH/big >actual &&
+	echo "$HASH/big" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file stat' describes a file" '
+	ipfs file stat $HASH/big >actual &&
+	printf "Type: file\nSize: 600000\nBlocks: 3\nCumulativeSize: 600194\n" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file stat' describes a directory" '
+	ipfs file stat $HASH >actual &&
+	printf "Type: directory\nSize: 0\nBlocks: 3\nCumulativeSize: 600363\n" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file stat' describes a symlink" '
+	ipfs file stat $HASH/link >actual &&
+	printf "Type: symlink\nSize: 5\nBlocks: 0\nCumulativeSize: 11\n" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file stat' rejects other objects" '
+	OBJ=$(echo "{\"Data\": \"abc\"}" | ipfs object put | cut -d" " -f2) &&
+	test_must_fail ipfs file stat $OBJ
+'
+
+test_done
@@ -0,0 +1,58 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test ipfs add --chunker"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make a file" '
+	seq 1 200000 >seqfile
+'
+
+test_add_chunker() {
+	chunker=$1
+	expected=$2
+
+	test_expect_success "'ipfs add --chunker=$chunker' succeeds" '
+		HASH=$(ipfs add -q --chunker=$chunker seqfile) &&
+		test "$HASH" = $expected
+	'
+
+	test_expect_success "'ipfs cat' gives back the file split with $chunker" '
+		ipfs cat $HASH >actual &&
+		test_cmp seqfile actual
+	'
+}
+
+test_add_chunker size-262144 QmNx9frVshtUjEKhcgTiPh3RzQpsfRGLDhmxooMv4saCAW
+test_add_chunker size-1024 QmVpY8Hz1SLQQwKYMFEqzqZtmUkm4dxymCY4SCQtKg57Ki
+test_add_chunker rabin QmUvgJrEWET1a53GSZGfvbFFMqoMVurUZZikgspzyHkQBH
+test_add_chunker rabin-16384-32768-65536 QmbQe2eHujV4ZSwiH41FwJGV7wDkN3uTRTTeXAf9Tq24vL
+test_add_chunker buzhash QmVyRXg5cKzqo5sf6LcG6dHV2G9ngAfkmmDt5QucGcb6Zk
+
+test_expect_success "the default chunker is size-262144" '
+	HASH=$(ipfs add -q seqfile) &&
+	test "$HASH" = QmNx9frVshtUjEKhcgTiPh3RzQpsfRGLDhmxooMv4saCAW
+'
+
+test_expect_success "buzhash finds blocks again after an insertion" '
+	A=$(ipfs add -q --chunker=buzhash seqfile) &&
+	{ echo inserted && cat seqfile; } >seqfile2 &&
+	B=$(ipfs add -q --chunker=buzhash seqfile2) &&
+	ipfs refs $A | sort >refsA &&
+	ipfs refs $B | sort >refsB &&
+	comm -12 refsA refsB >common &&
+	test $(wc -l <common) -gt 0
+'
+
+for chunker in size-0 size-1048577 rabin-8-16-32 rabin-64-32-128 rabin-1-2 fixed; do
+	test_expect_success "'ipfs add --chunker=$chunker' fails" '
+		test_must_fail ipfs add --chunker=$chunker seqfile
+	'
+done
+
+test_done
@@ -0,0 +1,39 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test ipfs add --only-hash"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make files" '
+	mkdir files &&
+	echo "only hashed" >files/small &&
+	seq 1 200000 >files/big
+'
+
+test_expect_success "'ipfs add --only-hash' succeeds" '
+	ipfs pin ls --type=recursive >pins_before &&
+	ipfs add -r --only-hash --chunker=buzhash files >expected
+'
+
+test_expect_success "'ipfs add --only-hash' stores nothing" '
+	HASH=$(tail -n 1 expected | cut -d" " -f2) &&
+	ipfs refs local >refs &&
+	test_must_fail grep $HASH refs
+'
+
+test_expect_success "'ipfs add --only-hash' pins nothing" '
+	ipfs pin ls --type=recursive >pins_after &&
+	test_cmp pins_before pins_after
+'
+
+test_expect_success "'ipfs add' outputs the same hashes" '
+	ipfs add -r --chunker=buzhash files >actual &&
+	test_cmp expected actual
+'
+
+test_done
@@ -0,0 +1,82 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test refs command"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+# builds the dag:
+#   D3 -x-> D2 -d1-> D1 -a-> F
+#   D3 -y-> D1B -b-> F2
+test_expect_success "building a dag succeeds" '
+	echo "hello" >f1 &&
+	echo "world" >f2 &&
+	F=$(ipfs add -q f1) &&
+	F2=$(ipfs add -q f2) &&
+	D1=$(echo "{\"a\":{\"/\":\"$F\"}}" | ipfs dag put) &&
+	D1B=$(echo "{\"b\":{\"/\":\"$F2\"}}" | ipfs dag put) &&
+	D2=$(echo "{\"d1\":{\"/\":\"$D1\"}}" | ipfs dag put) &&
+	D3=$(echo "{\"x\":{\"/\":\"$D2\"},\"y\":{\"/\":\"$D1B\"}}" | ipfs dag put)
+'
+
+test_expect_success "'ipfs refs' lists direct refs" '
+	printf "$D2\n$D1B\n" >expected &&
+	ipfs refs $D3 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs refs -r' lists refs depth-first" '
+	printf "$D2\n$D1\n$F\n$D1B\n$F2\n" >expected &&
+	ipfs refs -r $D3 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs refs -r --bfs' lists refs breadth-first" '
+	printf "$D2\n$D1B\n$D1\n$F2\n$F\n" >expected &&
+	ipfs refs -r --bfs $D3 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs refs -r --max-depth' limits the depth" '
+	printf "$D2\n$D1\n$D1B\n$F2\n" >expected &&
+	ipfs refs -r --max-depth=2 $D3 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs refs -r --max-depth=1' lists direct refs" '
+	printf "$D2\n$D1B\n" >expected &&
+	ipfs refs -r --max-depth=1 $D3 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs refs -r --offline' lists local refs" '
+	printf "$D2\n$D1\n$F\n$D1B\n$F2\n" >expected &&
+	ipfs refs -r --offline $D3 >actual &&
+	test_cmp expected actual
+'
+
+# builds E -a-> F3 and E -b-> F4, keeps E pinned directly and removes F3
+test_expect_success "removing a block of a dag succeeds" '
+	echo "missing" >f3 &&
+	echo "kept" >f4 &&
+	F3=$(ipfs add -q f3) &&
+	F4=$(ipfs add -q f4) &&
+	E=$(echo "{\"Data\":\"e\",\"Links\":[{\"Name\":\"a\",\"Hash\":\"$F3\"},{\"Name\":\"b\",\"Hash\":\"$F4\"}]}" | ipfs object put | cut -d" " -f2) &&
+	ipfs pin add $E &&
+	ipfs pin rm -r $F3 &&
+	ipfs repo gc &&
+	ipfs refs local >local &&
+	test_must_fail grep "$F3" local
+'
+
+test_expect_success "'ipfs refs -r --offline' skips missing blocks" '
+	printf "$F3\n$F4\n" >expected &&
+	ipfs refs -r --offline $E >actual &&
+	test_cmp expected actual
+'
+
+test_done
@@ -0,0 +1,69 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test sharded directories"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+test_config_ipfs_gateway_readonly $ADDR_GWAY
+
+test_expect_success "make a directory too big for one node" '
+	mkdir big &&
+	for i in $(seq 1 1200); do
+		echo "file $i" >big/f$i || return 1
+	done
+'
+
+test_expect_success "'ipfs add -r' shards the directory" '
+	BIG=$(ipfs add -r -q big | tail -n 1) &&
+	test "$BIG" = QmVJAdNYwSaF4NStnemqc32sKYsAko7fLSMLrv7e2EhJbc &&
+	ipfs object get $BIG >shard &&
+	test_should_contain "\"Name\": \"08\"" shard
+'
+
+test_expect_success "'ipfs ls' lists every entry" '
+	ipfs ls $BIG >actual &&
+	test $(wc -l <actual) = 1200 &&
+	grep -w f777 actual
+'
+
+test_expect_success "'ipfs cat' resolves paths through the shards" '
+	echo "file 777" >expected &&
+	ipfs cat $BIG/f777 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs file ls' lists the entries, not the shards" '
+	ipfs file ls $BIG >actual &&
+	test $(wc -l <actual) = 1200 &&
+	grep " 8 *f777 *$" actual
+'
+
+test_expect_success "'ipfs file stat' describes a sharded directory" '
+	ipfs file stat $BIG >actual &&
+	test_should_contain "Type: sharded-directory" actual
+'
+
+test_expect_success "'ipfs get' writes every entry" '
+	ipfs get -o=got $BIG &&
+	diff -r big got
+'
+
+test_launch_ipfs_daemon
+
+test_expect_success "gateway lists the sharded directory" '
+	curl -sf "http://127.0.0.1:$PORT_GWAY/ipfs/$BIG/" >listing &&
+	test_should_contain "f777" listing
+'
+
+test_expect_success "gateway serves files in the sharded directory" '
+	curl -sf "http://127.0.0.1:$PORT_GWAY/ipfs/$BIG/f777" >actual &&
+	test_cmp expected actual
+'
+
+test_kill_ipfs_daemon
+
+test_done
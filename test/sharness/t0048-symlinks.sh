@@ -0,0 +1,45 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test adding and getting symlinks"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make a directory with symlinks" '
+	mkdir files &&
+	echo hi >files/file &&
+	ln -s file files/link &&
+	ln -s /nowhere files/broken
+'
+
+test_expect_success "'ipfs add -r' stores symlinks as links" '
+	HASH=$(ipfs add -r -q files | tail -n 1) &&
+	test "$HASH" = QmYhqT1k52zzhYqXdcRWT7ATeudVFPNWNRefeuTrYinSPi &&
+	ipfs ls $HASH >actual &&
+	grep -w QmR9xxqowLA3QpkBFcgufp86p1y9BdFffZew2go8bgMvmA actual &&
+	grep -w QmSYiWCphD2QimoVBuKEmQLJvFUDhrVGLdYXaS4jKLjgJy actual
+'
+
+test_expect_success "'ipfs cat' of a symlink fails" '
+	test_must_fail ipfs cat $HASH/link
+'
+
+test_expect_success "'ipfs get' restores the symlinks" '
+	ipfs get -o=got $HASH &&
+	test -h got/link &&
+	test "$(readlink got/link)" = file &&
+	test "$(readlink got/broken)" = /nowhere &&
+	test_cmp files/file got/link
+'
+
+test_expect_success "'ipfs get -a' archives the symlinks" '
+	ipfs get -a -o=got.tar $HASH &&
+	tar tvf got.tar >actual &&
+	grep "link -> file" actual
+'
+
+test_done
@@ -0,0 +1,54 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test preserving file modes and modification times"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make files with modes and times" '
+	mkdir files &&
+	printf "#!/bin/sh\necho hi\n" >files/run.sh &&
+	echo data >files/data &&
+	chmod 755 files/run.sh &&
+	chmod 600 files/data &&
+	chmod 750 files &&
+	touch -d @1443700800 files/run.sh files/data files
+'
+
+test_expect_success "'ipfs add -r' records nothing by default" '
+	HASH=$(ipfs add -r -q files | tail -n 1) &&
+	test "$HASH" = QmUkHhXBbbadvhDfHsKgVN6oSty9c8C8fmiAG5fr5BAA6K &&
+	ipfs ls -l $HASH >actual &&
+	grep "^- *- " actual
+'
+
+test_expect_success "'ipfs add --preserve-mode' records modes" '
+	HASH=$(ipfs add -r -q --preserve-mode files | tail -n 1) &&
+	test "$HASH" = QmV274Tbrg59uxdQ6hRe1rWXZ3dSJXMMmcGgn7JfGFavgV
+'
+
+test_expect_success "'ipfs add --preserve-mode --preserve-mtime' records both" '
+	HASH=$(ipfs add -r -q --preserve-mode --preserve-mtime files | tail -n 1) &&
+	test "$HASH" = QmXRJEgNM2iMx6tPn6Cd4HmihW3d24o3PfbxzzC3JvZzMq
+'
+
+test_expect_success "'ipfs ls -l' shows modes and times" '
+	ipfs ls -l $HASH >actual &&
+	grep "^-rw------- .* data" actual &&
+	grep "^-rwxr-xr-x .* run.sh" actual
+'
+
+test_expect_success "'ipfs get' restores modes and times" '
+	ipfs get -o=got $HASH &&
+	test "$(stat -c %a got)" = 750 &&
+	test "$(stat -c %a got/run.sh)" = 755 &&
+	test "$(stat -c %a got/data)" = 600 &&
+	test "$(stat -c %Y got/run.sh)" = 1443700800 &&
+	test "$(stat -c %Y got)" = 1443700800
+'
+
+test_done
@@ -39,4 +39,26 @@ test_expect_success "'ipfs block get' output looks good" '
   test_cmp expected_stat actual_stat
 '
 
+test_expect_success "'ipfs block put --hash' succeeds" '
+	ipfs block put --hash=sha3-512 <expected_in >actual_out
+'
+
+test_expect_success "'ipfs block put --hash' output looks good" '
+	HASH="8tUkgAtWvFKkgwc11miMhBDZ3LzgWWB6pW6RwtVzzr5Ybvfykt3tFSmASpMyoUU5ZYcatJr4WRNwRJkiAwn4rxK1py" &&
+	echo "$HASH" >expected_out &&
+	test_cmp expected_out actual_out
+'
+
+test_expect_success "'ipfs block get' sha3 block succeeds" '
+	ipfs block get $HASH >actual_in
+'
+
+test_expect_success "'ipfs block get' sha3 block output looks good" '
+	test_cmp expected_in actual_in
+'
+
+test_expect_success "'ipfs block put' unknown hash fails" '
+	test_must_fail ipfs block put --hash=md5 <expected_in
+'
+
 test_done
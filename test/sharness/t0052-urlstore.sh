@@ -0,0 +1,57 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test urlstore command"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+test_config_ipfs_gateway_readonly $ADDR_GWAY
+test_launch_ipfs_daemon
+
+port=$PORT_GWAY
+
+test_expect_success "'ipfs add' a file to serve succeeds" '
+  seq 1 200000 >file &&
+  HASH=$(ipfs add -q file)
+'
+
+test_expect_success "'ipfs urlstore add' succeeds" '
+  ipfs urlstore add "http://127.0.0.1:$port/ipfs/$HASH" >actual
+'
+
+test_expect_success "'ipfs urlstore add' output looks good" '
+  echo "added $HASH http://127.0.0.1:$port/ipfs/$HASH" >expected &&
+  echo "etag $HASH" >>expected &&
+  test_cmp expected actual
+'
+
+test_expect_success "'ipfs urlstore add -q' succeeds" '
+  ipfs urlstore add -q "http://127.0.0.1:$port/ipfs/$HASH" >actual
+'
+
+test_expect_success "'ipfs urlstore add -q' output looks good" '
+  echo "$HASH" >expected &&
+  test_cmp expected actual
+'
+
+test_expect_success "'ipfs urlstore add --chunker' succeeds" '
+  ipfs urlstore add -q --chunker=size-1000 "http://127.0.0.1:$port/ipfs/$HASH" >actual &&
+  ipfs add -q --chunker=size-1000 file >expected &&
+  test_cmp expected actual
+'
+
+test_expect_success "'ipfs urlstore add' of a missing url fails" '
+  test_must_fail ipfs urlstore add "http://127.0.0.1:$port/ipfs/QmNotThere" 2>err
+'
+
+test_expect_success "'ipfs urlstore add' of a file url fails" '
+  test_must_fail ipfs urlstore add file:///etc/passwd 2>err &&
+  grep "only http and https URLs can be added" err
+'
+
+test_kill_ipfs_daemon
+
+test_done
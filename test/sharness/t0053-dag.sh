@@ -0,0 +1,79 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test dag command"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "'ipfs add' succeeds" '
+	echo "hello" >file &&
+	FILE=$(ipfs add -q file)
+'
+
+test_expect_success "'ipfs dag put' succeeds" '
+	echo "{\"file\":{\"/\":\"$FILE\"},\"x\":1}" >doc.json &&
+	ipfs dag put doc.json >actual_put
+'
+
+test_expect_success "'ipfs dag put' output looks good" '
+	HASH="zdpuAvSRFJ9YjySYvX8F26wwGAprj5HNeNcuP54ETuTSvWMW5" &&
+	echo "$HASH" >expected_put &&
+	test_cmp expected_put actual_put
+'
+
+test_expect_success "'ipfs dag put' pins the document" '
+	ipfs pin rm -r $HASH
+'
+
+test_expect_success "'ipfs dag put' again succeeds" '
+	ipfs dag put doc.json
+'
+
+test_expect_success "'ipfs dag get' succeeds" '
+	ipfs dag get $HASH >actual_get
+'
+
+test_expect_success "'ipfs dag get' output looks good" '
+	printf "{\n  \"file\": {\n    \"/\": \"$FILE\"\n  },\n  \"x\": 1\n}\n" >expected_get &&
+	test_cmp expected_get actual_get
+'
+
+test_expect_success "'ipfs dag get' accepts a base32 cid" '
+	ipfs cid format --base=base32 $HASH >b32 &&
+	ipfs dag get $(cat b32) >actual_get32 &&
+	test_cmp expected_get actual_get32
+'
+
+test_expect_success "'ipfs dag get' resolves fields" '
+	echo "1" >expected_field &&
+	ipfs dag get /ipfs/$HASH/x >actual_field &&
+	test_cmp expected_field actual_field
+'
+
+test_expect_success "'ipfs cat' resolves through dag objects" '
+	ipfs cat /ipfs/$HASH/file >actual_cat &&
+	test_cmp file actual_cat
+'
+
+test_expect_success "'ipfs refs' lists dag object links" '
+	echo "$FILE" >expected_refs &&
+	ipfs refs $HASH >actual_refs &&
+	test_cmp expected_refs actual_refs
+'
+
+test_expect_success "'ipfs dag put' rejects bad links" '
+	echo "{\"a\":{\"/\":\"notahash\"}}" >bad.json &&
+	test_must_fail ipfs dag put bad.json
+'
+
+test_expect_success "'ipfs dag put' rejects data after the document" '
+	echo "{\"a\":1} garbage" >trailing.json &&
+	test_must_fail ipfs dag put trailing.json 2>trailing_err &&
+	grep "unexpected data after the json document" trailing_err
+'
+
+test_done
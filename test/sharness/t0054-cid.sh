@@ -0,0 +1,51 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test cid command"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "'ipfs block put' succeeds" '
+	echo "Hello Mars!" >expected_in &&
+	HASH=$(ipfs block put <expected_in)
+'
+
+test_expect_success "'ipfs cid format --cid-version=1' succeeds" '
+	ipfs cid format --cid-version=1 $HASH >actual
+'
+
+test_expect_success "'ipfs cid format --cid-version=1' output looks good" '
+	CIDV1="zdj7WYR5dnECZiAfPukUBoUnvqPhcpaJZ1aPJBG7Gvpor1GPg" &&
+	echo "$CIDV1" >expected &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs cid format --base' output looks good" '
+	echo "bafybeibmlvvgdyihetgocpof6xk64kjjzdeq2e4c7hqs3krdheosk4tgj4" >expected &&
+	echo "f017012202c5d6a61e10724cce13dc5f5d5ee2929c8c90d1382f9e12daa23391d2572664f" >>expected &&
+	ipfs cid format --base=base32 $HASH >actual &&
+	ipfs cid format --base=base16 $CIDV1 >>actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs cid format --cid-version=0' converts back" '
+	echo "$HASH" >expected &&
+	ipfs cid format --cid-version=0 $CIDV1 >actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs block get' accepts a version 1 cid" '
+	ipfs block get $CIDV1 >actual_in &&
+	test_cmp expected_in actual_in
+'
+
+test_expect_success "'ipfs cid format' rejects bad input" '
+	test_must_fail ipfs cid format notacid &&
+	test_must_fail ipfs cid format --base=base64 $HASH
+'
+
+test_done
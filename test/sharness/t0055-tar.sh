@@ -0,0 +1,47 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test ipfs tar add and cat"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "make tar archives" '
+	mkdir -p files/src &&
+	echo hello >files/src/small &&
+	random 1000000 7 >files/src/big &&
+	ln -s small files/src/link &&
+	touch files/src/a-rather-long-file-name-that-does-not-fit-in-the-hundred-bytes-of-a-ustar-header-and-needs-a-pax-record &&
+	tar --format=pax -cf pax.tar -C files src &&
+	tar --format=gnu -cf gnu.tar -C files src
+'
+
+test_expect_success "'ipfs tar add' succeeds" '
+	PAX=$(ipfs tar add pax.tar) &&
+	GNU=$(ipfs tar add gnu.tar)
+'
+
+test_expect_success "'ipfs tar cat' gives back the pax archive" '
+	ipfs tar cat $PAX >actual &&
+	test_cmp pax.tar actual
+'
+
+test_expect_success "'ipfs tar cat' gives back the gnu archive" '
+	ipfs tar cat $GNU >actual &&
+	test_cmp gnu.tar actual
+'
+
+test_expect_success "archived files are stored as unixfs files" '
+	BIG=$(ipfs add -q files/src/big) &&
+	ipfs refs -r $PAX >refs &&
+	grep $BIG refs
+'
+
+test_expect_success "'ipfs tar cat' rejects other objects" '
+	test_must_fail ipfs tar cat $BIG
+'
+
+test_done
@@ -17,7 +17,7 @@ test_expect_success "'ipfs name publish' succeeds" '
 '
 
 test_expect_success "publish output looks good" '
-	echo Published name $PEERID to $HASH > expected1 &&
+	echo Published name $PEERID to /ipfs/$HASH > expected1 &&
 	test_cmp publish_out expected1
 '
 
@@ -26,8 +26,90 @@ test_expect_success "'ipfs name resolve' succeeds" '
 '
 
 test_expect_success "resolve output looks good" '
-	printf "%s" $HASH > expected2 &&
+	printf "/ipfs/%s" $HASH > expected2 &&
 	test_cmp output expected2
 '
 
+test_expect_success "'ipfs name publish --lifetime --ttl' succeeds" '
+	HASH2=QmZLRFWaz9Kypt2ACNMDzA5uzACDRiCqwdkNSP1UZsu56D &&
+	ipfs name publish --lifetime=1h --ttl=30s $HASH2 > publish_out
+'
+
+test_expect_success "publish output looks good" '
+	echo Published name $PEERID to /ipfs/$HASH2 > expected3 &&
+	test_cmp publish_out expected3
+'
+
+test_expect_success "resolve gives the last record published" '
+	ipfs name resolve $PEERID > output &&
+	printf "/ipfs/%s" $HASH2 > expected4 &&
+	test_cmp output expected4
+'
+
+test_expect_success "'ipfs name resolve --nocache' succeeds" '
+	ipfs name resolve --nocache $PEERID > output &&
+	test_cmp output expected4
+'
+
+test_expect_success "'ipfs name cache' succeeds" '
+	ipfs name cache > cache_out &&
+	grep "hits: " cache_out &&
+	grep "misses: " cache_out
+'
+
+test_expect_success "'ipfs name publish' fails on a bad lifetime" '
+	test_must_fail ipfs name publish --lifetime=forever $HASH 2> publish_err &&
+	grep "error parsing lifetime option" publish_err
+'
+
+test_expect_success "'ipfs name publish' fails on a lifetime that is not positive" '
+	test_must_fail ipfs name publish --lifetime=0s $HASH 2> publish_err &&
+	grep "lifetime must be positive" publish_err &&
+	test_must_fail ipfs name publish --lifetime=-1h $HASH 2> publish_err &&
+	grep "lifetime must be positive" publish_err
+'
+
+test_expect_success "'ipfs name publish' publishes paths below objects" '
+	ipfs name publish /ipfs/$HASH/a/b > publish_out &&
+	echo Published name $PEERID to /ipfs/$HASH/a/b > expected5 &&
+	test_cmp publish_out expected5 &&
+	ipfs name resolve $PEERID > output &&
+	printf "/ipfs/%s/a/b" $HASH > expected6 &&
+	test_cmp output expected6
+'
+
+test_expect_success "'ipfs name publish' refuses values that are not paths" '
+	test_must_fail ipfs name publish /foo/$HASH
+'
+
+test_expect_success "names pointing to other names resolve one step" '
+	KEYID=`ipfs key gen --size=1024 other` &&
+	ipfs name publish --key=other /ipns/$PEERID/c &&
+	ipfs name resolve $KEYID > output &&
+	printf "/ipns/%s/c" $PEERID > expected7 &&
+	test_cmp output expected7
+'
+
+test_expect_success "'ipfs name resolve --recursive' follows them" '
+	ipfs name resolve --recursive $KEYID > output &&
+	printf "/ipfs/%s/a/b/c" $HASH > expected8 &&
+	test_cmp output expected8
+'
+
+test_expect_success "'ipfs name resolve --recursive' respects --max-depth" '
+	test_must_fail ipfs name resolve --recursive --max-depth=1 $KEYID 2> resolve_err &&
+	grep "recursion limit exceeded" resolve_err
+'
+
+test_expect_success "names resolving to themselves fail" '
+	ipfs name publish /ipns/$KEYID &&
+	test_must_fail ipfs name resolve --recursive $KEYID 2> resolve_err &&
+	grep "names resolve to each other" resolve_err
+'
+
+test_expect_success "'ipfs name republisher' needs the daemon" '
+	test_must_fail ipfs name republisher 2> repub_err &&
+	grep "must be run in online mode" repub_err
+'
+
 test_done
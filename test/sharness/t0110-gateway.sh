@@ -33,6 +33,16 @@ test_expect_success "GET IPFS path output looks good" '
   rm actual
 '
 
+test_expect_success "GET IPFS path by version 1 cid succeeds" '
+  CIDV1=$(ipfs cid format --base=base32 "$HASH") &&
+  curl -sfo actual "http://127.0.0.1:$port/ipfs/$CIDV1"
+'
+
+test_expect_success "GET IPFS path by version 1 cid output looks good" '
+  test_cmp expected actual &&
+  rm actual
+'
+
 test_expect_success "GET IPFS directory path succeeds" '
   mkdir dir &&
   echo "12345" >dir/test &&
@@ -97,6 +97,20 @@ test_expect_success "We can HTTP GET file just created" '
   test_cmp infile outfile
 '
 
+test_expect_success "HTTP POST splits files with the chunker asked for" '
+  seq 1 200000 >seqfile &&
+  URL="http://localhost:$port/ipfs/?chunker=buzhash" &&
+  curl -svX POST --data-binary @seqfile "$URL" 2>curl.out &&
+  grep "HTTP/1.1 201 Created" curl.out &&
+  grep "Location: /ipfs/QmVyRXg5cKzqo5sf6LcG6dHV2G9ngAfkmmDt5QucGcb6Zk" curl.out
+'
+
+test_expect_success "HTTP POST rejects invalid chunkers" '
+  URL="http://localhost:$port/ipfs/?chunker=size-0" &&
+  curl -svX POST --data-binary @seqfile "$URL" 2>curl.out &&
+  grep "HTTP/1.1 400 Bad Request" curl.out
+'
+
 test_kill_ipfs_daemon
 
 test_done
@@ -0,0 +1,93 @@
+#!/bin/sh
+#
+# MIT Licensed; see the LICENSE file in this repository.
+#
+
+test_description="Test ipfs keystore commands"
+
+. lib/test-lib.sh
+
+test_init_ipfs
+
+test_expect_success "'ipfs key gen' succeeds" '
+	FOOID=`ipfs key gen --type=rsa --size=1024 foo`
+'
+
+test_expect_success "'ipfs key gen' refuses an existing name" '
+	test_must_fail ipfs key gen --size=1024 foo
+'
+
+test_expect_success "'ipfs key gen' refuses the name self" '
+	test_must_fail ipfs key gen --size=1024 self
+'
+
+test_expect_success "'ipfs key gen' refuses unknown key types" '
+	test_must_fail ipfs key gen --type=foo bar
+'
+
+test_expect_success "'ipfs key gen' refuses small key sizes" '
+	test_must_fail ipfs key gen --size=512 bar
+'
+
+test_expect_success "'ipfs key list' lists self and foo" '
+	printf "self\nfoo\n" > expected &&
+	ipfs key list > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs key list -l' shows the key ids" '
+	PEERID=`ipfs id -format="<id>"` &&
+	printf "%s self\n%s foo\n" $PEERID $FOOID > expected &&
+	ipfs key list -l > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs name publish --key' publishes to the key's name" '
+	HASH=QmYpv2VEsxzTTXRYX3PjDg961cnJE3kY1YDXLycHGQ3zZB &&
+	echo Published name $FOOID to /ipfs/$HASH > expected &&
+	ipfs name publish --key=foo $HASH > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "the key's name resolves" '
+	printf "/ipfs/%s" $HASH > expected &&
+	ipfs name resolve $FOOID > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs name publish --key' fails with unknown keys" '
+	test_must_fail ipfs name publish --key=nope $HASH
+'
+
+test_expect_success "'ipfs key rename' succeeds" '
+	echo Key foo renamed to bar > expected &&
+	ipfs key rename foo bar > actual &&
+	test_cmp expected actual &&
+	printf "%s self\n%s bar\n" $PEERID $FOOID > expected &&
+	ipfs key list -l > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs key rename' only replaces keys with --force" '
+	ipfs key gen --size=1024 baz > /dev/null &&
+	test_must_fail ipfs key rename bar baz &&
+	ipfs key rename --force bar baz &&
+	printf "%s self\n%s baz\n" $PEERID $FOOID > expected &&
+	ipfs key list -l > actual &&
+	test_cmp expected actual
+'
+
+test_expect_success "'ipfs key rm' refuses to remove self" '
+	test_must_fail ipfs key rm self
+'
+
+test_expect_success "'ipfs key rm' succeeds" '
+	echo baz > expected &&
+	ipfs key rm baz > actual &&
+	test_cmp expected actual &&
+	echo self > expected &&
+	ipfs key list > actual &&
+	test_cmp expected actual
+'
+
+test_done
@@ -0,0 +1,28 @@
+package blake2b
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSum512(t *testing.T) {
+	cases := []struct {
+		in  []byte
+		hex string
+	}{
+		{nil, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{[]byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+
+		// exactly one block, and one block and a byte
+		{bytes.Repeat([]byte("x"), 128), "082b91ea2e15d1556d2ceefdd5af5d64d31b4e01aff1959724578876293825b236ee8079173a0a38160d7d6685d6bca0bfb62c177b3599b8727d9173e2115b91"},
+		{bytes.Repeat([]byte("y"), 129), "b5a49bd30a88f4b0a5c36d2d57c3a550e88d6884c99802aba1a1d70b1c804057b3a4188074287fcb322f91d7d54bee3f8fa77b9594b377391a63936109f4d042"},
+	}
+
+	for _, c := range cases {
+		sum := Sum512(c.in)
+		if hex.EncodeToString(sum[:]) != c.hex {
+			t.Fatalf("blake2b of %d bytes: got %x, expected %s", len(c.in), sum, c.hex)
+		}
+	}
+}
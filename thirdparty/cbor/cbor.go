@@ -0,0 +1,398 @@
+// package cbor implements a canonical encoding of the subset of CBOR
+// (RFC 7049) needed to represent JSON-like documents with byte strings
+// and tags.
+//
+// Encoding is canonical: integers and lengths use their shortest form, all
+// lengths are definite, and map keys are sorted by length, then bytewise.
+// Encoding the same value twice always yields the same bytes, which makes
+// the output suitable for content addressing. Floats are always encoded in
+// 64 bits. Decoding rejects input that is not encoded that way, so that a
+// value has only one encoding.
+//
+// Supported Go types are nil, bool, int, int64, uint64, float64, string,
+// []byte, []interface{}, map[string]interface{} and Tag. Decoding produces
+// the same set, with integers as int64 (uint64 when they do not fit) and
+// all floats as float64.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxDepth is the maximum nesting of arrays, maps and tags accepted when
+// encoding or decoding.
+const MaxDepth = 512
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+const (
+	simpleFalse   = 20
+	simpleTrue    = 21
+	simpleNull    = 22
+	simpleFloat16 = 25
+	simpleFloat32 = 26
+	simpleFloat64 = 27
+)
+
+var (
+	ErrTooDeep         = errors.New("cbor: value nested too deeply")
+	ErrTruncated       = errors.New("cbor: unexpected end of data")
+	ErrTrailingData    = errors.New("cbor: trailing data after value")
+	ErrIndefinite      = errors.New("cbor: indefinite lengths are not supported")
+	ErrNonStringKey    = errors.New("cbor: map keys must be text strings")
+	ErrDuplicateKey    = errors.New("cbor: duplicate map key")
+	ErrIntegerOverflow = errors.New("cbor: integer overflows int64")
+	ErrNonCanonical    = errors.New("cbor: value is not canonically encoded")
+)
+
+// Tag is a tagged CBOR value.
+type Tag struct {
+	Number uint64
+	Value  interface{}
+}
+
+// IsMap reports whether data starts with a CBOR map header.
+func IsMap(data []byte) bool {
+	return len(data) > 0 && data[0]>>5 == majorMap
+}
+
+// Marshal returns the canonical CBOR encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, v, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(m | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(m | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(m | 25)
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		buf.Write(b[:])
+	case n <= math.MaxUint32:
+		buf.WriteByte(m | 26)
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		buf.Write(b[:])
+	default:
+		buf.WriteByte(m | 27)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		buf.Write(b[:])
+	}
+}
+
+func encodeInt(buf *bytes.Buffer, i int64) {
+	if i < 0 {
+		writeHeader(buf, majorNegInt, uint64(-(i + 1)))
+		return
+	}
+	writeHeader(buf, majorUint, uint64(i))
+}
+
+func encode(buf *bytes.Buffer, v interface{}, depth int) error {
+	if depth > MaxDepth {
+		return ErrTooDeep
+	}
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(majorSimple<<5 | simpleNull)
+	case bool:
+		if v {
+			buf.WriteByte(majorSimple<<5 | simpleTrue)
+		} else {
+			buf.WriteByte(majorSimple<<5 | simpleFalse)
+		}
+	case int:
+		encodeInt(buf, int64(v))
+	case int64:
+		encodeInt(buf, v)
+	case uint64:
+		writeHeader(buf, majorUint, v)
+	case float64:
+		buf.WriteByte(majorSimple<<5 | simpleFloat64)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+		buf.Write(b[:])
+	case string:
+		writeHeader(buf, majorText, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		writeHeader(buf, majorBytes, uint64(len(v)))
+		buf.Write(v)
+	case []interface{}:
+		writeHeader(buf, majorArray, uint64(len(v)))
+		for _, e := range v {
+			if err := encode(buf, e, depth+1); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Sort(canonicalKeys(keys))
+
+		writeHeader(buf, majorMap, uint64(len(v)))
+		for _, k := range keys {
+			writeHeader(buf, majorText, uint64(len(k)))
+			buf.WriteString(k)
+			if err := encode(buf, v[k], depth+1); err != nil {
+				return err
+			}
+		}
+	case Tag:
+		writeHeader(buf, majorTag, v.Number)
+		return encode(buf, v.Value, depth+1)
+	case *Tag:
+		return encode(buf, *v, depth)
+	default:
+		return fmt.Errorf("cbor: cannot encode value of type %T", v)
+	}
+	return nil
+}
+
+// canonicalKeys sorts map keys in RFC 7049 canonical order: shorter
+// encoded keys first, then bytewise.
+type canonicalKeys []string
+
+func (ks canonicalKeys) Len() int           { return len(ks) }
+func (ks canonicalKeys) Swap(a, b int)      { ks[a], ks[b] = ks[b], ks[a] }
+func (ks canonicalKeys) Less(a, b int) bool { return canonicalLess(ks[a], ks[b]) }
+
+func canonicalLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// Unmarshal decodes a single CBOR value from data.
+func Unmarshal(data []byte) (interface{}, error) {
+	d := &decoder{data: data}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, ErrTrailingData
+	}
+	return v, nil
+}
+
+type decoder struct {
+	data []byte
+	off  int
+}
+
+func (d *decoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, ErrTruncated
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// header reads an item header, returning its major type, the additional
+// information bits, and the argument they encode.
+func (d *decoder) header() (byte, byte, uint64, error) {
+	major, info, arg, err := d.rawHeader()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	// the arguments of simple values are floats, not integers
+	if major != majorSimple {
+		var max uint64
+		switch info {
+		case 24:
+			max = 23
+		case 25:
+			max = math.MaxUint8
+		case 26:
+			max = math.MaxUint16
+		case 27:
+			max = math.MaxUint32
+		}
+		if info >= 24 && arg <= max {
+			return 0, 0, 0, ErrNonCanonical
+		}
+	}
+	return major, info, arg, nil
+}
+
+// rawHeader reads an item header, as header does, however it is encoded.
+func (d *decoder) rawHeader() (byte, byte, uint64, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		b, err := d.next(1)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		return major, info, uint64(b[0]), nil
+	case info == 25:
+		b, err := d.next(2)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		return major, info, uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		b, err := d.next(4)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		return major, info, uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		b, err := d.next(8)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		return major, info, binary.BigEndian.Uint64(b), nil
+	case info == 31:
+		return 0, 0, 0, ErrIndefinite
+	default:
+		return 0, 0, 0, fmt.Errorf("cbor: invalid additional info %d", info)
+	}
+}
+
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > MaxDepth {
+		return nil, ErrTooDeep
+	}
+
+	major, info, arg, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUint:
+		if arg > math.MaxInt64 {
+			return arg, nil
+		}
+		return int64(arg), nil
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return nil, ErrIntegerOverflow
+		}
+		return -1 - int64(arg), nil
+	case majorBytes:
+		b, err := d.next(arg)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(b))
+		copy(out, b)
+		return out, nil
+	case majorText:
+		b, err := d.next(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case majorArray:
+		if arg > uint64(len(d.data)-d.off) {
+			return nil, ErrTruncated
+		}
+		out := make([]interface{}, arg)
+		for i := range out {
+			out[i], err = d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case majorMap:
+		if arg > uint64(len(d.data)-d.off) {
+			return nil, ErrTruncated
+		}
+		out := make(map[string]interface{}, arg)
+		var prev string
+		for i := uint64(0); i < arg; i++ {
+			kmaj, _, klen, err := d.header()
+			if err != nil {
+				return nil, err
+			}
+			if kmaj != majorText {
+				return nil, ErrNonStringKey
+			}
+			kb, err := d.next(klen)
+			if err != nil {
+				return nil, err
+			}
+			k := string(kb)
+			if _, dup := out[k]; dup {
+				return nil, ErrDuplicateKey
+			}
+			if i > 0 && !canonicalLess(prev, k) {
+				return nil, ErrNonCanonical
+			}
+			prev = k
+			out[k], err = d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case majorTag:
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Tag{Number: arg, Value: v}, nil
+	default: // majorSimple
+		return decodeSimple(info, arg)
+	}
+}
+
+func decodeSimple(info byte, arg uint64) (interface{}, error) {
+	switch info {
+	case simpleFalse:
+		return false, nil
+	case simpleTrue:
+		return true, nil
+	case simpleNull:
+		return nil, nil
+	case simpleFloat16, simpleFloat32:
+		// floats are encoded in 64 bits
+		return nil, ErrNonCanonical
+	case simpleFloat64:
+		return math.Float64frombits(arg), nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", arg)
+	}
+}
@@ -0,0 +1,103 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeKnownValues(t *testing.T) {
+	cases := []struct {
+		v   interface{}
+		hex string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(1000), "1903e8"},
+		{int64(-1), "20"},
+		{int64(-1000), "3903e7"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{nil, "f6"},
+		{true, "f5"},
+		{false, "f4"},
+		{1.1, "fb3ff199999999999a"},
+		{[]interface{}{int64(1), int64(2)}, "820102"},
+		{Tag{Number: 42, Value: []byte{0}}, "d82a4100"},
+		{map[string]interface{}{"b": int64(1), "a": int64(2), "aa": int64(3)}, "a3616102616201626161" + "03"},
+	}
+
+	for _, c := range cases {
+		out, err := Marshal(c.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(out) != c.hex {
+			t.Fatalf("encoding %#v: got %x, expected %s", c.v, out, c.hex)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc := map[string]interface{}{
+		"name":  "beep",
+		"count": int64(-42),
+		"big":   uint64(1 << 63),
+		"float": 3.5,
+		"raw":   []byte("boop"),
+		"list":  []interface{}{nil, true, "x", map[string]interface{}{}},
+		"link":  Tag{Number: 42, Value: []byte{0x12, 0x20}},
+	}
+
+	enc, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsMap(enc) {
+		t.Fatal("encoded document should start with a map header")
+	}
+
+	out, err := Unmarshal(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, doc) {
+		t.Fatalf("round trip mismatch:\n%#v\n%#v", out, doc)
+	}
+
+	again, err := Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, again) {
+		t.Fatal("encoding is not stable")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := map[string]error{
+		"":                   ErrTruncated,
+		"1903":               ErrTruncated,
+		"0000":               ErrTrailingData,
+		"9f":                 ErrIndefinite,
+		"a10101":             ErrNonStringKey,
+		"a2616100616101":     ErrDuplicateKey,
+		"3bffffffffffffffff": ErrIntegerOverflow,
+		"1817":               ErrNonCanonical, // 23 in a byte
+		"190017":             ErrNonCanonical,
+		"5800":               ErrNonCanonical, // empty bytes, length in a byte
+		"a2616201616101":     ErrNonCanonical, // keys "b", "a"
+		"a262616101616201":   ErrNonCanonical, // keys "aa", "b"
+		"f93c00":             ErrNonCanonical, // 1.0 in 16 bits
+		"fa3f800000":         ErrNonCanonical, // 1.0 in 32 bits
+	}
+	for in, expect := range cases {
+		b, _ := hex.DecodeString(in)
+		if _, err := Unmarshal(b); err != expect {
+			t.Fatalf("decoding %s: expected %v, got %v", in, expect, err)
+		}
+	}
+}
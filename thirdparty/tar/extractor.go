@@ -2,14 +2,32 @@ package tar
 
 import (
 	"archive/tar"
+	"fmt"
 	"io"
 	"os"
 	fp "path/filepath"
 	"strings"
 )
 
+// The PAX records marking the mode and the modification time of a file as
+// recorded. Only those are restored: other files keep the mode they are
+// created with, and are dated when they are extracted.
+const (
+	PAXRecordedMode  = "IPFS.mode"
+	PAXRecordedMTime = "IPFS.mtime"
+)
+
 type Extractor struct {
 	Path string
+
+	// directories extracted, whose mode and modification time are set
+	// once their contents are written
+	dirs []extractedDir
+}
+
+type extractedDir struct {
+	path   string
+	header *tar.Header
 }
 
 func (te *Extractor) Extract(reader io.Reader) error {
@@ -46,11 +64,42 @@ func (te *Extractor) Extract(reader io.Reader) error {
 			continue
 		}
 
+		if header.Typeflag == tar.TypeSymlink {
+			err = te.extractSymlink(header, i, exists, pathIsDir)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
 		err = te.extractFile(header, tarReader, i, exists, pathIsDir)
 		if err != nil {
 			return err
 		}
 	}
+
+	// innermost directories first, as setting the modification time of a
+	// directory must follow any change to its contents
+	for i := len(te.dirs) - 1; i >= 0; i-- {
+		d := te.dirs[i]
+		if err := setModeAndTime(d.path, d.header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setModeAndTime gives the file at path the permissions and modification
+// time in the header h, of those that were recorded.
+func setModeAndTime(path string, h *tar.Header) error {
+	if _, ok := h.PAXRecords[PAXRecordedMode]; ok {
+		if err := os.Chmod(path, h.FileInfo().Mode()&os.ModePerm); err != nil {
+			return err
+		}
+	}
+	if _, ok := h.PAXRecords[PAXRecordedMTime]; ok {
+		return os.Chtimes(path, h.ModTime, h.ModTime)
+	}
 	return nil
 }
 
@@ -61,6 +110,9 @@ func (te *Extractor) extractDir(h *tar.Header, depth int, exists bool) error {
 	}
 	path := fp.Join(pathElements...)
 	path = fp.Join(te.Path, path)
+	if err := te.checkPath(path); err != nil {
+		return err
+	}
 	if depth == 0 {
 		// if this is the root root directory, use it as the output path for remaining files
 		te.Path = path
@@ -71,38 +123,143 @@ func (te *Extractor) extractDir(h *tar.Header, depth int, exists bool) error {
 		return err
 	}
 
+	te.dirs = append(te.dirs, extractedDir{path, h})
 	return nil
 }
 
 func (te *Extractor) extractFile(h *tar.Header, r *tar.Reader, depth int, exists bool, pathIsDir bool) error {
+	path, err := te.outputPath(h, depth, exists, pathIsDir)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return err
+	}
+
+	return setModeAndTime(path, h)
+}
+
+func (te *Extractor) extractSymlink(h *tar.Header, depth int, exists bool, pathIsDir bool) error {
+	path, err := te.outputPath(h, depth, exists, pathIsDir)
+	if err != nil {
+		return err
+	}
+
+	// a symlink extracted on its own may point next to itself, others
+	// only within the output directory
+	root := te.Path
+	if path == te.Path {
+		root = fp.Dir(path)
+	}
+	if fp.IsAbs(h.Linkname) {
+		return fmt.Errorf("tar: symlink %s points outside of %s", h.Name, root)
+	}
+	ok, err := linkWithin(root, fp.Dir(path), h.Linkname)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("tar: symlink %s points outside of %s", h.Name, root)
+	}
+
+	return os.Symlink(h.Linkname, path)
+}
+
+// linkWithin reports whether the relative symlink target, followed from
+// the directory dir, stays within root. A ".." is only followed out of a
+// directory that is already there, and not a symlink: out of a symlink,
+// or a name that may become one later, it could lead anywhere.
+func linkWithin(root, dir, target string) (bool, error) {
+	cur := dir
+	for _, el := range strings.Split(fp.FromSlash(target), string(fp.Separator)) {
+		switch el {
+		case "", ".":
+		case "..":
+			fi, err := os.Lstat(cur)
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			if !fi.IsDir() {
+				return false, nil
+			}
+			cur = fp.Dir(cur)
+			if !within(root, cur) {
+				return false, nil
+			}
+		default:
+			cur = fp.Join(cur, el)
+		}
+	}
+	return within(root, cur), nil
+}
+
+// outputPath returns the path a file or symlink is extracted to.
+func (te *Extractor) outputPath(h *tar.Header, depth int, exists bool, pathIsDir bool) (string, error) {
 	var path string
 	if depth == 0 {
 		// if depth is 0, this is the only file (we aren't 'ipfs get'ing a directory)
 		switch {
 		case exists && !pathIsDir:
-			return os.ErrExist
+			return "", os.ErrExist
 		case exists && pathIsDir:
 			path = fp.Join(te.Path, h.Name)
-		case !exists:
+		default:
 			path = te.Path
 		}
 	} else {
 		// we are outputting a directory, this file is inside of it
 		pathElements := strings.Split(h.Name, "/")[1:]
-		path = fp.Join(pathElements...)
-		path = fp.Join(te.Path, path)
+		path = fp.Join(te.Path, fp.Join(pathElements...))
 	}
 
-	file, err := os.Create(path)
-	if err != nil {
-		return err
+	if err := te.checkPath(path); err != nil {
+		return "", err
 	}
-	defer file.Close()
+	return path, nil
+}
 
-	_, err = io.Copy(file, r)
-	if err != nil {
+// checkPath returns an error if path lies outside of te.Path, or if it or
+// a directory between te.Path and it is a symlink, which would have the
+// extracted file written elsewhere.
+func (te *Extractor) checkPath(path string) error {
+	if !within(te.Path, path) {
+		return fmt.Errorf("tar: %s is outside of %s", path, te.Path)
+	}
+	rel, err := fp.Rel(te.Path, path)
+	if err != nil || rel == "." {
 		return err
 	}
 
+	dir := te.Path
+	for _, el := range strings.Split(rel, string(fp.Separator)) {
+		dir = fp.Join(dir, el)
+		fi, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("tar: cannot extract %s through symlink %s", path, dir)
+		}
+	}
 	return nil
 }
+
+// within reports whether path is root, or lies under it.
+func within(root, path string) bool {
+	rel, err := fp.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(fp.Separator))
+}
@@ -0,0 +1,143 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	fp "path/filepath"
+	"testing"
+	"time"
+)
+
+// entry is a file of a test archive: a directory if its name ends in a
+// slash, a symlink if link is set, or else a file holding "data".
+type entry struct {
+	name string
+	link string
+}
+
+func extractEntries(t *testing.T, out string, entries ...entry) error {
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: 4}
+		switch {
+		case e.name[len(e.name)-1] == '/':
+			h.Name, h.Typeflag, h.Mode, h.Size = e.name[:len(e.name)-1], tar.TypeDir, 0755, 0
+		case e.link != "":
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := w.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Size > 0 {
+			w.Write([]byte("data"))
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return (&Extractor{Path: out}).Extract(buf)
+}
+
+func TestExtractSymlinks(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "extractor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	out := fp.Join(tmp, "out")
+	err = extractEntries(t, out,
+		entry{name: "root/"},
+		entry{name: "root/sub/"},
+		entry{name: "root/sub/f"},
+		entry{name: "root/link", link: "sub/f"},
+		entry{name: "root/sub/up", link: "../link"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(fp.Join(out, "sub/up")); err != nil || target != "../link" {
+		t.Fatalf("expected a symlink to ../link, got %q (%v)", target, err)
+	}
+
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"absolute symlink", []entry{{name: "root/"}, {name: "root/l", link: "/etc"}}},
+		{"symlink out of the output", []entry{{name: "root/"}, {name: "root/l", link: "../x"}}},
+		{"file out of the output", []entry{{name: "root/"}, {name: "root/../x"}}},
+		{"file through a symlink", []entry{{name: "root/"}, {name: "root/l", link: "."}, {name: "root/l/f"}}},
+		{"directory through a symlink", []entry{{name: "root/"}, {name: "root/l", link: "."}, {name: "root/l/d/"}}},
+		{"symlink up through a symlink", []entry{{name: "root/"}, {name: "root/d", link: "."}, {name: "root/l", link: "d/.."}}},
+		{"symlink up through a missing name", []entry{{name: "root/"}, {name: "root/l", link: "m/.."}}},
+	}
+	for i, test := range tests {
+		out := fp.Join(tmp, "bad", fmt.Sprint(i))
+		if err := os.MkdirAll(fp.Dir(out), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := extractEntries(t, out, test.entries...); err == nil {
+			t.Fatalf("%s: extracted", test.name)
+		}
+		if _, err := os.Lstat(fp.Join(tmp, "bad", "x")); err == nil {
+			t.Fatalf("%s: wrote outside of the output", test.name)
+		}
+	}
+}
+
+func TestExtractModeAndTime(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "extractor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	mtime := time.Unix(1443700800, 0)
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	headers := []*tar.Header{
+		{Name: "root", Typeflag: tar.TypeDir, Mode: 0777, ModTime: mtime},
+		{Name: "root/set", Typeflag: tar.TypeReg, Mode: 04751, ModTime: mtime, PAXRecords: map[string]string{
+			PAXRecordedMode:  "1",
+			PAXRecordedMTime: "1",
+		}},
+		{Name: "root/unset", Typeflag: tar.TypeReg, Mode: 0777, ModTime: mtime},
+	}
+	for _, h := range headers {
+		if err := w.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := fp.Join(tmp, "out")
+	if err := (&Extractor{Path: out}).Extract(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(fp.Join(out, "set"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode() != 0751 || !fi.ModTime().Equal(mtime) {
+		t.Fatalf("expected mode 0751 from %s, got %s from %s", mtime, fi.Mode(), fi.ModTime())
+	}
+
+	for _, name := range []string{"", "unset"} {
+		fi, err := os.Stat(fp.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() == 0777 || fi.ModTime().Equal(mtime) {
+			t.Fatalf("%q: mode %s and time %s were not recorded", name, fi.Mode(), fi.ModTime())
+		}
+	}
+}
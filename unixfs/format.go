@@ -4,8 +4,11 @@ package unixfs
 
 import (
 	"errors"
+	"os"
+	"time"
 
 	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
+	dag "github.com/ipfs/go-ipfs/merkledag"
 	pb "github.com/ipfs/go-ipfs/unixfs/pb"
 )
 
@@ -14,6 +17,8 @@ const (
 	TFile      = pb.Data_File
 	TDirectory = pb.Data_Directory
 	TMetadata  = pb.Data_Metadata
+	TSymlink   = pb.Data_Symlink
+	THAMTShard = pb.Data_HAMTShard
 )
 
 var ErrMalformedFileFormat = errors.New("malformed data in file format")
@@ -29,6 +34,20 @@ func FromBytes(data []byte) (*pb.Data, error) {
 	return pbdata, nil
 }
 
+// FromNode returns the unixfs data of the given node. Raw leaf nodes
+// carry no unixfs data of their own, so they are described as Raw.
+func FromNode(n *dag.Node) (*pb.Data, error) {
+	if n.Format == dag.FormatRaw {
+		typ := pb.Data_Raw
+		return &pb.Data{
+			Type:     &typ,
+			Data:     n.Data,
+			Filesize: proto.Uint64(uint64(len(n.Data))),
+		}, nil
+	}
+	return FromBytes(n.Data)
+}
+
 func FilePBData(data []byte, totalsize uint64) []byte {
 	pbfile := new(pb.Data)
 	typ := pb.Data_File
@@ -62,6 +81,106 @@ func FolderPBData() []byte {
 	return data
 }
 
+// SymlinkData returns the bytes of a symlink pointing to target.
+func SymlinkData(target string) []byte {
+	pbdata := new(pb.Data)
+	typ := pb.Data_Symlink
+	pbdata.Data = []byte(target)
+	pbdata.Type = &typ
+
+	out, err := proto.Marshal(pbdata)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// HAMTShardPBData returns the bytes of a HAMT directory shard whose
+// occupied slots are set in bitfield.
+func HAMTShardPBData(bitfield []byte, hashType, fanout uint64) []byte {
+	pbfile := new(pb.Data)
+	typ := pb.Data_HAMTShard
+	pbfile.Type = &typ
+	pbfile.Data = bitfield
+	pbfile.HashType = proto.Uint64(hashType)
+	pbfile.Fanout = proto.Uint64(fanout)
+
+	data, err := proto.Marshal(pbfile)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// Mode returns the permission bits recorded in d, if any.
+func Mode(d *pb.Data) (os.FileMode, bool) {
+	if d.Mode == nil {
+		return 0, false
+	}
+
+	m := d.GetMode()
+	mode := os.FileMode(m) & os.ModePerm
+	if m&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, true
+}
+
+// ModTime returns the modification time recorded in d, if any.
+func ModTime(d *pb.Data) (time.Time, bool) {
+	if d.Mtime == nil {
+		return time.Time{}, false
+	}
+	t := d.GetMtime()
+	return time.Unix(t.GetSeconds(), int64(t.GetFractionalNanoseconds())), true
+}
+
+// posixMode returns the POSIX permission bits of mode.
+func posixMode(mode os.FileMode) *uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= 01000
+	}
+	return proto.Uint32(m)
+}
+
+func unixTime(t time.Time) *pb.UnixTime {
+	ut := &pb.UnixTime{Seconds: proto.Int64(t.Unix())}
+	if ns := t.Nanosecond(); ns != 0 {
+		ut.FractionalNanoseconds = proto.Uint32(uint32(ns))
+	}
+	return ut
+}
+
+// SetModeAndModTime returns data, the bytes of a unixfs node, with mode
+// and mtime recorded. A zero mode or mtime is left unrecorded.
+func SetModeAndModTime(data []byte, mode os.FileMode, mtime time.Time) ([]byte, error) {
+	pbdata, err := FromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != 0 {
+		pbdata.Mode = posixMode(mode)
+	}
+	if !mtime.IsZero() {
+		pbdata.Mtime = unixTime(mtime)
+	}
+	return proto.Marshal(pbdata)
+}
+
 func WrapData(b []byte) []byte {
 	pbdata := new(pb.Data)
 	typ := pb.Data_Raw
@@ -94,11 +213,11 @@ func DataSize(data []byte) (uint64, error) {
 	}
 
 	switch pbdata.GetType() {
-	case pb.Data_Directory:
+	case pb.Data_Directory, pb.Data_HAMTShard:
 		return 0, errors.New("Cant get data size of directory!")
 	case pb.Data_File:
 		return pbdata.GetFilesize(), nil
-	case pb.Data_Raw:
+	case pb.Data_Raw, pb.Data_Symlink:
 		return uint64(len(pbdata.GetData())), nil
 	default:
 		return 0, errors.New("Unrecognized node data type!")
@@ -116,6 +235,10 @@ type FSNode struct {
 
 	// node type of this node
 	Type pb.Data_DataType
+
+	// mode and mtime, if recorded
+	mode  *uint32
+	mtime *pb.UnixTime
 }
 
 func FSNodeFromBytes(b []byte) (*FSNode, error) {
@@ -130,6 +253,8 @@ func FSNodeFromBytes(b []byte) (*FSNode, error) {
 	n.blocksizes = pbn.Blocksizes
 	n.subtotal = pbn.GetFilesize() - uint64(len(n.Data))
 	n.Type = pbn.GetType()
+	n.mode = pbn.Mode
+	n.mtime = pbn.Mtime
 	return n, nil
 }
 
@@ -150,9 +275,21 @@ func (n *FSNode) GetBytes() ([]byte, error) {
 	pbn.Filesize = proto.Uint64(uint64(len(n.Data)) + n.subtotal)
 	pbn.Blocksizes = n.blocksizes
 	pbn.Data = n.Data
+	pbn.Mode = n.mode
+	pbn.Mtime = n.mtime
 	return proto.Marshal(pbn)
 }
 
+// SetMode records the permission bits of mode in the node.
+func (n *FSNode) SetMode(mode os.FileMode) {
+	n.mode = posixMode(mode)
+}
+
+// SetModTime records t as the node's modification time.
+func (n *FSNode) SetModTime(t time.Time) {
+	n.mtime = unixTime(t)
+}
+
 func (n *FSNode) FileSize() uint64 {
 	return uint64(len(n.Data)) + n.subtotal
 }
@@ -183,6 +320,7 @@ func MetadataFromBytes(b []byte) (*Metadata, error) {
 	}
 	md := new(Metadata)
 	md.MimeType = pbm.GetMimeType()
+	md.Size = pbd.GetFilesize()
 	return md, nil
 }
 
@@ -1,7 +1,9 @@
 package unixfs
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
 	pb "github.com/ipfs/go-ipfs/unixfs/pb"
@@ -36,3 +38,59 @@ func TestFSNode(t *testing.T) {
 		t.Fatal("Datasize calculations incorrect!")
 	}
 }
+
+func TestModeAndModTime(t *testing.T) {
+	mtime := time.Unix(1445000000, 123456789)
+	b, err := SetModeAndModTime(FolderPBData(), 0755|os.ModeSetgid|os.ModeDir, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pbn, err := FromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pbn.GetMode() != 02755 {
+		t.Fatalf("expected POSIX mode 02755, got %o", pbn.GetMode())
+	}
+	mode, ok := Mode(pbn)
+	if !ok || mode != 0755|os.ModeSetgid {
+		t.Fatalf("mode did not round trip: %v", mode)
+	}
+	got, ok := ModTime(pbn)
+	if !ok || !got.Equal(mtime) {
+		t.Fatalf("mtime did not round trip: %v", got)
+	}
+
+	// FSNode keeps them through a rewrite
+	fsn, err := FSNodeFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = fsn.GetBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbn, err = FromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Mode(pbn); !ok {
+		t.Fatal("FSNode dropped the mode")
+	}
+	if _, ok := ModTime(pbn); !ok {
+		t.Fatal("FSNode dropped the mtime")
+	}
+
+	// nothing is recorded by default
+	pbn, err = FromBytes(FilePBData([]byte("beep"), 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Mode(pbn); ok {
+		t.Fatal("found a mode that was never set")
+	}
+	if _, ok := ModTime(pbn); ok {
+		t.Fatal("found an mtime that was never set")
+	}
+}
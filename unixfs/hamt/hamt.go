@@ -0,0 +1,522 @@
+// Package hamt implements sharded unixfs directories.
+//
+// A directory with too many entries to fit comfortably in one node is
+// stored as a hash array mapped trie. Each shard node has a slot for each
+// of the Fanout values of the next bits of the hash of an entry name; the
+// occupied slots are set in a bitfield held in the node's unixfs Data, and
+// the node has one link per occupied slot, in slot order.
+//
+// The name of a link starts with the slot index, in upper case hex padded
+// to a fixed width. A link whose name is just the index points to a child
+// shard, which splits the slot on the following bits of the hash. Any other
+// link is a directory entry; its name is the index followed by the entry
+// name.
+//
+// Names whose hashes agree on all the bits the shards above them use are
+// kept in a collision bucket: a shard at the deepest level holding them in
+// name order, one per slot from the first. A bucket with more names than
+// slots holds the rest in another bucket, linked from its last slot.
+package hamt
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"os"
+	"sort"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+	upb "github.com/ipfs/go-ipfs/unixfs/pb"
+)
+
+// HashFNV1a64 identifies the 64 bit FNV-1a hash of entry names. It is the
+// only hash function shards may use.
+const HashFNV1a64 = 1
+
+// DefaultFanout is the number of slots in each shard of new directories.
+const DefaultFanout = 256
+
+// MaxFanout is the largest fanout a shard may have. Fanouts come from the
+// nodes shards are read from, so they are bounded to keep a hostile node
+// from making its shard take unbounded time and memory.
+const MaxFanout = 1024
+
+var (
+	ErrNotShard       = errors.New("hamt: node is not a directory shard")
+	ErrInvalidFanout  = errors.New("hamt: fanout must be a power of two, from 8 to 1024")
+	ErrUnknownHash    = errors.New("hamt: unsupported hash function")
+	ErrMalformedShard = errors.New("hamt: malformed directory shard")
+)
+
+// Shard is a node of a sharded directory. Child shards are loaded from the
+// DAGService as they are needed.
+type Shard struct {
+	dserv dag.DAGService
+
+	bitfield *big.Int
+	children []*child
+
+	tableSize    int
+	tableSizeLg2 int
+	padLen       int
+
+	// HashFunc is the multihash code of the hash function new shard nodes
+	// are hashed with.
+	HashFunc int
+}
+
+// child is a slot of a shard: either an entry, or a child shard.
+type child struct {
+	// name and val are set for entries
+	name string
+	val  *dag.Link
+
+	// shard is set once a child shard is loaded; until then link points
+	// to it.
+	shard *Shard
+	link  *dag.Link
+}
+
+// NewShard returns an empty shard with the given fanout.
+func NewShard(dserv dag.DAGService, fanout int) (*Shard, error) {
+	if fanout < 8 || fanout > MaxFanout {
+		return nil, ErrInvalidFanout
+	}
+	lg2 := 0
+	for 1<<uint(lg2) < fanout {
+		lg2++
+	}
+	if 1<<uint(lg2) != fanout {
+		return nil, ErrInvalidFanout
+	}
+
+	return &Shard{
+		dserv:        dserv,
+		bitfield:     new(big.Int),
+		tableSize:    fanout,
+		tableSizeLg2: lg2,
+		padLen:       len(fmt.Sprintf("%X", fanout-1)),
+	}, nil
+}
+
+// IsShard reports whether nd is a directory shard.
+func IsShard(nd *dag.Node) bool {
+	if nd.Format != dag.FormatProtobuf {
+		return false
+	}
+	pbd, err := ft.FromBytes(nd.Data)
+	return err == nil && pbd.GetType() == upb.Data_HAMTShard
+}
+
+// NewShardFromNode returns the shard stored in nd.
+func NewShardFromNode(dserv dag.DAGService, nd *dag.Node) (*Shard, error) {
+	if nd.Format != dag.FormatProtobuf {
+		return nil, ErrNotShard
+	}
+	pbd, err := ft.FromBytes(nd.Data)
+	if err != nil {
+		return nil, err
+	}
+	if pbd.GetType() != upb.Data_HAMTShard {
+		return nil, ErrNotShard
+	}
+	if pbd.GetHashType() != HashFNV1a64 {
+		return nil, ErrUnknownHash
+	}
+
+	if pbd.GetFanout() > MaxFanout {
+		return nil, ErrInvalidFanout
+	}
+	s, err := NewShard(dserv, int(pbd.GetFanout()))
+	if err != nil {
+		return nil, err
+	}
+	s.HashFunc = nd.HashFunc
+	s.bitfield.SetBytes(pbd.GetData())
+
+	if s.bitfield.BitLen() > s.tableSize || len(nd.Links) != s.popCount(s.tableSize) {
+		return nil, ErrMalformedShard
+	}
+	// each link must be named for the slot it fills
+	idx := 0
+	for _, l := range nd.Links {
+		for s.bitfield.Bit(idx) == 0 {
+			idx++
+		}
+		if len(l.Name) < s.padLen || l.Name[:s.padLen] != s.linkPrefix(idx) {
+			return nil, ErrMalformedShard
+		}
+		idx++
+		lnk := *l
+		if len(l.Name) == s.padLen {
+			s.children = append(s.children, &child{link: &lnk})
+			continue
+		}
+		lnk.Name = l.Name[s.padLen:]
+		s.children = append(s.children, &child{name: lnk.Name, val: &lnk})
+	}
+	return s, nil
+}
+
+// Set adds the entry name, pointing where lnk does, replacing any entry
+// of the same name.
+func (s *Shard) Set(name string, lnk *dag.Link) error {
+	val := *lnk
+	val.Name = name
+	val.Node = nil
+	return s.modify(newHashBits(name), name, &val)
+}
+
+// Remove removes the entry name, returning os.ErrNotExist if there is
+// none.
+func (s *Shard) Remove(name string) error {
+	return s.modify(newHashBits(name), name, nil)
+}
+
+// Find returns the link of the entry name, or os.ErrNotExist.
+func (s *Shard) Find(name string) (*dag.Link, error) {
+	hv := newHashBits(name)
+	for cur := s; ; {
+		if hv.exhausted(cur.tableSizeLg2) {
+			return cur.findInBucket(name)
+		}
+		idx := hv.next(cur.tableSizeLg2)
+		if cur.bitfield.Bit(idx) == 0 {
+			return nil, os.ErrNotExist
+		}
+
+		c := cur.children[cur.childIndex(idx)]
+		if c.val != nil {
+			if c.name != name {
+				return nil, os.ErrNotExist
+			}
+			return c.val, nil
+		}
+		var err error
+		cur, err = cur.loadChild(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ForEachLink calls f with the link of every entry in the directory, in
+// hash order, and in name order for names of the same hash.
+func (s *Shard) ForEachLink(f func(*dag.Link) error) error {
+	for _, c := range s.children {
+		if c.val != nil {
+			if err := f(c.val); err != nil {
+				return err
+			}
+			continue
+		}
+
+		cs, err := s.loadChild(c)
+		if err != nil {
+			return err
+		}
+		if err := cs.ForEachLink(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Links returns the links of all entries in the directory.
+func (s *Shard) Links() ([]*dag.Link, error) {
+	var out []*dag.Link
+	err := s.ForEachLink(func(l *dag.Link) error {
+		out = append(out, l)
+		return nil
+	})
+	return out, err
+}
+
+// Node returns the node of this shard. Modified child shards are written
+// to the DAGService; the returned node is not.
+func (s *Shard) Node() (*dag.Node, error) {
+	out := &dag.Node{
+		Data:     ft.HAMTShardPBData(s.bitfield.Bytes(), HashFNV1a64, uint64(s.tableSize)),
+		HashFunc: s.HashFunc,
+	}
+
+	cindex := 0
+	for idx := 0; idx < s.tableSize; idx++ {
+		if s.bitfield.Bit(idx) == 0 {
+			continue
+		}
+		c := s.children[cindex]
+		cindex++
+
+		prefix := s.linkPrefix(idx)
+		switch {
+		case c.val != nil:
+			lnk := *c.val
+			lnk.Name = prefix + c.name
+			out.Links = append(out.Links, &lnk)
+		case c.shard != nil:
+			cnd, err := c.shard.Node()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := s.dserv.Add(cnd); err != nil {
+				return nil, err
+			}
+			if err := out.AddNodeLinkClean(prefix, cnd); err != nil {
+				return nil, err
+			}
+		default:
+			lnk := *c.link
+			lnk.Name = prefix
+			out.Links = append(out.Links, &lnk)
+		}
+	}
+	return out, nil
+}
+
+func (s *Shard) modify(hv *hashBits, name string, val *dag.Link) error {
+	if hv.exhausted(s.tableSizeLg2) {
+		return s.modifyBucket(name, val)
+	}
+	idx := hv.next(s.tableSizeLg2)
+
+	if s.bitfield.Bit(idx) == 0 {
+		if val == nil {
+			return os.ErrNotExist
+		}
+		s.insertChild(idx, &child{name: name, val: val})
+		return nil
+	}
+
+	cindex := s.childIndex(idx)
+	c := s.children[cindex]
+	if c.val != nil {
+		if c.name == name {
+			if val == nil {
+				s.removeChild(idx, cindex)
+			} else {
+				c.val = val
+			}
+			return nil
+		}
+		if val == nil {
+			return os.ErrNotExist
+		}
+
+		// two entries share this slot: split it into a new shard.
+		ns, err := NewShard(s.dserv, s.tableSize)
+		if err != nil {
+			return err
+		}
+		ns.HashFunc = s.HashFunc
+		chv := newHashBits(c.name)
+		chv.consumed = hv.consumed
+		if err := ns.modify(chv, c.name, c.val); err != nil {
+			return err
+		}
+		if err := ns.modify(hv, name, val); err != nil {
+			return err
+		}
+		s.children[cindex] = &child{shard: ns}
+		return nil
+	}
+
+	cs, err := s.loadChild(c)
+	if err != nil {
+		return err
+	}
+	if err := cs.modify(hv, name, val); err != nil {
+		return err
+	}
+
+	if val == nil {
+		// collapse child shards left holding a single entry.
+		switch len(cs.children) {
+		case 0:
+			s.removeChild(idx, cindex)
+		case 1:
+			if only := cs.children[0]; only.val != nil {
+				s.children[cindex] = only
+			}
+		}
+	}
+	return nil
+}
+
+// findInBucket returns the link of the entry name in the collision bucket
+// s, or os.ErrNotExist.
+func (s *Shard) findInBucket(name string) (*dag.Link, error) {
+	entries, err := s.bucketEntries()
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].name >= name })
+	if i == len(entries) || entries[i].name != name {
+		return nil, os.ErrNotExist
+	}
+	return entries[i].val, nil
+}
+
+// modifyBucket sets the entry name of the collision bucket s to val, or
+// removes it if val is nil.
+func (s *Shard) modifyBucket(name string, val *dag.Link) error {
+	entries, err := s.bucketEntries()
+	if err != nil {
+		return err
+	}
+
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].name >= name })
+	found := i < len(entries) && entries[i].name == name
+	switch {
+	case val == nil && !found:
+		return os.ErrNotExist
+	case val == nil:
+		entries = append(entries[:i], entries[i+1:]...)
+	case found:
+		entries[i] = &child{name: name, val: val}
+	default:
+		entries = append(entries, nil)
+		copy(entries[i+1:], entries[i:])
+		entries[i] = &child{name: name, val: val}
+	}
+	return s.setBucket(entries)
+}
+
+// bucketEntries returns the entries of the collision bucket s, and of the
+// buckets it overflows into, in name order.
+func (s *Shard) bucketEntries() ([]*child, error) {
+	var out []*child
+	for _, c := range s.children {
+		if c.val != nil {
+			out = append(out, c)
+			continue
+		}
+
+		cs, err := s.loadChild(c)
+		if err != nil {
+			return nil, err
+		}
+		rest, err := cs.bucketEntries()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rest...)
+	}
+	sort.Sort(childrenByName(out))
+	return out, nil
+}
+
+// setBucket makes s the collision bucket of entries, which are in name
+// order.
+func (s *Shard) setBucket(entries []*child) error {
+	s.bitfield = new(big.Int)
+	s.children = nil
+
+	n := len(entries)
+	if n > s.tableSize {
+		n = s.tableSize - 1
+	}
+	for i, c := range entries[:n] {
+		s.insertChild(i, c)
+	}
+	if n == len(entries) {
+		return nil
+	}
+
+	ns, err := NewShard(s.dserv, s.tableSize)
+	if err != nil {
+		return err
+	}
+	ns.HashFunc = s.HashFunc
+	if err := ns.setBucket(entries[n:]); err != nil {
+		return err
+	}
+	s.insertChild(n, &child{shard: ns})
+	return nil
+}
+
+func (s *Shard) loadChild(c *child) (*Shard, error) {
+	if c.shard != nil {
+		return c.shard, nil
+	}
+
+	nd, err := c.link.GetNode(s.dserv)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := NewShardFromNode(s.dserv, nd)
+	if err != nil {
+		return nil, err
+	}
+	c.shard = cs
+	return cs, nil
+}
+
+func (s *Shard) insertChild(idx int, c *child) {
+	i := s.childIndex(idx)
+	s.children = append(s.children, nil)
+	copy(s.children[i+1:], s.children[i:])
+	s.children[i] = c
+	s.bitfield.SetBit(s.bitfield, idx, 1)
+}
+
+func (s *Shard) removeChild(idx, cindex int) {
+	s.children = append(s.children[:cindex], s.children[cindex+1:]...)
+	s.bitfield.SetBit(s.bitfield, idx, 0)
+}
+
+// childIndex returns the position in children of slot idx.
+func (s *Shard) childIndex(idx int) int {
+	return s.popCount(idx)
+}
+
+// popCount returns the number of occupied slots below idx.
+func (s *Shard) popCount(idx int) int {
+	n := 0
+	for i := 0; i < idx; i++ {
+		n += int(s.bitfield.Bit(i))
+	}
+	return n
+}
+
+func (s *Shard) linkPrefix(idx int) string {
+	return fmt.Sprintf("%0*X", s.padLen, idx)
+}
+
+type childrenByName []*child
+
+func (c childrenByName) Len() int           { return len(c) }
+func (c childrenByName) Less(i, j int) bool { return c[i].name < c[j].name }
+func (c childrenByName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// hashBits hands out the bits of the hash of a name, most significant
+// first.
+type hashBits struct {
+	h        uint64
+	consumed int
+}
+
+// hashName is the hash of entry names, a variable so that tests can make
+// names collide.
+var hashName = func(name string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(name))
+	return f.Sum64()
+}
+
+func newHashBits(name string) *hashBits {
+	return &hashBits{h: hashName(name)}
+}
+
+// exhausted reports whether fewer than n bits of the hash are left.
+func (hv *hashBits) exhausted(n int) bool {
+	return hv.consumed+n > 64
+}
+
+func (hv *hashBits) next(n int) int {
+	v := (hv.h << uint(hv.consumed)) >> uint(64-n)
+	hv.consumed += n
+	return int(v)
+}
@@ -0,0 +1,286 @@
+package hamt
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	dag "github.com/ipfs/go-ipfs/merkledag"
+	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
+	ft "github.com/ipfs/go-ipfs/unixfs"
+)
+
+func makeEntries(t *testing.T, ds dag.DAGService, n int) map[string]*dag.Link {
+	out := make(map[string]*dag.Link)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("entry-%d", i)
+		nd := &dag.Node{Data: []byte(name)}
+		if _, err := ds.Add(nd); err != nil {
+			t.Fatal(err)
+		}
+		lnk, err := dag.MakeLink(nd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[name] = lnk
+	}
+	return out
+}
+
+// storeShard writes s to ds and loads it back.
+func storeShard(t *testing.T, ds dag.DAGService, s *Shard) *Shard {
+	nd, err := s.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ds.Add(nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err = ds.Get(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsShard(nd) {
+		t.Fatal("stored node is not a shard")
+	}
+	out, err := NewShardFromNode(ds, nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func checkEntries(t *testing.T, s *Shard, entries map[string]*dag.Link) {
+	for name, lnk := range entries {
+		got, err := s.Find(name)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if got.Name != name || string(got.Hash) != string(lnk.Hash) {
+			t.Fatalf("%s: found the wrong link", name)
+		}
+	}
+
+	links, err := s.Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != len(entries) {
+		t.Fatalf("expected %d links, got %d", len(entries), len(links))
+	}
+	var names []string
+	for _, l := range links {
+		if _, ok := entries[l.Name]; !ok {
+			t.Fatalf("unexpected entry %q", l.Name)
+		}
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+	for i := 1; i < len(names); i++ {
+		if names[i] == names[i-1] {
+			t.Fatalf("entry %q listed twice", names[i])
+		}
+	}
+}
+
+func TestSetAndFind(t *testing.T) {
+	ds := mdtest.Mock(t)
+	entries := makeEntries(t, ds, 3000)
+
+	s, err := NewShard(ds, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, lnk := range entries {
+		if err := s.Set(name, lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkEntries(t, s, entries)
+
+	loaded := storeShard(t, ds, s)
+	checkEntries(t, loaded, entries)
+
+	if _, err := loaded.Find("missing"); !os.IsNotExist(err) {
+		t.Fatal("found an entry that was never set")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ds := mdtest.Mock(t)
+	entries := makeEntries(t, ds, 500)
+
+	s, err := NewShard(ds, DefaultFanout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, lnk := range entries {
+		if err := s.Set(name, lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s = storeShard(t, ds, s)
+
+	for i := 0; i < 400; i++ {
+		name := fmt.Sprintf("entry-%d", i)
+		if err := s.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+		delete(entries, name)
+	}
+	if err := s.Remove("entry-0"); !os.IsNotExist(err) {
+		t.Fatal("removed an entry twice")
+	}
+	checkEntries(t, s, entries)
+
+	// removing entries must give the same shard as never adding them.
+	fresh, err := NewShard(ds, DefaultFanout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, lnk := range entries {
+		if err := fresh.Set(name, lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := s.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fresh.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ak, _ := a.Key()
+	bk, _ := b.Key()
+	if ak != bk {
+		t.Fatal("shard differs from one built without the removed entries")
+	}
+}
+
+func TestHashCollisions(t *testing.T) {
+	fnvHash := hashName
+	defer func() { hashName = fnvHash }()
+	hashName = func(name string) uint64 {
+		if strings.HasSuffix(name, "0") {
+			return 42
+		}
+		return fnvHash(name)
+	}
+
+	ds := mdtest.Mock(t)
+	entries := makeEntries(t, ds, 300)
+
+	// 30 names ending in 0 share a hash: more than a bucket of fanout 8
+	// holds.
+	s, err := NewShard(ds, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, lnk := range entries {
+		if err := s.Set(name, lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkEntries(t, s, entries)
+	s = storeShard(t, ds, s)
+	checkEntries(t, s, entries)
+
+	if _, err := s.Find("entry-1000"); !os.IsNotExist(err) {
+		t.Fatal("found a colliding entry that was never set")
+	}
+
+	for i := 0; i < 300; i += 20 {
+		name := fmt.Sprintf("entry-%d", i)
+		if err := s.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+		delete(entries, name)
+	}
+	if err := s.Remove("entry-0"); !os.IsNotExist(err) {
+		t.Fatal("removed a colliding entry twice")
+	}
+	checkEntries(t, s, entries)
+
+	// buckets must not depend on the order names were set in.
+	fresh, err := NewShard(ds, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 299; i >= 0; i-- {
+		name := fmt.Sprintf("entry-%d", i)
+		if lnk, ok := entries[name]; ok {
+			if err := fresh.Set(name, lnk); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	a, err := s.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fresh.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ak, _ := a.Key()
+	bk, _ := b.Key()
+	if ak != bk {
+		t.Fatal("shard differs from one built in another order")
+	}
+}
+
+func TestInvalidFanout(t *testing.T) {
+	for _, f := range []int{0, 4, 12, 100, 2048, 1 << 40, 1<<63 - 1} {
+		if _, err := NewShard(nil, f); err != ErrInvalidFanout {
+			t.Fatalf("accepted fanout %d", f)
+		}
+	}
+
+	nd := &dag.Node{Data: ft.HAMTShardPBData(nil, HashFNV1a64, 1<<63)}
+	if _, err := NewShardFromNode(nil, nd); err != ErrInvalidFanout {
+		t.Fatal("loaded a shard with a fanout out of range")
+	}
+}
+
+func TestMalformedShard(t *testing.T) {
+	ds := mdtest.Mock(t)
+	s, err := NewShard(ds, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, lnk := range makeEntries(t, ds, 4) {
+		if err := s.Set(name, lnk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nd, err := s.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewShardFromNode(ds, nd); err != nil {
+		t.Fatal(err)
+	}
+
+	// a link named for a slot other than the one it fills
+	bad := nd.Copy()
+	lnk := *bad.Links[0]
+	idx := strings.Index("0123456789ABCDEF", lnk.Name[:1])
+	lnk.Name = fmt.Sprintf("%X", (idx+1)%16) + lnk.Name[1:]
+	bad.Links[0] = &lnk
+	if _, err := NewShardFromNode(ds, bad); err != ErrMalformedShard {
+		t.Fatal("loaded a shard with a misnamed link")
+	}
+
+	// a bitfield with bits beyond the table
+	bad = nd.Copy()
+	bits := new(big.Int).SetBit(s.bitfield, 16, 1)
+	bad.Data = ft.HAMTShardPBData(bits.Bytes(), HashFNV1a64, 16)
+	if _, err := NewShardFromNode(ds, bad); err != ErrMalformedShard {
+		t.Fatal("loaded a shard with a bitfield larger than its table")
+	}
+}
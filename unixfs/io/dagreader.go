@@ -16,6 +16,18 @@ import (
 
 var ErrIsDir = errors.New("this dag node is a directory")
 
+var ErrCantReadSymlinks = errors.New("cannot currently read symlinks")
+
+// DefaultReadAhead is the number of bytes of upcoming blocks a DagReader
+// fetches ahead of its read head, once reading has settled into a
+// sequential pattern.
+var DefaultReadAhead int64 = 2 << 20
+
+// initialReadAhead is the read-ahead window of a DagReader which has just
+// been opened or seeked. It doubles with every block read sequentially,
+// up to the reader's read-ahead limit.
+const initialReadAhead = 256 << 10
+
 // DagReader provides a way to easily read the data contained in a dag.
 type DagReader struct {
 	serv mdag.DAGService
@@ -30,12 +42,25 @@ type DagReader struct {
 	// will either be a bytes.Reader or a child DagReader
 	buf ReadSeekCloser
 
-	// NodeGetters for each of 'nodes' child links
+	// NodeGetters for each of 'nodes' child links, nil for the links
+	// that have not been requested yet
 	promises []mdag.NodeGetter
 
 	// the index of the child link currently being read from
 	linkPosition int
 
+	// the index of the first child link not yet requested
+	fetched int
+
+	// the number of bytes of upcoming links currently kept in flight, and
+	// the limit it grows to
+	window    int64
+	readAhead int64
+
+	// context of the requests in flight, cancelled when seeking
+	fetchCtx    context.Context
+	fetchCancel func()
+
 	// current offset for the read head within the 'file'
 	offset int64
 
@@ -56,20 +81,21 @@ type ReadSeekCloser interface {
 // NewDagReader creates a new reader object that reads the data represented by the given
 // node, using the passed in DAGService for data retreival
 func NewDagReader(ctx context.Context, n *mdag.Node, serv mdag.DAGService) (*DagReader, error) {
-	pb := new(ftpb.Data)
-	err := proto.Unmarshal(n.Data, pb)
+	pb, err := ft.FromNode(n)
 	if err != nil {
 		return nil, err
 	}
 
 	switch pb.GetType() {
-	case ftpb.Data_Directory:
+	case ftpb.Data_Directory, ftpb.Data_HAMTShard:
 		// Dont allow reading directories
 		return nil, ErrIsDir
+	case ftpb.Data_Symlink:
+		return nil, ErrCantReadSymlinks
 	case ftpb.Data_Raw:
 		fallthrough
 	case ftpb.Data_File:
-		return newDataFileReader(ctx, n, pb, serv), nil
+		return newDataFileReader(ctx, n, pb, serv, DefaultReadAhead), nil
 	case ftpb.Data_Metadata:
 		if len(n.Links) == 0 {
 			return nil, errors.New("incorrectly formatted metadata object")
@@ -84,20 +110,105 @@ func NewDagReader(ctx context.Context, n *mdag.Node, serv mdag.DAGService) (*Dag
 	}
 }
 
-func newDataFileReader(ctx context.Context, n *mdag.Node, pb *ftpb.Data, serv mdag.DAGService) *DagReader {
+func newDataFileReader(ctx context.Context, n *mdag.Node, pb *ftpb.Data, serv mdag.DAGService, readAhead int64) *DagReader {
 	fctx, cancel := context.WithCancel(ctx)
-	promises := serv.GetDAG(fctx, n)
-	return &DagReader{
-		node:     n,
-		serv:     serv,
-		buf:      NewRSNCFromBytes(pb.GetData()),
-		promises: promises,
-		ctx:      fctx,
-		cancel:   cancel,
-		pbdata:   pb,
+	dr := &DagReader{
+		node:      n,
+		serv:      serv,
+		buf:       NewRSNCFromBytes(pb.GetData()),
+		promises:  make([]mdag.NodeGetter, len(n.Links)),
+		ctx:       fctx,
+		cancel:    cancel,
+		pbdata:    pb,
+		readAhead: readAhead,
+	}
+	dr.resetFetch()
+	return dr
+}
+
+// SetReadAhead sets the number of bytes of upcoming blocks the reader
+// keeps in flight while reading sequentially. Zero disables read-ahead, so
+// that each block is only requested once it is read.
+func (dr *DagReader) SetReadAhead(n int64) {
+	dr.readAhead = n
+	if dr.window > n {
+		dr.window = n
+	}
+	if child, ok := dr.buf.(*DagReader); ok {
+		child.SetReadAhead(n)
+	}
+}
+
+// resetFetch cancels the requests in flight and shrinks the read-ahead
+// window back to its initial size.
+func (dr *DagReader) resetFetch() {
+	if dr.fetchCancel != nil {
+		dr.fetchCancel()
+	}
+	dr.fetchCtx, dr.fetchCancel = context.WithCancel(dr.ctx)
+	for i := range dr.promises {
+		dr.promises[i] = nil
+	}
+	dr.fetched = 0
+	dr.window = initialReadAhead
+	if dr.window > dr.readAhead {
+		dr.window = dr.readAhead
+	}
+}
+
+// growWindow doubles the read-ahead window, up to the read-ahead limit.
+func (dr *DagReader) growWindow() {
+	dr.window *= 2
+	if dr.window == 0 {
+		dr.window = initialReadAhead
+	}
+	if dr.window > dr.readAhead {
+		dr.window = dr.readAhead
 	}
 }
 
+// prefetch requests the current link, and the upcoming links that fit in
+// the read-ahead window, if they have not been requested yet. To keep
+// requests few, the window is only refilled once less than half of it is
+// left in flight.
+func (dr *DagReader) prefetch() {
+	if dr.fetched < dr.linkPosition {
+		dr.fetched = dr.linkPosition
+	}
+
+	if dr.fetched > dr.linkPosition {
+		inFlight := int64(0)
+		for i := dr.linkPosition + 1; i < dr.fetched; i++ {
+			inFlight += dr.linkSize(i)
+		}
+		if dr.fetched == len(dr.promises) || inFlight >= dr.window/2 {
+			return
+		}
+	}
+
+	end := dr.linkPosition + 1
+	ahead := int64(0)
+	for end < len(dr.promises) && ahead < dr.window {
+		ahead += dr.linkSize(end)
+		end++
+	}
+	if end <= dr.fetched {
+		return
+	}
+
+	batch := &mdag.Node{Links: dr.node.Links[dr.fetched:end]}
+	copy(dr.promises[dr.fetched:end], dr.serv.GetDAG(dr.fetchCtx, batch))
+	dr.fetched = end
+}
+
+// linkSize returns the number of bytes of the file under link i.
+func (dr *DagReader) linkSize(i int) int64 {
+	if i < len(dr.pbdata.Blocksizes) {
+		return int64(dr.pbdata.Blocksizes[i])
+	}
+	return int64(dr.node.Links[i].Size)
+}
+
 // precalcNextBuf follows the next link in line and loads it from the DAGService,
 // setting the next buffer to read from
 func (dr *DagReader) precalcNextBuf(ctx context.Context) error {
@@ -106,12 +217,18 @@ func (dr *DagReader) precalcNextBuf(ctx context.Context) error {
 		return io.EOF
 	}
 
+	dr.prefetch()
 	nxt, err := dr.promises[dr.linkPosition].Get(ctx)
 	if err != nil {
 		return err
 	}
 	dr.linkPosition++
 
+	if nxt.Format == mdag.FormatRaw {
+		dr.buf = NewRSNCFromBytes(nxt.Data)
+		return nil
+	}
+
 	pb := new(ftpb.Data)
 	err = proto.Unmarshal(nxt.Data, pb)
 	if err != nil {
@@ -119,11 +236,11 @@ func (dr *DagReader) precalcNextBuf(ctx context.Context) error {
 	}
 
 	switch pb.GetType() {
-	case ftpb.Data_Directory:
+	case ftpb.Data_Directory, ftpb.Data_HAMTShard:
 		// A directory should not exist within a file
 		return ft.ErrInvalidDirLocation
 	case ftpb.Data_File:
-		dr.buf = newDataFileReader(dr.ctx, nxt, pb, dr.serv)
+		dr.buf = newDataFileReader(dr.ctx, nxt, pb, dr.serv, dr.readAhead)
 		return nil
 	case ftpb.Data_Raw:
 		dr.buf = NewRSNCFromBytes(pb.GetData())
@@ -167,6 +284,7 @@ func (dr *DagReader) CtxReadFull(ctx context.Context, b []byte) (int, error) {
 		}
 
 		// Otherwise, load up the next block
+		dr.growWindow()
 		err = dr.precalcNextBuf(ctx)
 		if err != nil {
 			return total, err
@@ -189,6 +307,7 @@ func (dr *DagReader) WriteTo(w io.Writer) (int64, error) {
 		}
 
 		// Otherwise, load up the next block
+		dr.growWindow()
 		err = dr.precalcNextBuf(dr.ctx)
 		if err != nil {
 			if err == io.EOF {
@@ -218,6 +337,9 @@ func (dr *DagReader) Seek(offset int64, whence int) (int64, error) {
 		// Grab cached protobuf object (solely to make code look cleaner)
 		pb := dr.pbdata
 
+		// whatever was fetched ahead of the old position is of no use
+		dr.resetFetch()
+
 		// left represents the number of bytes remaining to seek to (from beginning)
 		left := offset
 		if int64(len(pb.Data)) >= offset {
@@ -0,0 +1,239 @@
+package io
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
+	dssync "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore/sync"
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	blocks "github.com/ipfs/go-ipfs/blocks"
+	blockstore "github.com/ipfs/go-ipfs/blocks/blockstore"
+	bserv "github.com/ipfs/go-ipfs/blockservice"
+	offline "github.com/ipfs/go-ipfs/exchange/offline"
+	imp "github.com/ipfs/go-ipfs/importer"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	mdag "github.com/ipfs/go-ipfs/merkledag"
+	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
+	delay "github.com/ipfs/go-ipfs/thirdparty/delay"
+	u "github.com/ipfs/go-ipfs/util"
+)
+
+func buildTestFile(t testing.TB, ds mdag.DAGService, size, chunkSize int) ([]byte, *mdag.Node) {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	nd, err := imp.BuildDagFromReader(bytes.NewReader(data), ds, nil, &chunk.SizeSplitter{Size: chunkSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, nd
+}
+
+func TestReadAheadReadsEverything(t *testing.T) {
+	ds := mdtest.Mock(t)
+	data, nd := buildTestFile(t, ds, 1<<20, 1024)
+
+	for _, ra := range []int64{0, 1, 4096, DefaultReadAhead, 1 << 30} {
+		dr, err := NewDagReader(context.Background(), nd, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dr.SetReadAhead(ra)
+
+		out, err := ioutil.ReadAll(dr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Fatalf("read-ahead %d: read the wrong data", ra)
+		}
+		dr.Close()
+	}
+}
+
+func TestReadAheadWindow(t *testing.T) {
+	ds := mdtest.Mock(t)
+	data, nd := buildTestFile(t, ds, 30*1024, 1024)
+	if len(nd.Links) != 30 {
+		t.Fatalf("expected a single level of 30 blocks, got %d", len(nd.Links))
+	}
+
+	dr, err := NewDagReader(context.Background(), nd, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+	dr.SetReadAhead(4 * 1024)
+
+	buf := make([]byte, 10)
+	if _, err := io.ReadFull(dr, buf); err != nil {
+		t.Fatal(err)
+	}
+	if dr.fetched > 6 {
+		t.Fatalf("requested %d blocks to read 10 bytes", dr.fetched)
+	}
+
+	// the window is only refilled as blocks are read
+	if _, err := io.ReadFull(dr, make([]byte, 10*1024)); err != nil {
+		t.Fatal(err)
+	}
+	if dr.fetched > 16 {
+		t.Fatalf("requested %d blocks to read 11 of them", dr.fetched)
+	}
+
+	// seeking drops everything requested before
+	off := int64(20 * 1024)
+	if _, err := dr.Seek(off, os.SEEK_SET); err != nil {
+		t.Fatal(err)
+	}
+	if dr.window > 4*1024 || dr.fetched > 26 {
+		t.Fatalf("seek left window %d, %d blocks requested", dr.window, dr.fetched)
+	}
+	for i := 0; i < 20; i++ {
+		if dr.promises[i] != nil {
+			t.Fatalf("block %d still requested after seeking past it", i)
+		}
+	}
+	if _, err := io.ReadFull(dr, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, data[off:off+10]) {
+		t.Fatal("read the wrong data after seeking")
+	}
+}
+
+func TestReadAheadSeek(t *testing.T) {
+	ds := mdtest.Mock(t)
+	data, nd := buildTestFile(t, ds, 1<<20, 1024)
+
+	dr, err := NewDagReader(context.Background(), nd, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+
+	r := rand.New(rand.NewSource(2))
+	buf := make([]byte, 3000)
+	for i := 0; i < 50; i++ {
+		off := r.Int63n(int64(len(data) - len(buf)))
+		if _, err := dr.Seek(off, os.SEEK_SET); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadFull(dr, buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, data[off:off+int64(len(buf))]) {
+			t.Fatalf("read the wrong data at offset %d", off)
+		}
+	}
+}
+
+// delayExchange is a mock network, serving the blocks of a remote
+// blockstore after one round trip per request.
+type delayExchange struct {
+	remote blockstore.Blockstore
+	rtt    delay.D
+}
+
+func (e *delayExchange) GetBlock(ctx context.Context, k u.Key) (*blocks.Block, error) {
+	e.rtt.Wait()
+	return e.remote.Get(k)
+}
+
+func (e *delayExchange) GetBlocks(ctx context.Context, ks []u.Key) (<-chan *blocks.Block, error) {
+	out := make(chan *blocks.Block)
+	go func() {
+		defer close(out)
+		e.rtt.Wait()
+		for _, k := range ks {
+			blk, err := e.remote.Get(k)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- blk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (e *delayExchange) HasBlock(context.Context, *blocks.Block) error {
+	return nil
+}
+
+func (e *delayExchange) Close() error {
+	return nil
+}
+
+// runReadAheadBenchmark reads a file off a mock network with the given
+// round trip time.
+func runReadAheadBenchmark(b *testing.B, rtt time.Duration, readAhead int64) {
+	const size = 4 << 20
+	b.SetBytes(size)
+
+	remote := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	rbs, err := bserv.New(remote, offline.Exchange(remote))
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, nd := buildTestFile(b, mdag.NewDAGService(rbs), size, 64*1024)
+	k, err := nd.Key()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		// start each read with an empty local blockstore
+		bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+		bs, err := bserv.New(bstore, &delayExchange{remote, delay.Fixed(rtt)})
+		if err != nil {
+			b.Fatal(err)
+		}
+		dserv := mdag.NewDAGService(bs)
+		b.StartTimer()
+
+		root, err := dserv.Get(k)
+		if err != nil {
+			b.Fatal(err)
+		}
+		dr, err := NewDagReader(context.Background(), root, dserv)
+		if err != nil {
+			b.Fatal(err)
+		}
+		dr.SetReadAhead(readAhead)
+		out, err := ioutil.ReadAll(dr)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		if !bytes.Equal(out, data) {
+			b.Fatal("read the wrong data")
+		}
+		dr.Close()
+		bs.Close()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkReadAheadNone(b *testing.B) {
+	runReadAheadBenchmark(b, 5*time.Millisecond, 0)
+}
+
+func BenchmarkReadAheadDefault(b *testing.B) {
+	runReadAheadBenchmark(b, 5*time.Millisecond, DefaultReadAhead)
+}
+
+func BenchmarkReadAheadAll(b *testing.B) {
+	runReadAheadBenchmark(b, 5*time.Millisecond, 1<<40)
+}
@@ -1,14 +1,22 @@
 package io
 
 import (
+	"os"
+
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	format "github.com/ipfs/go-ipfs/unixfs"
+	hamt "github.com/ipfs/go-ipfs/unixfs/hamt"
 	u "github.com/ipfs/go-ipfs/util"
 )
 
+// ShardThreshold is the number of entries past which a directory is
+// sharded into a HAMT.
+var ShardThreshold = 1000
+
 type directoryBuilder struct {
 	dserv   mdag.DAGService
 	dirnode *mdag.Node
+	shard   *hamt.Shard
 }
 
 func NewDirectory(dserv mdag.DAGService) *directoryBuilder {
@@ -19,20 +27,136 @@ func NewDirectory(dserv mdag.DAGService) *directoryBuilder {
 	return db
 }
 
+// SetHashFunc sets the multihash code of the hash function the directory
+// nodes are hashed with.
+func (d *directoryBuilder) SetHashFunc(code int) {
+	d.dirnode.HashFunc = code
+	if d.shard != nil {
+		d.shard.HashFunc = code
+	}
+}
+
 func (d *directoryBuilder) AddChild(name string, k u.Key) error {
 	cnode, err := d.dserv.Get(k)
 	if err != nil {
 		return err
 	}
 
-	err = d.dirnode.AddNodeLinkClean(name, cnode)
+	return d.AddChildNode(name, cnode)
+}
+
+// AddChildNode adds nd to the directory under name. nd must already be
+// stored in the DAGService.
+func (d *directoryBuilder) AddChildNode(name string, nd *mdag.Node) error {
+	if d.shard != nil {
+		lnk, err := mdag.MakeLink(nd)
+		if err != nil {
+			return err
+		}
+		return d.shard.Set(name, lnk)
+	}
+
+	err := d.dirnode.AddNodeLinkClean(name, nd)
+	if err != nil {
+		return err
+	}
+
+	if len(d.dirnode.Links) > ShardThreshold {
+		return d.switchToSharding()
+	}
+	return nil
+}
+
+func (d *directoryBuilder) switchToSharding() error {
+	s, err := hamt.NewShard(d.dserv, hamt.DefaultFanout)
 	if err != nil {
 		return err
 	}
+	s.HashFunc = d.dirnode.HashFunc
 
+	for _, l := range d.dirnode.Links {
+		if err := s.Set(l.Name, l); err != nil {
+			return err
+		}
+	}
+	d.shard = s
 	return nil
 }
 
-func (d *directoryBuilder) GetNode() *mdag.Node {
-	return d.dirnode
+// GetNode returns the directory node. The nodes of a sharded directory's
+// child shards are written to the DAGService; the returned node is not.
+func (d *directoryBuilder) GetNode() (*mdag.Node, error) {
+	if d.shard != nil {
+		return d.shard.Node()
+	}
+	return d.dirnode, nil
+}
+
+// IsDirectory reports whether nd is a unixfs directory, sharded or not.
+func IsDirectory(nd *mdag.Node) bool {
+	if nd.Format != mdag.FormatProtobuf {
+		return false
+	}
+	pbd, err := format.FromBytes(nd.Data)
+	if err != nil {
+		return false
+	}
+	t := pbd.GetType()
+	return t == format.TDirectory || t == format.THAMTShard
+}
+
+// DirectoryLinks returns the links to the entries of the directory nd,
+// named after the entries. The links of a sharded directory are gathered
+// from all of its shards.
+func DirectoryLinks(dserv mdag.DAGService, nd *mdag.Node) ([]*mdag.Link, error) {
+	if !hamt.IsShard(nd) {
+		return nd.Links, nil
+	}
+
+	s, err := hamt.NewShardFromNode(dserv, nd)
+	if err != nil {
+		return nil, err
+	}
+	return s.Links()
+}
+
+// FindDirectoryLink returns the link to the entry name of the directory
+// nd, or mdag.ErrNotFound if it has none.
+func FindDirectoryLink(dserv mdag.DAGService, nd *mdag.Node, name string) (*mdag.Link, error) {
+	if !hamt.IsShard(nd) {
+		for _, l := range nd.Links {
+			if l.Name == name {
+				return l, nil
+			}
+		}
+		return nil, mdag.ErrNotFound
+	}
+
+	s, err := hamt.NewShardFromNode(dserv, nd)
+	if err != nil {
+		return nil, err
+	}
+	lnk, err := s.Find(name)
+	if os.IsNotExist(err) {
+		return nil, mdag.ErrNotFound
+	}
+	return lnk, err
+}
+
+// ResolveUnixfsOnce resolves names within nd as far as its next link,
+// looking the first name up in the shards of a sharded directory. It is
+// meant for the ResolveOnce of a path.Resolver.
+func ResolveUnixfsOnce(dserv mdag.DAGService, nd *mdag.Node, names []string) (interface{}, []string, error) {
+	if !hamt.IsShard(nd) {
+		return nd.Resolve(names)
+	}
+
+	lnk, err := FindDirectoryLink(dserv, nd, names[0])
+	if err == mdag.ErrNotFound {
+		return nil, names, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return lnk, names[1:], nil
 }
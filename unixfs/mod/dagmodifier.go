@@ -46,6 +46,9 @@ type DagModifier struct {
 	wrBuf      *bytes.Buffer
 
 	read *uio.DagReader
+
+	// RawLeaves stores data appended to the file in raw blocks
+	RawLeaves bool
 }
 
 func NewDagModifier(ctx context.Context, from *mdag.Node, serv mdag.DAGService, mp pin.ManualPinner, spl chunk.BlockSplitter) (*DagModifier, error) {
@@ -142,7 +145,7 @@ func (dm *DagModifier) Write(b []byte) (int, error) {
 }
 
 func (dm *DagModifier) Size() (int64, error) {
-	pbn, err := ft.FromBytes(dm.curNode.Data)
+	pbn, err := ft.FromNode(dm.curNode)
 	if err != nil {
 		return 0, err
 	}
@@ -184,7 +187,7 @@ func (dm *DagModifier) Sync() error {
 		return err
 	}
 
-	nd, err := dm.dagserv.Get(thisk)
+	nd, err := dm.dagserv.GetFormat(thisk, dm.curNode.Format)
 	if err != nil {
 		return err
 	}
@@ -225,25 +228,34 @@ func (dm *DagModifier) Sync() error {
 // returns the new key of the passed in node and whether or not all the data in the reader
 // has been consumed.
 func (dm *DagModifier) modifyDag(node *mdag.Node, offset uint64, data io.Reader) (u.Key, bool, error) {
-	f, err := ft.FromBytes(node.Data)
+	f, err := ft.FromNode(node)
 	if err != nil {
 		return "", false, err
 	}
 
 	// If we've reached a leaf node.
 	if len(node.Links) == 0 {
+		if node.Format == mdag.FormatRaw {
+			// raw data is shared with the node, don't write over it
+			f.Data = append([]byte(nil), f.Data...)
+		}
+
 		n, err := data.Read(f.Data[offset:])
 		if err != nil && err != io.EOF {
 			return "", false, err
 		}
 
 		// Update newly written node..
-		b, err := proto.Marshal(f)
-		if err != nil {
-			return "", false, err
+		nd := mdag.NewRawNode(f.Data)
+		if node.Format != mdag.FormatRaw {
+			b, err := proto.Marshal(f)
+			if err != nil {
+				return "", false, err
+			}
+			nd = &mdag.Node{Data: b}
 		}
+		nd.HashFunc = node.HashFunc
 
-		nd := &mdag.Node{Data: b}
 		k, err := dm.dagserv.Add(nd)
 		if err != nil {
 			return "", false, err
@@ -305,9 +317,11 @@ func (dm *DagModifier) modifyDag(node *mdag.Node, offset uint64, data io.Reader)
 // appendData appends the blocks from the given chan to the end of this dag
 func (dm *DagModifier) appendData(node *mdag.Node, blks <-chan []byte) (*mdag.Node, error) {
 	dbp := &help.DagBuilderParams{
-		Dagserv:  dm.dagserv,
-		Maxlinks: help.DefaultLinksPerBlock,
-		Pinner:   dm.mp,
+		Dagserv:   dm.dagserv,
+		Maxlinks:  help.DefaultLinksPerBlock,
+		Pinner:    dm.mp,
+		RawLeaves: dm.RawLeaves,
+		HashFunc:  node.HashFunc,
 	}
 
 	return trickle.TrickleAppend(node, dbp.New(blks))
@@ -442,6 +456,12 @@ func (dm *DagModifier) Truncate(size int64) error {
 // dagTruncate truncates the given node to 'size' and returns the modified Node
 func dagTruncate(nd *mdag.Node, size uint64, ds mdag.DAGService) (*mdag.Node, error) {
 	if len(nd.Links) == 0 {
+		if nd.Format == mdag.FormatRaw {
+			nnd := mdag.NewRawNode(nd.Data[:size])
+			nnd.HashFunc = nd.HashFunc
+			return nnd, nil
+		}
+
 		// TODO: this can likely be done without marshaling and remarshaling
 		pbn, err := ft.FromBytes(nd.Data)
 		if err != nil {
@@ -462,9 +482,12 @@ func dagTruncate(nd *mdag.Node, size uint64, ds mdag.DAGService) (*mdag.Node, er
 			return nil, err
 		}
 
-		childsize, err := ft.DataSize(child.Data)
-		if err != nil {
-			return nil, err
+		childsize := uint64(len(child.Data))
+		if child.Format != mdag.FormatRaw {
+			childsize, err = ft.DataSize(child.Data)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// found the child we want to cut
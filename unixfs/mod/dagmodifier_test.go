@@ -656,3 +656,63 @@ func printDag(nd *mdag.Node, ds mdag.DAGService, indent int) {
 	}
 	fmt.Println("}")
 }
+
+func TestRawLeavesModify(t *testing.T) {
+	dserv, pins := getMockDagServ(t)
+
+	in := io.LimitReader(u.NewTimeSeededRand(), 50000)
+	dbp := h.DagBuilderParams{
+		Dagserv:   dserv,
+		Maxlinks:  h.DefaultLinksPerBlock,
+		Pinner:    pins,
+		RawLeaves: true,
+	}
+	n, err := trickle.TrickleLayout(dbp.New((&chunk.SizeSplitter{Size: 500}).Split(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dr, err := uio.NewDagReader(context.Background(), n, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadAll(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dagmod, err := NewDagModifier(ctx, n, dserv, pins, &chunk.SizeSplitter{Size: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dagmod.RawLeaves = true
+
+	// overwrite within the file, across block boundaries
+	b = testModWrite(t, 1234, 2000, b, dagmod)
+
+	// write past its end
+	b = testModWrite(t, 49000, 5000, b, dagmod)
+
+	err = dagmod.Truncate(12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dagmod.Seek(0, os.SEEK_SET)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(dagmod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = arrComp(out, b[:12345]); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,178 @@
+package tar
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	importer "github.com/ipfs/go-ipfs/importer"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	mdag "github.com/ipfs/go-ipfs/merkledag"
+	"github.com/ipfs/go-ipfs/pin"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+)
+
+// A tar archive is stored as a root node whose data is tarMagic, with one
+// link per archive entry, in archive order. The links are unnamed: links
+// are kept sorted by name, and only links of equal names keep their order.
+// The node of an entry holds the raw bytes of the archive that precede the
+// entry's contents: the padding of the previous entry, and every header
+// block of this one, pax and GNU extension records included. If the
+// entry has contents, they are a unixfs file linked as "data". A last
+// link holds the end of the archive.
+//
+// Concatenating all of it gives back the original archive, byte for byte.
+const tarMagic = "ipfs/tar"
+
+const dataLinkName = "data"
+
+var ErrNotTarchive = errors.New("object is not an ipfs tar archive")
+
+// recorder keeps a copy of everything read from r in w.
+type recorder struct {
+	r io.Reader
+	w io.Writer
+}
+
+func (rec *recorder) Read(b []byte) (int, error) {
+	n, err := rec.r.Read(b)
+	if n > 0 {
+		if _, werr := rec.w.Write(b[:n]); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// ImportTar reads a tar archive from r and stores it in ds, returning its
+// root node. The root node is not added to ds.
+func ImportTar(r io.Reader, ds mdag.DAGService, mp pin.ManualPinner) (*mdag.Node, error) {
+	var raw bytes.Buffer
+	rec := &recorder{r: r, w: &raw}
+	tr := tar.NewReader(rec)
+
+	root := &mdag.Node{Data: []byte(tarMagic)}
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &mdag.Node{Data: append([]byte(nil), raw.Bytes()...)}
+		raw.Reset()
+
+		body, err := importBody(tr, rec, ds, mp)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			if err := entry.AddNodeLinkClean(dataLinkName, body); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := ds.Add(entry); err != nil {
+			return nil, err
+		}
+		if err := root.AddNodeLinkClean("", entry); err != nil {
+			return nil, err
+		}
+	}
+
+	// the end of archive marker, and whatever padding follows it
+	if _, err := ioutil.ReadAll(rec); err != nil {
+		return nil, err
+	}
+	trailer := &mdag.Node{Data: raw.Bytes()}
+	if _, err := ds.Add(trailer); err != nil {
+		return nil, err
+	}
+	if err := root.AddNodeLinkClean("", trailer); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// importBody stores the contents of the current entry of tr as a unixfs
+// file. The file is built from the raw bytes read off the archive, so
+// that they are kept exactly. It returns nil for entries without
+// contents.
+func importBody(tr *tar.Reader, rec *recorder, ds mdag.DAGService, mp pin.ManualPinner) (*mdag.Node, error) {
+	pr, pw := io.Pipe()
+	hdrs := rec.w
+	rec.w = pw
+	defer func() { rec.w = hdrs }()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(ioutil.Discard, tr)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	br := bufio.NewReader(pr)
+	if _, err := br.Peek(1); err != nil {
+		// either the entry is empty, or reading it failed: the copy
+		// tells which
+		return nil, <-done
+	}
+
+	body, err := importer.BuildDagFromReader(br, ds, mp, chunk.DefaultSplitter)
+	pr.CloseWithError(err)
+	if cerr := <-done; cerr != nil {
+		return nil, cerr
+	}
+	return body, err
+}
+
+// ExportTar returns the tar archive stored in root, as it was imported.
+func ExportTar(ctx context.Context, root *mdag.Node, ds mdag.DAGService) (io.Reader, error) {
+	if string(root.Data) != tarMagic {
+		return nil, ErrNotTarchive
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(ctx, pw, root, ds))
+	}()
+	return pr, nil
+}
+
+func writeTar(ctx context.Context, w io.Writer, root *mdag.Node, ds mdag.DAGService) error {
+	for _, lnk := range root.Links {
+		entry, err := lnk.GetNode(ds)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(entry.Data); err != nil {
+			return err
+		}
+
+		for _, elnk := range entry.Links {
+			if elnk.Name != dataLinkName {
+				continue
+			}
+			body, err := elnk.GetNode(ds)
+			if err != nil {
+				return err
+			}
+			dr, err := uio.NewDagReader(ctx, body, ds)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, dr)
+			dr.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
+	importer "github.com/ipfs/go-ipfs/importer"
+	chunk "github.com/ipfs/go-ipfs/importer/chunk"
+	mdag "github.com/ipfs/go-ipfs/merkledag"
+	mdtest "github.com/ipfs/go-ipfs/merkledag/test"
+)
+
+type testEntry struct {
+	hdr  tar.Header
+	body []byte
+}
+
+func makeTar(t *testing.T, entries []testEntry, padding int) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.Size = int64(len(e.body))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(make([]byte, padding))
+	return buf.Bytes()
+}
+
+func roundTrip(t *testing.T, ds mdag.DAGService, archive []byte) *mdag.Node {
+	root, err := ImportTar(bytes.NewReader(archive), ds, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ds.Add(root); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ExportTar(context.Background(), root, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, archive) {
+		t.Fatalf("exported %d bytes differing from the %d imported", len(out), len(archive))
+	}
+	return root
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	big := make([]byte, 1<<20+123)
+	rand.New(rand.NewSource(1)).Read(big)
+	mtime := time.Unix(1443700800, 0)
+
+	entries := []testEntry{
+		{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0750, ModTime: mtime}},
+		{
+			hdr: tar.Header{
+				Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0644, ModTime: mtime,
+				Uid: 1000, Gid: 100, Uname: "someone", Gname: "users",
+			},
+			body: []byte("some contents\n"),
+		},
+		{hdr: tar.Header{Name: "dir/big", Typeflag: tar.TypeReg, Mode: 0600, ModTime: mtime}, body: big},
+		{hdr: tar.Header{Name: "dir/empty", Typeflag: tar.TypeReg, Mode: 0644, ModTime: mtime}},
+		{hdr: tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "file", ModTime: mtime}},
+		{
+			// needs pax records
+			hdr:  tar.Header{Name: "dir/" + strings.Repeat("long-", 40) + "name", Typeflag: tar.TypeReg, Mode: 0644, ModTime: mtime},
+			body: []byte("long name\n"),
+		},
+		{
+			hdr:  tar.Header{Name: "dir/ünïcode", Typeflag: tar.TypeReg, Mode: 0644, ModTime: time.Unix(1443700800, 500)},
+			body: []byte("pax mtime\n"),
+		},
+	}
+
+	ds := mdtest.Mock(t)
+	for _, padding := range []int{0, 9216} {
+		root := roundTrip(t, ds, makeTar(t, entries, padding))
+		if len(root.Links) != len(entries)+1 {
+			t.Fatalf("expected %d links, got %d", len(entries)+1, len(root.Links))
+		}
+	}
+}
+
+func TestTarBodiesAreFiles(t *testing.T) {
+	body := make([]byte, 300000)
+	rand.New(rand.NewSource(2)).Read(body)
+	archive := makeTar(t, []testEntry{{hdr: tar.Header{Name: "f", Typeflag: tar.TypeReg, Mode: 0644}, body: body}}, 0)
+
+	ds := mdtest.Mock(t)
+	root := roundTrip(t, ds, archive)
+
+	entry, err := root.Links[0].GetNode(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := importer.BuildDagFromReader(bytes.NewReader(body), ds, nil, chunk.DefaultSplitter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fk, err := file.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Links) != 1 || string(entry.Links[0].Hash) != string(fk) {
+		t.Fatal("entry contents are not the same dag as the file added alone")
+	}
+}
+
+func TestExportNotTar(t *testing.T) {
+	_, err := ExportTar(context.Background(), &mdag.Node{Data: []byte("nope")}, mdtest.Mock(t))
+	if err != ErrNotTarchive {
+		t.Fatal("exported a node that is not a tar archive")
+	}
+}
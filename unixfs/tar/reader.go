@@ -11,10 +11,10 @@ import (
 	"github.com/ipfs/go-ipfs/Godeps/_workspace/src/golang.org/x/net/context"
 	mdag "github.com/ipfs/go-ipfs/merkledag"
 	path "github.com/ipfs/go-ipfs/path"
+	tarx "github.com/ipfs/go-ipfs/thirdparty/tar"
+	ft "github.com/ipfs/go-ipfs/unixfs"
 	uio "github.com/ipfs/go-ipfs/unixfs/io"
 	upb "github.com/ipfs/go-ipfs/unixfs/pb"
-
-	proto "github.com/ipfs/go-ipfs/Godeps/_workspace/src/code.google.com/p/goprotobuf/proto"
 )
 
 type Reader struct {
@@ -61,8 +61,7 @@ func NewReader(path path.Path, dag mdag.DAGService, resolver *path.Resolver, com
 }
 
 func (r *Reader) writeToBuf(dagnode *mdag.Node, path string, depth int) {
-	pb := new(upb.Data)
-	err := proto.Unmarshal(dagnode.Data, pb)
+	pb, err := ft.FromNode(dagnode)
 	if err != nil {
 		r.emitError(err)
 		return
@@ -72,14 +71,12 @@ func (r *Reader) writeToBuf(dagnode *mdag.Node, path string, depth int) {
 		defer r.close()
 	}
 
-	if pb.GetType() == upb.Data_Directory {
-		err = r.writer.WriteHeader(&tar.Header{
+	if t := pb.GetType(); t == upb.Data_Directory || t == upb.Data_HAMTShard {
+		err = r.writer.WriteHeader(fileHeader(pb, &tar.Header{
 			Name:     path,
 			Typeflag: tar.TypeDir,
 			Mode:     0777,
-			ModTime:  time.Now(),
-			// TODO: set mode, dates, etc. when added to unixFS
-		})
+		}))
 		if err != nil {
 			r.emitError(err)
 			return
@@ -89,25 +86,46 @@ func (r *Reader) writeToBuf(dagnode *mdag.Node, path string, depth int) {
 		ctx, cancel := context.WithTimeout(context.TODO(), time.Second*60)
 		defer cancel()
 
-		for i, ng := range r.dag.GetDAG(ctx, dagnode) {
+		links, err := uio.DirectoryLinks(r.dag, dagnode)
+		if err != nil {
+			r.emitError(err)
+			return
+		}
+		dir := &mdag.Node{Links: links}
+
+		for i, ng := range r.dag.GetDAG(ctx, dir) {
 			childNode, err := ng.Get(ctx)
 			if err != nil {
 				r.emitError(err)
 				return
 			}
-			r.writeToBuf(childNode, gopath.Join(path, dagnode.Links[i].Name), depth+1)
+			r.writeToBuf(childNode, gopath.Join(path, links[i].Name), depth+1)
+		}
+		return
+	}
+
+	if pb.GetType() == upb.Data_Symlink {
+		err = r.writer.WriteHeader(&tar.Header{
+			Name:     path,
+			Linkname: string(pb.GetData()),
+			Typeflag: tar.TypeSymlink,
+			Mode:     0777,
+			ModTime:  time.Now(),
+		})
+		if err != nil {
+			r.emitError(err)
+			return
 		}
+		r.flush()
 		return
 	}
 
-	err = r.writer.WriteHeader(&tar.Header{
+	err = r.writer.WriteHeader(fileHeader(pb, &tar.Header{
 		Name:     path,
 		Size:     int64(pb.GetFilesize()),
 		Typeflag: tar.TypeReg,
 		Mode:     0644,
-		ModTime:  time.Now(),
-		// TODO: set mode, dates, etc. when added to unixFS
-	})
+	}))
 	if err != nil {
 		r.emitError(err)
 		return
@@ -127,6 +145,26 @@ func (r *Reader) writeToBuf(dagnode *mdag.Node, path string, depth int) {
 	}
 }
 
+// fileHeader fills in h with the mode and modification time recorded in
+// pb, and marks them as recorded for the extractor. Without them, h keeps
+// its mode and is dated now.
+func fileHeader(pb *upb.Data, h *tar.Header) *tar.Header {
+	h.PAXRecords = make(map[string]string)
+
+	// unixfs records the POSIX mode bits, as tar does
+	if pb.Mode != nil {
+		h.Mode = int64(pb.GetMode())
+		h.PAXRecords[tarx.PAXRecordedMode] = "1"
+	}
+
+	h.ModTime = time.Now()
+	if mtime, ok := ft.ModTime(pb); ok {
+		h.ModTime = mtime
+		h.PAXRecords[tarx.PAXRecordedMTime] = "1"
+	}
+	return h
+}
+
 func (r *Reader) Read(p []byte) (int, error) {
 	// wait for the goroutine that is writing data to the buffer to tell us
 	// there is something to read
@@ -7,6 +7,8 @@ import (
 	b58 "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-base58"
 	ds "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-datastore"
 	mh "github.com/ipfs/go-ipfs/Godeps/_workspace/src/github.com/jbenet/go-multihash"
+
+	blake2b "github.com/ipfs/go-ipfs/thirdparty/blake2b"
 )
 
 // Key is a string representation of multihash for use with maps.
@@ -118,6 +120,51 @@ func Hash(data []byte) mh.Multihash {
 	return h
 }
 
+// HashFuncs maps the names of the hash functions content may be hashed
+// with to their multihash codes.
+var HashFuncs = map[string]int{
+	"sha1":        mh.SHA1,
+	"sha2-256":    mh.SHA2_256,
+	"sha2-512":    mh.SHA2_512,
+	"sha3":        mh.SHA3,
+	"sha3-512":    mh.SHA3,
+	"blake2b":     mh.BLAKE2B,
+	"blake2b-512": mh.BLAKE2B,
+}
+
+// HashFuncByName returns the multihash code of the named hash function.
+func HashFuncByName(name string) (int, error) {
+	code, ok := HashFuncs[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown hash function %q", name)
+	}
+	return code, nil
+}
+
+// HashWith hashes data with the hash function of the given multihash code.
+// A code of 0 selects the default, SHA2_256.
+func HashWith(data []byte, code int) (mh.Multihash, error) {
+	switch code {
+	case 0, mh.SHA2_256:
+		return Hash(data), nil
+	case mh.BLAKE2B:
+		// not implemented by go-multihash
+		sum := blake2b.Sum512(data)
+		return mh.Encode(sum[:], mh.BLAKE2B)
+	default:
+		return mh.Sum(data, code, -1)
+	}
+}
+
+// HashFunc returns the multihash code of the hash function k was made with.
+func (k Key) HashFunc() (int, error) {
+	dec, err := mh.Decode([]byte(k))
+	if err != nil {
+		return 0, err
+	}
+	return dec.Code, nil
+}
+
 // IsValidHash checks whether a given hash is valid (b58 decodable, len > 0)
 func IsValidHash(s string) bool {
 	out := b58.Decode(s)